@@ -0,0 +1,89 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// fakeserver stands in for a Minecraft server, start it from a start script
+// or as the java binary of a launch profile.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/fakeserver"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/rcon"
+)
+
+var (
+	flavor     = flag.String("flavor", "vanilla", "log format to imitate: vanilla, paper, purpur, forge, neoforge or fabric")
+	version    = flag.String("version", fakeserver.DefaultVersion, "Minecraft version printed in the banner")
+	maxPlayers = flag.Int("max-players", fakeserver.DefaultMaxPlayers, "max players shown by list")
+	bootDelay  = flag.Duration("boot-delay", 500*time.Millisecond, "time between the banner and the Done line")
+	script     = flag.String("script", "", "script of player actions to run once the server is ready")
+	properties = flag.String("properties", "server.properties", "server.properties to read the RCON settings from, missing is fine")
+)
+
+// jvmArgs drops what the JVM would have consumed when the simulator is used
+// as the java binary of a launch profile.
+func jvmArgs(args []string) (rest []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-jar" || arg == "-cp" || arg == "-classpath":
+			i++
+		case strings.HasPrefix(arg, "-X"), strings.HasPrefix(arg, "-D"), strings.HasPrefix(arg, "@"),
+			arg == "nogui", arg == "--nogui", strings.HasPrefix(arg, "-XX:"):
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest
+}
+
+func main() {
+	flag.CommandLine.Parse(jvmArgs(os.Args[1:]))
+	serverFlavor, err := fakeserver.ParseFlavor(*flavor)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	server := fakeserver.NewServer(serverFlavor, os.Stdout)
+	server.Version = *version
+	server.MaxPlayers = *maxPlayers
+	if *script != "" {
+		file, err := os.Open(*script)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		go func() {
+			defer file.Close()
+			if err := server.RunScript(file); err != nil {
+				fmt.Fprintln(os.Stderr, "script:", err)
+			}
+		}()
+	}
+	handleThreadDump(server)
+	go server.Boot(*bootDelay)
+	if config, err := rcon.LoadConfig(*properties); err == nil && config.Enabled {
+		go func() {
+			<-server.Ready()
+			server.ListenRcon(config.Port, config.Password)
+		}()
+	}
+	go server.Run(os.Stdin)
+	os.Exit(server.ExitCode())
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/gamemanager"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/fatih/color"
+)
+
+var (
+	listenAddress = flag.String("listen", manager.DefaultAddress, "listen address, host:port or unix:/path/to/socket")
+	socketMode    = flag.Uint("socket-mode", 0600, "permission of the unix socket file")
+	token         = flag.String("token", "", "shared token required from clients (default $GAMEMANAGER_TOKEN)")
+	tlsCert       = flag.String("tls-cert", "", "TLS certificate file")
+	tlsKey        = flag.String("tls-key", "", "TLS private key file")
+	tlsCA         = flag.String("tls-ca", "", "client CA file, enables mutual TLS")
+	managerConfig = gamemanager.ConfigFlags(flag.CommandLine)
+)
+
+func main() {
+	flag.Parse()
+	transport := &manager.TransportConfig{
+		Address:    *listenAddress,
+		SocketMode: fs.FileMode(*socketMode),
+		Token:      *token,
+		TLSCert:    *tlsCert,
+		TLSKey:     *tlsKey,
+		TLSCA:      *tlsCA,
+	}
+	if transport.Token == "" {
+		transport.Token = os.Getenv("GAMEMANAGER_TOKEN")
+	}
+	config, err := managerConfig()
+	if err != nil {
+		gamemanager.Println(color.RedString("%v", err))
+		os.Exit(1)
+	}
+	managerServer := gamemanager.NewManagerServer(config)
+	serverOptions, err := transport.ServerOptions()
+	if err != nil {
+		gamemanager.Println(color.RedString("加载传输配置失败: %v", err))
+		os.Exit(1)
+	}
+	listener, err := transport.Listen()
+	if err != nil {
+		gamemanager.Println(color.RedString("无法监听 %s: %v", transport.Address, err))
+		os.Exit(1)
+	}
+	if transport.Token == "" {
+		gamemanager.Println(color.RedString("未设置 Token，任何能连接到 %s 的用户都可以控制服务器", transport.Address))
+	}
+	rpcServer := managerServer.NewGRPCServer(serverOptions...)
+	go func() {
+		rpcServer.Serve(listener)
+	}()
+	sysSignals := make(chan os.Signal, 1)
+	signal.Notify(sysSignals, syscall.SIGINT, syscall.SIGTERM)
+	gamemanager.Println(color.YellowString("GameManager已启动, 监听 %s, 等待客户端链接", color.GreenString(transport.Address)))
+	for {
+		<-sysSignals
+		gamemanager.Println(color.RedString("接受到 SIGTERM/SIGINT 信号，正在关闭服务器"))
+
+		managerServer.StopAll()
+		rpcServer.Stop()
+		gamemanager.Println(color.RedString("GameManager已关闭"))
+		os.Exit(0)
+	}
+}
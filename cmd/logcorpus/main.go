@@ -0,0 +1,63 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// logcorpus replays the log corpora of the built-in log profiles through the
+// event parser, or the corpus files given as arguments, named <profile>.log.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core"
+)
+
+func main() {
+	flag.Parse()
+	open := func(name string) (io.ReadCloser, error) { return core.LogCorpus.Open(name) }
+	files, _ := fs.Glob(core.LogCorpus, "logcorpus/*.log")
+	if flag.NArg() > 0 {
+		open = func(name string) (io.ReadCloser, error) { return os.Open(name) }
+		files = flag.Args()
+	}
+	failed := false
+	for _, file := range files {
+		profile := strings.TrimSuffix(filepath.Base(file), ".log")
+		f, err := open(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		errs := core.CheckLogCorpus(profile, f)
+		f.Close()
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		if len(errs) > 0 {
+			failed = true
+			fmt.Printf("FAIL %s\n", profile)
+		} else {
+			fmt.Printf("ok   %s\n", profile)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -15,6 +15,11 @@
 package core
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
 	"regexp"
 	"slices"
 	"strings"
@@ -23,156 +28,466 @@ import (
 
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/rcon"
 	"github.com/fatih/color"
 )
 
+// MinecraftCommandRequest is a batch of commands run back to back under one
+// console lock, usually a single one.
 type MinecraftCommandRequest struct {
-	command  string
-	response chan string
+	ctx      context.Context
+	commands []string
+	response chan []pluginabi.CommandResult
+	source   string
+	priority pluginabi.CommandPriority
+	queuedAt time.Time
 }
 
 type MinecraftCommandProcessor struct {
 	managerClient    *MinecraftPluginManager
-	queue            chan *MinecraftCommandRequest
+	queue            *commandQueue
 	responeReceivers chan string
 	receiverLock     sync.RWMutex
 	index            uint64
 	cleanSignal      chan struct{}
+	sentinelPrefix   string
+	sentinel         *regexp.Regexp
+	sentinelSignal   chan struct{}
+	rcon             *rcon.Pool
+	rconLock         sync.Mutex
+	rconDown         bool
 }
 
 func (mc *MinecraftCommandProcessor) Depends() []string {
 	return nil
 }
 
+func (mc *MinecraftCommandProcessor) Version() string {
+	return "1.0.0"
+}
+
 func (mc *MinecraftCommandProcessor) Println(a ...any) (int, error) {
 	return mc.managerClient.Println(color.MagentaString(mc.DisplayName()), a...)
 }
 
 var UnknownCommand = regexp.MustCompile("Unknown or incomplete command")
 
+// FailedCommand matches the start of the feedback of a command that did
+// nothing.
+var FailedCommand = regexp.MustCompile(`^(?:Incorrect argument for command|No player was found|No entity was found|No targets matched selector|` +
+	`Unknown scoreboard objective|An objective already exists by that name|Can't get value of|Found no elements matching|` +
+	`Unknown (?:item|block|dimension|function|advancement)|That position is not loaded|Expected |Invalid )`)
+
 var SkipWaitCommand []string = []string{"tellraw"}
 var WaitForRegexCommand map[string]*regexp.Regexp = map[string]*regexp.Regexp{"save-all": regexp.MustCompile("Saved"), "testServerReady": UnknownCommand, "list": regexp.MustCompile("players online")}
 
+// SentinelCommand is written after every command, %s is replaced by an id the
+// server repeats in its answer. Everything between the command and that
+// answer is the command's output. Empty falls back to the timing heuristics.
+// The command must not change anything on the server.
+var SentinelCommand = "scoreboard players get %[1]s %[1]s"
+
+// SentinelReply is the whole server message that answers SentinelCommand, %s
+// is replaced by the id.
+var SentinelReply = "Unknown scoreboard objective '%s'"
+
+// SentinelTimeout ends a command whose sentinel never came back, commands in
+// WaitForRegexCommand wait for as long as it takes.
+var SentinelTimeout = 10 * time.Second
+
 func (mc *MinecraftCommandProcessor) RunCommand(command string) (response string) {
-	resp := make(chan string, 1)
-	mc.queue <- &MinecraftCommandRequest{
-		command:  command,
-		response: resp,
+	response, _ = mc.RunCommandContext(context.Background(), command)
+	return response
+}
+
+// RunCommandContext runs command and returns its output. A command whose ctx
+// ends while it is queued is never sent to the server. The lane and source
+// are taken from ctx, see pluginabi.WithCommandPriority.
+func (mc *MinecraftCommandProcessor) RunCommandContext(ctx context.Context, command string) (string, error) {
+	result := mc.RunCommandsContext(ctx, []string{command})[0]
+	return result.Output, result.Err
+}
+
+func (mc *MinecraftCommandProcessor) RunCommands(commands []string) []pluginabi.CommandResult {
+	return mc.RunCommandsContext(context.Background(), commands)
+}
+
+// RunCommandsContext runs commands in order under one console lock, each one
+// gets its own output and error. Once ctx ends the rest of the batch is
+// skipped.
+func (mc *MinecraftCommandProcessor) RunCommandsContext(ctx context.Context, commands []string) []pluginabi.CommandResult {
+	failed := func(err error) []pluginabi.CommandResult {
+		results := make([]pluginabi.CommandResult, len(commands))
+		for i, command := range commands {
+			results[i] = pluginabi.CommandResult{Command: command, Err: err}
+		}
+		return results
+	}
+	if len(commands) == 0 {
+		return nil
 	}
-	return <-resp
+	req := &MinecraftCommandRequest{
+		ctx:      ctx,
+		commands: commands,
+		response: make(chan []pluginabi.CommandResult, 1),
+		source:   pluginabi.CommandSourceFrom(ctx),
+	}
+	req.priority, _ = pluginabi.CommandPriorityFrom(ctx)
+	if req.source == "" {
+		req.source = DefaultCommandSource
+	}
+	if err := mc.queue.push(ctx, req); err != nil {
+		return failed(err)
+	}
+	select {
+	case results := <-req.response:
+		return results
+	case <-ctx.Done():
+		return failed(contextError(ctx))
+	}
+}
+
+// contextError reports an expired deadline as ErrCommandTimeout.
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", pluginabi.ErrCommandTimeout, ctx.Err())
+	}
+	return ctx.Err()
+}
+
+// commandError tells from its output whether the server rejected command.
+func commandError(command string, output string) error {
+	if command == "testServerReady" {
+		return nil
+	}
+	if loc := UnknownCommand.FindStringIndex(output); loc != nil && loc[0] == 0 {
+		return pluginabi.ErrUnknownCommand
+	}
+	if FailedCommand.MatchString(output) {
+		return fmt.Errorf("%w: %s", pluginabi.ErrCommandFailed, strings.SplitN(output, "\n", 2)[0])
+	}
+	return nil
 }
 
 func (mc *MinecraftCommandProcessor) commandResponeProcessor(logText string, _ bool) {
 	mc.receiverLock.RLock()
 	receiver := mc.responeReceivers
+	sentinel, sentinelSignal := mc.sentinel, mc.sentinelSignal
 	mc.receiverLock.RUnlock()
-	if receiver != nil {
-		if (DedicatedServerMessage.MatchString(logText) || !strings.HasPrefix(logText, "[")) && !PlayerMessage.MatchString(logText) &&
-			!PlayerJoinLeaveMessage.MatchString(logText) && !LoginMessage.MatchString(logText) && !PlayerCommandMessage.MatchString(logText) {
-			receiver <- logText
+	message, ok := mc.managerClient.logProfiles.ServerMessage(logText)
+	// a player can say the id too, only the server's own answer counts
+	if ok && sentinel != nil && sentinel.MatchString(message) {
+		select {
+		case sentinelSignal <- struct{}{}:
+		default:
 		}
+		return
 	}
+	if receiver == nil {
+		return
+	}
+	if !ok && strings.HasPrefix(logText, "[") {
+		return
+	}
+	if ok && (PlayerMessage.MatchString(message) || PlayerJoinLeaveMessage.MatchString(message) ||
+		LoginMessage.MatchString(message) || PlayerCommandMessage.MatchString(message)) {
+		return
+	}
+	receiver <- logText
 }
 
 func (mc *MinecraftCommandProcessor) Worker() {
-	for cmd := range mc.queue {
-		var waitRegex *regexp.Regexp
-		var isWaitRegex bool
-		var responseReceiver chan string
-		var cleanSignal chan struct{}
-		commandBuffer := make([]string, 0, 32)
-		_, err := mc.managerClient.Lock()
-		if err != nil {
-			cmd.response <- ""
-			mc.managerClient.Unlock()
-			mc.index++
-			continue
+	for {
+		cmd := mc.queue.pop()
+		skipWait := false
+		if len(cmd.commands) == 1 {
+			command := strings.Split(strings.TrimLeft(cmd.commands[0], "/"), " ")[0]
+			skipWait = slices.Index(SkipWaitCommand, command) >= 0
 		}
-		cmd.command = strings.TrimLeft(cmd.command, "/")
-		command := strings.Split(cmd.command, " ")[0]
-		mc.Println(color.YellowString("正在执行命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(cmd.command), color.YellowString(" 队列中剩余: "), color.RedString("%d", len(mc.queue)))
-		if slices.Index(SkipWaitCommand, command) < 0 {
-			responseReceiver = make(chan string, 32)
-			mc.receiverLock.Lock()
-			mc.responeReceivers = responseReceiver
-			cleanSignal = make(chan struct{})
-			mc.cleanSignal = cleanSignal
-			mc.receiverLock.Unlock()
-			mc.managerClient.Write(&manager.WriteRequest{Id: mc.index, Content: cmd.command})
-		} else {
-			mc.managerClient.Write(&manager.WriteRequest{Id: mc.index, Content: cmd.command})
-			cmd.response <- ""
-			mc.managerClient.Unlock()
-			mc.index++
-			continue
+		if skipWait && cmd.ctx.Err() == nil {
+			// the caller doesn't wait, it can't cancel us either
+			cmd.response <- []pluginabi.CommandResult{{Command: cmd.commands[0]}}
+			cmd.ctx = context.Background()
 		}
-		renewLockTicker := time.NewTicker(5 * time.Second)
-		var endCommandTimer *time.Timer
-		var endCommandChannel <-chan time.Time = nil
-		if waitRegex, isWaitRegex = WaitForRegexCommand[command]; !isWaitRegex {
-			endCommandTimer = time.NewTimer(100 * time.Millisecond)
-			endCommandChannel = endCommandTimer.C
+		results := mc.runBatch(cmd, skipWait)
+		if !skipWait {
+			cmd.response <- results
 		}
+	}
+}
 
-	cmdReceiver:
-		for {
-			select {
-			case <-renewLockTicker.C:
-				mc.managerClient.Lock()
-			case line, ok := <-responseReceiver:
-				if !ok {
-					continue
-				}
-				// queue := len(responseReceiver)
-				if !isWaitRegex {
-					if !endCommandTimer.Stop() {
-						<-endCommandTimer.C
-					}
-					endCommandTimer.Reset(10 * time.Millisecond)
-				}
-				match := DedicatedServerMessage.FindStringSubmatch(line)
-				if len(match) == 2 {
-					commandBuffer = append(commandBuffer, match[1])
-					if !isWaitRegex {
-						mc.Println(color.YellowString("将命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(cmd.command), color.YellowString(" 的输出储存为: "), color.CyanString(match[1]))
-					} else if waitRegex.MatchString(match[1]) {
-						mc.Println(color.YellowString("将命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(cmd.command), color.YellowString(" 的输出储存为: "), color.CyanString(match[1]))
-						endCommandTimer = time.NewTimer(10 * time.Millisecond)
-						endCommandChannel = endCommandTimer.C
-						isWaitRegex = false
-					}
-				} else {
-					if len(commandBuffer) > 0 {
-						commandBuffer = append(commandBuffer, line)
-						mc.Println(color.YellowString("将命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(cmd.command), color.YellowString(" 的输出储存为: "), color.CyanString(line))
+func (mc *MinecraftCommandProcessor) runBatch(cmd *MinecraftCommandRequest, skipWait bool) []pluginabi.CommandResult {
+	results := make([]pluginabi.CommandResult, len(cmd.commands))
+	locked := false
+	defer func() {
+		if locked {
+			mc.managerClient.Unlock()
+		}
+	}()
+	for i, line := range cmd.commands {
+		line = strings.TrimLeft(line, "/")
+		results[i].Command = line
+		if cmd.ctx.Err() != nil {
+			results[i].Err = contextError(cmd.ctx)
+			continue
+		}
+		if output, sent, err := mc.runRcon(cmd, line); sent {
+			results[i].Output, results[i].Err = output, err
+		} else {
+			if !locked {
+				if _, err := mc.managerClient.LockContext(cmd.ctx); err != nil {
+					mc.managerClient.Unlock()
+					if cmd.ctx.Err() != nil {
+						results[i].Err = contextError(cmd.ctx)
+					} else {
+						results[i].Err = fmt.Errorf("%w: %w", pluginabi.ErrLockFailed, err)
 					}
+					mc.index++
+					continue
 				}
-			case <-endCommandChannel:
-				mc.Println(color.BlueString("命令执行结束"), color.YellowString("["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(cmd.command))
-				break cmdReceiver
-			case <-cleanSignal:
-				mc.Println(color.RedString("清理未完成的命令: "), color.YellowString(command))
-				break cmdReceiver
+				locked = true
 			}
+			results[i].Output, results[i].Err = mc.execute(cmd, line, skipWait)
+		}
+		if results[i].Err == nil {
+			results[i].Err = commandError(strings.Split(line, " ")[0], results[i].Output)
 		}
+		mc.index++
+	}
+	return results
+}
+
+// write sends content to the console, a failed write of a stopped server is
+// reported as ErrServerStopped.
+func (mc *MinecraftCommandProcessor) write(content string) error {
+	_, err := mc.managerClient.Write(&manager.WriteRequest{Id: mc.index, Content: content})
+	if err != nil {
+		if status, statusErr := mc.managerClient.getStatus(); statusErr == nil && status.State != manager.MinecraftState_running {
+			return fmt.Errorf("%w: %w", pluginabi.ErrServerStopped, err)
+		}
+	}
+	return err
+}
+
+// execute writes line to the console and collects its output, the console
+// has to be locked.
+func (mc *MinecraftCommandProcessor) execute(cmd *MinecraftCommandRequest, commandLine string, skipWait bool) (string, error) {
+	var waitRegex *regexp.Regexp
+	var isWaitRegex bool
+	var sentinelSignal chan struct{}
+	var sentinelId string
+	command := strings.Split(commandLine, " ")[0]
+	commandBuffer := make([]string, 0, 32)
+	mc.Println(color.YellowString("正在执行命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(commandLine), color.YellowString(" 来源: "), color.BlueString(cmd.source), color.YellowString(" 等待: "), color.RedString("%s", time.Since(cmd.queuedAt).Round(time.Millisecond)), color.YellowString(" 队列中剩余: "), color.RedString("%d", mc.queue.Len()))
+	if skipWait {
+		return "", mc.write(commandLine)
+	}
+	responseReceiver := make(chan string, 32)
+	cleanSignal := make(chan struct{})
+	mc.receiverLock.Lock()
+	mc.responeReceivers = responseReceiver
+	mc.cleanSignal = cleanSignal
+	if SentinelCommand != "" {
+		sentinelSignal = make(chan struct{}, 1)
+		sentinelId = fmt.Sprintf("%s_%d", mc.sentinelPrefix, mc.index)
+		mc.sentinel = regexp.MustCompile("^" + regexp.QuoteMeta(fmt.Sprintf(SentinelReply, sentinelId)) + "$")
+		mc.sentinelSignal = sentinelSignal
+	}
+	mc.receiverLock.Unlock()
+	defer func() {
 		mc.receiverLock.Lock()
 		mc.responeReceivers = nil
 		mc.cleanSignal = nil
+		mc.sentinel = nil
+		mc.sentinelSignal = nil
 		mc.receiverLock.Unlock()
-		renewLockTicker.Stop()
+	}()
+	if err := mc.write(commandLine); err != nil {
+		return "", err
+	}
+	if sentinelSignal != nil {
+		if err := mc.write(fmt.Sprintf(SentinelCommand, sentinelId)); err != nil {
+			return "", err
+		}
+	}
+
+	renewLockTicker := time.NewTicker(5 * time.Second)
+	defer renewLockTicker.Stop()
+	var endCommandTimer *time.Timer
+	var endCommandChannel <-chan time.Time = nil
+	waitRegex, isWaitRegex = WaitForRegexCommand[command]
+	if sentinelSignal != nil {
+		// the sentinel ends the command, the timer only guards against a
+		// server that never answers it. The regex still marks where the
+		// output of a waiting command starts.
+		if !isWaitRegex {
+			endCommandTimer = time.NewTimer(SentinelTimeout)
+			endCommandChannel = endCommandTimer.C
+		}
+	} else if !isWaitRegex {
+		endCommandTimer = time.NewTimer(100 * time.Millisecond)
+		endCommandChannel = endCommandTimer.C
+	}
+	defer func() {
 		if endCommandTimer != nil {
 			endCommandTimer.Stop()
 		}
-		cmd.response <- strings.Join(commandBuffer, "\n")
-		mc.managerClient.Unlock()
-		mc.index++
+	}()
+
+	collect := func(line string) {
+		if message, ok := mc.managerClient.logProfiles.ServerMessage(line); ok {
+			if !isWaitRegex || waitRegex.MatchString(message) {
+				commandBuffer = append(commandBuffer, message)
+				mc.Println(color.YellowString("将命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(commandLine), color.YellowString(" 的输出储存为: "), color.CyanString(message))
+			}
+			if isWaitRegex && waitRegex.MatchString(message) {
+				if sentinelSignal == nil {
+					endCommandTimer = time.NewTimer(10 * time.Millisecond)
+					endCommandChannel = endCommandTimer.C
+				}
+				isWaitRegex = false
+			}
+		} else if len(commandBuffer) > 0 {
+			commandBuffer = append(commandBuffer, line)
+			mc.Println(color.YellowString("将命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(commandLine), color.YellowString(" 的输出储存为: "), color.CyanString(line))
+		}
+	}
+
+	ctxDone := cmd.ctx.Done()
+	for {
+		select {
+		case <-renewLockTicker.C:
+			mc.managerClient.LockContext(cmd.ctx)
+		case line, ok := <-responseReceiver:
+			if !ok {
+				continue
+			}
+			if sentinelSignal == nil && !isWaitRegex {
+				if !endCommandTimer.Stop() {
+					<-endCommandTimer.C
+				}
+				endCommandTimer.Reset(10 * time.Millisecond)
+			}
+			collect(line)
+		case <-sentinelSignal:
+			// lines before the sentinel are already queued in the receiver
+		drain:
+			for {
+				select {
+				case line := <-responseReceiver:
+					collect(line)
+				default:
+					break drain
+				}
+			}
+			mc.Println(color.BlueString("命令执行结束"), color.YellowString("["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(commandLine))
+			return strings.Join(commandBuffer, "\n"), nil
+		case <-endCommandChannel:
+			if sentinelSignal != nil {
+				mc.Println(color.RedString("等待命令结束标记超时, 使用已收到的输出"), color.YellowString("["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(commandLine))
+			} else {
+				mc.Println(color.BlueString("命令执行结束"), color.YellowString("["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(commandLine))
+			}
+			return strings.Join(commandBuffer, "\n"), nil
+		case <-ctxDone:
+			// the caller is gone, but the output still has to be consumed
+			// before the next command starts
+			ctxDone = nil
+			mc.Println(color.RedString("命令已取消: "), color.YellowString(commandLine))
+			if sentinelSignal == nil {
+				return strings.Join(commandBuffer, "\n"), contextError(cmd.ctx)
+			}
+			if endCommandTimer == nil {
+				endCommandTimer = time.NewTimer(SentinelTimeout)
+				endCommandChannel = endCommandTimer.C
+			}
+		case <-cleanSignal:
+			mc.Println(color.RedString("清理未完成的命令: "), color.YellowString(command))
+			return strings.Join(commandBuffer, "\n"), pluginabi.ErrServerStopped
+		}
+	}
+}
+
+// runRcon runs commandLine over RCON, sent is false when the command has to go
+// through the console instead because RCON is off or unreachable.
+func (mc *MinecraftCommandProcessor) runRcon(cmd *MinecraftCommandRequest, commandLine string) (output string, sent bool, err error) {
+	mc.rconLock.Lock()
+	pool := mc.rcon
+	mc.rconLock.Unlock()
+	if pool == nil {
+		return "", false, nil
+	}
+	output, err = pool.ExecContext(cmd.ctx, commandLine)
+	// nothing reached the server, the console can take over
+	if errors.Is(err, rcon.ErrCommandTooLong) {
+		return "", false, nil
+	}
+	if errors.Is(err, rcon.ErrUnavailable) || errors.Is(err, rcon.ErrConnectionStale) || errors.Is(err, rcon.ErrPoolClosed) {
+		if !mc.rconDown {
+			mc.rconDown = true
+			mc.Println(color.RedString("RCON 不可用, 改用控制台执行命令: "), color.YellowString(err.Error()))
+		}
+		return "", false, nil
+	}
+	if err == nil && mc.rconDown {
+		mc.rconDown = false
+		mc.Println(color.GreenString("RCON 已恢复"))
+	}
+	mc.Println(color.YellowString("正在通过 RCON 执行命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(commandLine), color.YellowString(" 来源: "), color.BlueString(cmd.source), color.YellowString(" 等待: "), color.RedString("%s", time.Since(cmd.queuedAt).Round(time.Millisecond)), color.YellowString(" 队列中剩余: "), color.RedString("%d", mc.queue.Len()))
+	if err != nil {
+		mc.Println(color.RedString("RCON 执行命令失败["), color.GreenString("%d", mc.index), color.RedString("]: "), color.YellowString(err.Error()))
+		if cmd.ctx.Err() != nil {
+			err = contextError(cmd.ctx)
+		}
+		return "", true, err
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line != "" {
+			mc.Println(color.YellowString("将命令["), color.GreenString("%d", mc.index), color.YellowString("]: "), color.RedString(commandLine), color.YellowString(" 的输出储存为: "), color.CyanString(line))
+		}
 	}
+	return output, true, nil
+}
+
+// configureRcon reads server.properties and switches to RCON when the server
+// enables it.
+func (mc *MinecraftCommandProcessor) configureRcon() {
+	mc.rconLock.Lock()
+	defer mc.rconLock.Unlock()
+	if mc.rcon != nil {
+		mc.rcon.Close()
+		mc.rcon = nil
+	}
+	if mc.managerClient.DisableRcon {
+		return
+	}
+	path := mc.managerClient.serverProperties()
+	config, err := rcon.LoadConfig(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			mc.Println(color.RedString("无法读取 %s: %v", path, err))
+		}
+		return
+	}
+	if !config.Enabled || config.Password == "" {
+		return
+	}
+	mc.rcon = rcon.NewPool(config.Address, config.Password)
+	mc.rconDown = false
+	mc.Println(color.YellowString("使用 RCON 执行命令: "), color.GreenString(config.Address))
+}
+
+// QueueStats returns the queue depth and wait times per command source.
+func (mc *MinecraftCommandProcessor) QueueStats() []CommandQueueStats {
+	return mc.queue.Stats()
 }
 
 func (mc *MinecraftCommandProcessor) Init(mpm pluginabi.PluginManager) error {
 	mc.managerClient = mpm.(*MinecraftPluginManager)
 	mpm.RegisterLogProcesser(mc, mc.commandResponeProcessor)
-	mc.queue = make(chan *MinecraftCommandRequest, 16384)
+	mc.queue = newCommandQueue(MaxQueuedCommands)
+	// a leftover sentinel of an earlier run must not end one of ours
+	mc.sentinelPrefix = fmt.Sprintf("mpd%06x", rand.Uint32()&0xffffff)
 	go mc.Worker()
 	return nil
 }
@@ -186,9 +501,16 @@ func (mc *MinecraftCommandProcessor) DisplayName() string {
 }
 
 func (mc *MinecraftCommandProcessor) Start() {
+	mc.configureRcon()
 }
 
 func (mc *MinecraftCommandProcessor) Pause() {
+	mc.rconLock.Lock()
+	if mc.rcon != nil {
+		mc.rcon.Close()
+		mc.rcon = nil
+	}
+	mc.rconLock.Unlock()
 	mc.receiverLock.RLock()
 	defer mc.receiverLock.RUnlock()
 	if mc.cleanSignal != nil {
@@ -0,0 +1,54 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestCommandSentinel(t *testing.T) {
+	mpm := &MinecraftPluginManager{}
+	mpm.logProfiles.Fixed = VanillaLogProfile
+	signal := make(chan struct{}, 1)
+	mc := &MinecraftCommandProcessor{
+		managerClient:  mpm,
+		sentinel:       regexp.MustCompile("^" + regexp.QuoteMeta(fmt.Sprintf(SentinelReply, "mpd000001_7")) + "$"),
+		sentinelSignal: signal,
+	}
+	for _, test := range []struct {
+		line string
+		ends bool
+	}{
+		{"[12:00:00] [Server thread/INFO]: <Steve> Unknown scoreboard objective 'mpd000001_7'", false},
+		{"[12:00:00] [Server thread/INFO]: Steve lost connection: mpd000001_7", false},
+		{"[12:00:00] [Server thread/INFO]: Unknown scoreboard objective 'mpd000001_70'", false},
+		{"[12:00:00] [User Authenticator #1/INFO]: Unknown scoreboard objective 'mpd000001_7'", false},
+		{"mpd000001_7", false},
+		{"[12:00:00] [Server thread/INFO]: Unknown scoreboard objective 'mpd000001_7'", true},
+	} {
+		mc.commandResponeProcessor(test.line, false)
+		ended := false
+		select {
+		case <-signal:
+			ended = true
+		default:
+		}
+		if ended != test.ends {
+			t.Errorf("%q ends the command: %v, want %v", test.line, ended, test.ends)
+		}
+	}
+}
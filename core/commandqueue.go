@@ -0,0 +1,204 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+)
+
+const MaxQueuedCommands = 16384
+
+// DefaultCommandSource is the source of commands that don't name one.
+const DefaultCommandSource = "MinecraftManager"
+
+// lanes in the order they are served
+var commandLanes = [...]pluginabi.CommandPriority{pluginabi.PriorityInteractive, pluginabi.PriorityNormal, pluginabi.PriorityBackground}
+
+func laneIndex(priority pluginabi.CommandPriority) int {
+	if i := slices.Index(commandLanes[:], priority); i >= 0 {
+		return i
+	}
+	return laneIndex(pluginabi.PriorityNormal)
+}
+
+// commandLane takes one command per source in turn.
+type commandLane struct {
+	queues map[string][]*MinecraftCommandRequest
+	ring   []string // sources with queued commands
+	next   int
+}
+
+func (cl *commandLane) push(req *MinecraftCommandRequest) {
+	if len(cl.queues[req.source]) == 0 {
+		cl.ring = append(cl.ring, req.source)
+	}
+	cl.queues[req.source] = append(cl.queues[req.source], req)
+}
+
+func (cl *commandLane) pop() *MinecraftCommandRequest {
+	if len(cl.ring) == 0 {
+		return nil
+	}
+	if cl.next >= len(cl.ring) {
+		cl.next = 0
+	}
+	source := cl.ring[cl.next]
+	queue := cl.queues[source]
+	req := queue[0]
+	queue[0] = nil
+	if len(queue) == 1 {
+		delete(cl.queues, source)
+		// the next source moves into this slot
+		cl.ring = slices.Delete(cl.ring, cl.next, cl.next+1)
+	} else {
+		cl.queues[source] = queue[1:]
+		cl.next++
+	}
+	return req
+}
+
+type commandSourceStats struct {
+	queued    [len(commandLanes)]int
+	executed  uint64
+	totalWait time.Duration
+	maxWait   time.Duration
+	lastWait  time.Duration
+}
+
+// CommandQueueStats describes the commands of one source.
+type CommandQueueStats struct {
+	Source   string
+	Queued   map[pluginabi.CommandPriority]int
+	Executed uint64
+	AvgWait  time.Duration // between queueing and execution
+	MaxWait  time.Duration
+	LastWait time.Duration
+}
+
+// commandQueue serves the interactive lane first, then normal, then
+// background, and round robin between the sources within a lane.
+type commandQueue struct {
+	lock    sync.Mutex
+	slots   chan struct{}
+	signal  chan struct{}
+	lanes   [len(commandLanes)]commandLane
+	stats   map[string]*commandSourceStats
+	pending int
+}
+
+func newCommandQueue(size int) *commandQueue {
+	cq := &commandQueue{
+		slots:  make(chan struct{}, size),
+		signal: make(chan struct{}, 1),
+		stats:  make(map[string]*commandSourceStats),
+	}
+	for i := range cq.lanes {
+		cq.lanes[i].queues = make(map[string][]*MinecraftCommandRequest)
+	}
+	return cq
+}
+
+// push blocks while the queue is full.
+func (cq *commandQueue) push(ctx context.Context, req *MinecraftCommandRequest) error {
+	select {
+	case cq.slots <- struct{}{}:
+	case <-ctx.Done():
+		return contextError(ctx)
+	}
+	req.queuedAt = time.Now()
+	lane := laneIndex(req.priority)
+	cq.lock.Lock()
+	cq.lanes[lane].push(req)
+	cq.sourceStats(req.source).queued[lane]++
+	cq.pending++
+	cq.lock.Unlock()
+	select {
+	case cq.signal <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop waits for the next command.
+func (cq *commandQueue) pop() *MinecraftCommandRequest {
+	for {
+		cq.lock.Lock()
+		for i := range cq.lanes {
+			req := cq.lanes[i].pop()
+			if req == nil {
+				continue
+			}
+			wait := time.Since(req.queuedAt)
+			stats := cq.sourceStats(req.source)
+			stats.queued[i]--
+			stats.executed++
+			stats.totalWait += wait
+			stats.lastWait = wait
+			stats.maxWait = max(stats.maxWait, wait)
+			cq.pending--
+			cq.lock.Unlock()
+			<-cq.slots
+			return req
+		}
+		cq.lock.Unlock()
+		<-cq.signal
+	}
+}
+
+func (cq *commandQueue) sourceStats(source string) *commandSourceStats {
+	stats, ok := cq.stats[source]
+	if !ok {
+		stats = &commandSourceStats{}
+		cq.stats[source] = stats
+	}
+	return stats
+}
+
+func (cq *commandQueue) Len() int {
+	cq.lock.Lock()
+	defer cq.lock.Unlock()
+	return cq.pending
+}
+
+func (cq *commandQueue) Stats() (list []CommandQueueStats) {
+	cq.lock.Lock()
+	defer cq.lock.Unlock()
+	for source, stats := range cq.stats {
+		entry := CommandQueueStats{
+			Source:   source,
+			Queued:   make(map[pluginabi.CommandPriority]int),
+			Executed: stats.executed,
+			MaxWait:  stats.maxWait,
+			LastWait: stats.lastWait,
+		}
+		for i, queued := range stats.queued {
+			entry.Queued[commandLanes[i]] = queued
+		}
+		if stats.executed > 0 {
+			entry.AvgWait = stats.totalWait / time.Duration(stats.executed)
+		}
+		list = append(list, entry)
+	}
+	slices.SortFunc(list, func(a, b CommandQueueStats) int {
+		return strings.Compare(a.Source, b.Source)
+	})
+	return list
+}
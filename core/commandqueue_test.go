@@ -0,0 +1,107 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+)
+
+// drain pushes the commands "source:command" in order and returns them in the
+// order the queue hands them out.
+func drain(t *testing.T, priority func(source string) pluginabi.CommandPriority, commands ...string) (order []string) {
+	t.Helper()
+	cq := newCommandQueue(len(commands))
+	for _, command := range commands {
+		source, _, _ := strings.Cut(command, ":")
+		req := &MinecraftCommandRequest{commands: []string{command}, source: source, priority: priority(source)}
+		if err := cq.push(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for cq.Len() > 0 {
+		order = append(order, cq.pop().commands[0])
+	}
+	return order
+}
+
+func normalPriority(string) pluginabi.CommandPriority { return pluginabi.PriorityNormal }
+
+func TestCommandQueueLanes(t *testing.T) {
+	lanes := map[string]pluginabi.CommandPriority{
+		"player": pluginabi.PriorityInteractive,
+		"plugin": pluginabi.PriorityNormal,
+		"backup": pluginabi.PriorityBackground,
+	}
+	order := drain(t, func(source string) pluginabi.CommandPriority { return lanes[source] },
+		"backup:save-off", "plugin:list", "backup:save-all", "player:tp", "plugin:say", "player:tellraw",
+	)
+	want := []string{"player:tp", "player:tellraw", "plugin:list", "plugin:say", "backup:save-off", "backup:save-all"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("order %q, want %q", order, want)
+	}
+}
+
+func TestCommandQueueUnknownPriority(t *testing.T) {
+	order := drain(t, func(source string) pluginabi.CommandPriority {
+		if source == "odd" {
+			return pluginabi.CommandPriority(42)
+		}
+		return pluginabi.PriorityBackground
+	}, "background:a", "odd:b")
+	// an unknown priority is served as normal
+	if want := []string{"odd:b", "background:a"}; !slices.Equal(order, want) {
+		t.Fatalf("order %q, want %q", order, want)
+	}
+}
+
+func TestCommandQueueFairness(t *testing.T) {
+	// a flood of one source doesn't hold back the others in its lane
+	order := drain(t, normalPriority,
+		"flood:1", "flood:2", "flood:3", "flood:4", "a:1", "b:1", "a:2",
+	)
+	want := []string{"flood:1", "a:1", "b:1", "flood:2", "a:2", "flood:3", "flood:4"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("order %q, want %q", order, want)
+	}
+}
+
+func TestCommandQueueFairnessWhileServing(t *testing.T) {
+	cq := newCommandQueue(16)
+	push := func(command string) {
+		source, _, _ := strings.Cut(command, ":")
+		cq.push(context.Background(), &MinecraftCommandRequest{commands: []string{command}, source: source, priority: pluginabi.PriorityNormal})
+	}
+	pop := func() string { return cq.pop().commands[0] }
+	push("a:1")
+	push("a:2")
+	push("b:1")
+	if command := pop(); command != "a:1" {
+		t.Fatalf("first %s, want a:1", command)
+	}
+	// a source that shows up later joins the end of the turn
+	push("c:1")
+	var order []string
+	for cq.Len() > 0 {
+		order = append(order, pop())
+	}
+	if want := []string{"b:1", "c:1", "a:2"}; !slices.Equal(order, want) {
+		t.Fatalf("order %q, want %q", order, want)
+	}
+}
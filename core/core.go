@@ -15,8 +15,14 @@
 package core
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"slices"
@@ -29,7 +35,7 @@ import (
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
 	"github.com/fatih/color"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -37,12 +43,20 @@ type GameManagerMessageBus struct {
 	client   manager.Manager_MessageClient
 	channels []chan *manager.MessageResponse
 	lock     sync.RWMutex
+	seq      uint64 // last received seq, resumed from after reconnecting
 }
 
 type PluginManager struct {
-	started bool
-	inited  bool
-	plugin  pluginabi.Plugin
+	started      bool
+	inited       bool
+	disabled     bool
+	plugin       pluginabi.Plugin
+	seq          uint64 // registration order
+	resources    []pluginResource
+	resourceLock sync.Mutex
+	ctx          context.Context // cancelled when the plugin is unloaded
+	cancel       context.CancelFunc
+	workers      sync.WaitGroup
 }
 
 func (pm *PluginManager) Init(mpm *MinecraftPluginManager) error {
@@ -50,9 +64,13 @@ func (pm *PluginManager) Init(mpm *MinecraftPluginManager) error {
 		return nil
 	}
 	mpm.kPrintln(color.YellowString("加载插件 "), color.BlueString(pm.plugin.DisplayName()))
+	pm.resourceLock.Lock()
+	pm.ctx, pm.cancel = context.WithCancel(context.Background())
+	pm.resourceLock.Unlock()
 	err := pm.plugin.Init(mpm)
 	if err != nil {
 		mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pm.plugin.DisplayName()), color.RedString(" 加载失败: "), color.MagentaString(err.Error()))
+		pm.stop(mpm)
 		return err
 	}
 	pm.inited = true
@@ -64,7 +82,7 @@ func (pm *PluginManager) Init(mpm *MinecraftPluginManager) error {
 }
 
 func (pm *PluginManager) Start() {
-	if pm.plugin != nil && !pm.started {
+	if pm.plugin != nil && pm.inited && !pm.started {
 		pm.started = true
 		pm.plugin.Start()
 	}
@@ -79,32 +97,77 @@ func (pm *PluginManager) Pause() {
 
 var (
 	errGameServerStopped     = fmt.Errorf("minecraft game stop")
+	errGameServerRestarted   = fmt.Errorf("minecraft game restarted by gamemanager")
 	errGrpcChannelDisconnect = fmt.Errorf("grpc disconnected")
 )
 
 type MinecraftPluginManager struct {
 	Repl             *REPLPlugin
 	Address          string
+	Transport        *manager.TransportConfig // Address is filled in by Dial
+	Instance         string                   // GameManager instance to bind, empty for "default"
 	StartScript      string
+	LaunchProfile    *manager.LaunchProfile // used instead of StartScript when set
+	ServerProperties string                 // read for the RCON settings, defaults to the one in the server directory
+	DisableRcon      bool                   // always send commands through the console
+	LogProfile       *LogProfile            // log layout of the server, detected from the banner when nil
 	ClientInfo       *manager.Client
 	client           manager.ManagerClient
 	context          context.Context
 	messageBus       GameManagerMessageBus
+	eventBus         GameEventBus
+	logProfiles      LogProfileSelector
 	errBus           chan error
 	commandProcessor *MinecraftCommandProcessor
 	plugins          map[string]*PluginManager
 	pluginLock       sync.RWMutex
+	pluginSeq        uint64
+	lifecycleLock    sync.Mutex // serializes registering, unloading, starting and pausing plugins
 	minecraftState   manager.MinecraftState
+	autoRestarting   bool
+	lockToken        uint64
+	OnExit           func() // called before the daemon exits from the REPL
 }
 
 func (mpm *MinecraftPluginManager) RunCommand(cmd string) string {
 	return mpm.commandProcessor.RunCommand(cmd)
 }
-func (mpm *MinecraftPluginManager) Lock(opts ...grpc.CallOption) (*emptypb.Empty, error) {
+
+func (mpm *MinecraftPluginManager) RunCommandContext(ctx context.Context, cmd string) (string, error) {
+	return mpm.commandProcessor.RunCommandContext(ctx, cmd)
+}
+
+func (mpm *MinecraftPluginManager) RunCommands(cmds []string) []pluginabi.CommandResult {
+	return mpm.commandProcessor.RunCommands(cmds)
+}
+
+func (mpm *MinecraftPluginManager) RunCommandsContext(ctx context.Context, cmds []string) []pluginabi.CommandResult {
+	return mpm.commandProcessor.RunCommandsContext(ctx, cmds)
+}
+
+func (mpm *MinecraftPluginManager) CommandQueueStats() []CommandQueueStats {
+	return mpm.commandProcessor.QueueStats()
+}
+func (mpm *MinecraftPluginManager) Lock(opts ...grpc.CallOption) (*manager.LockResponse, error) {
+	return mpm.LockContext(mpm.context, opts...)
+}
+
+// LockContext is Lock that leaves the queue of the lock once ctx ends.
+func (mpm *MinecraftPluginManager) LockContext(ctx context.Context, opts ...grpc.CallOption) (*manager.LockResponse, error) {
+	if mpm.ClientInfo == nil {
+		return nil, errGrpcChannelDisconnect
+	}
+	resp, err := mpm.client.Lock(ctx, mpm.ClientInfo, opts...)
+	if err == nil {
+		mpm.lockToken = resp.Token
+	}
+	return resp, err
+}
+func (mpm *MinecraftPluginManager) LockStatus(opts ...grpc.CallOption) (*manager.LockStatusResponse, error) {
 	if mpm.ClientInfo == nil {
 		return nil, errGrpcChannelDisconnect
 	}
-	return mpm.client.Lock(mpm.context, mpm.ClientInfo, opts...)
+	return mpm.client.LockStatus(mpm.context, mpm.ClientInfo, opts...)
 }
 func (mpm *MinecraftPluginManager) Unlock(opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	if mpm.ClientInfo == nil {
@@ -117,6 +180,7 @@ func (mpm *MinecraftPluginManager) Write(wr *manager.WriteRequest, opts ...grpc.
 		return nil, errGrpcChannelDisconnect
 	}
 	wr.Client = mpm.ClientInfo
+	wr.Token = mpm.lockToken
 	return mpm.client.Write(mpm.context, wr, opts...)
 }
 func (mpm *MinecraftPluginManager) Start(st *manager.StartRequest, opts ...grpc.CallOption) (*manager.StatusResponse, error) {
@@ -132,6 +196,24 @@ func (mpm *MinecraftPluginManager) Stop(opts ...grpc.CallOption) (*emptypb.Empty
 	}
 	return mpm.client.Stop(mpm.context, mpm.ClientInfo, opts...)
 }
+func (mpm *MinecraftPluginManager) Shutdown(termTimeout time.Duration, killTimeout time.Duration, opts ...grpc.CallOption) (*manager.StopResponse, error) {
+	if mpm.ClientInfo == nil {
+		return nil, errGrpcChannelDisconnect
+	}
+	return mpm.client.Shutdown(mpm.context, &manager.StopRequest{Client: mpm.ClientInfo, TermTimeout: durationpb.New(termTimeout), KillTimeout: durationpb.New(killTimeout)}, opts...)
+}
+func (mpm *MinecraftPluginManager) Kill(opts ...grpc.CallOption) (*manager.StopResponse, error) {
+	if mpm.ClientInfo == nil {
+		return nil, errGrpcChannelDisconnect
+	}
+	return mpm.client.Kill(mpm.context, mpm.ClientInfo, opts...)
+}
+func (mpm *MinecraftPluginManager) Restart(opts ...grpc.CallOption) (*manager.StopResponse, error) {
+	if mpm.ClientInfo == nil {
+		return nil, errGrpcChannelDisconnect
+	}
+	return mpm.client.Restart(mpm.context, &manager.StopRequest{Client: mpm.ClientInfo}, opts...)
+}
 func (mpm *MinecraftPluginManager) Status(opts ...grpc.CallOption) (*manager.StatusResponse, error) {
 	if mpm.ClientInfo == nil {
 		return nil, errGrpcChannelDisconnect
@@ -139,6 +221,20 @@ func (mpm *MinecraftPluginManager) Status(opts ...grpc.CallOption) (*manager.Sta
 	return mpm.client.Status(mpm.context, mpm.ClientInfo, opts...)
 }
 
+func (mpm *MinecraftPluginManager) Metrics(ctx context.Context, interval time.Duration, opts ...grpc.CallOption) (manager.Manager_MetricsClient, error) {
+	if mpm.ClientInfo == nil {
+		return nil, errGrpcChannelDisconnect
+	}
+	return mpm.client.Metrics(ctx, &manager.MetricsRequest{Client: mpm.ClientInfo, Interval: durationpb.New(interval)}, opts...)
+}
+
+func (mpm *MinecraftPluginManager) ListInstances(opts ...grpc.CallOption) (*manager.InstanceList, error) {
+	if mpm.ClientInfo == nil {
+		return nil, errGrpcChannelDisconnect
+	}
+	return mpm.client.ListInstances(mpm.context, mpm.ClientInfo, opts...)
+}
+
 func (mpm *MinecraftPluginManager) Printf(scope string, format string, a ...any) (n int, err error) {
 	if mpm.Repl != nil && mpm.Repl.terminal != nil {
 		s := fmt.Sprintf(color.YellowString("[")+"%s"+color.YellowString("] ")+strings.TrimRight(format, "\r\n")+"\r\n", append([]any{scope}, a...)...)
@@ -149,6 +245,48 @@ func (mpm *MinecraftPluginManager) Printf(scope string, format string, a ...any)
 	return
 }
 
+// Output returns a writer that prints through the REPL terminal once it is up.
+func (mpm *MinecraftPluginManager) Output() io.Writer {
+	return outputFunc(func(p []byte) (int, error) {
+		if mpm.Repl != nil && mpm.Repl.terminal != nil {
+			return mpm.Repl.terminal.Write(p)
+		}
+		return os.Stdout.Write(p)
+	})
+}
+
+type outputFunc func(p []byte) (int, error)
+
+func (f outputFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// serverProperties returns the path of the server's server.properties.
+func (mpm *MinecraftPluginManager) serverProperties() string {
+	if mpm.ServerProperties != "" {
+		return mpm.ServerProperties
+	}
+	dir := filepath.Dir(mpm.StartScript)
+	if profile := mpm.LaunchProfile; profile != nil {
+		switch {
+		case profile.Workdir != "":
+			dir = profile.Workdir
+		case profile.Jar != "":
+			dir = filepath.Dir(profile.Jar)
+		default:
+			dir = filepath.Dir(profile.ArgsFile)
+		}
+	}
+	return filepath.Join(dir, "server.properties")
+}
+
+func (mpm *MinecraftPluginManager) exit() {
+	if mpm.OnExit != nil {
+		mpm.OnExit()
+	}
+	os.Exit(0)
+}
+
 func (mpm *MinecraftPluginManager) Println(scope string, a ...any) (n int, err error) {
 	return mpm.Printf(scope, "%s", strings.TrimRight(fmt.Sprint(a...), "\r\n"))
 }
@@ -158,15 +296,24 @@ func (mpm *MinecraftPluginManager) kPrintln(a ...any) (n int, err error) {
 }
 
 func (mpm *MinecraftPluginManager) login(waitForReady bool) (err error) {
-	mpm.ClientInfo, err = mpm.client.Login(mpm.context, nil, grpc.WaitForReady(waitForReady))
+	clientInfo, err := mpm.client.Login(mpm.context, nil, grpc.WaitForReady(waitForReady))
 	if err != nil {
 		mpm.kPrintln(color.RedString("获取 Client ID 失败: " + err.Error()))
 		return err
 	}
-	mpm.kPrintln(color.YellowString("从 GameManager 获取 ClientId:%s ", color.GreenString("%d", mpm.ClientInfo.Id)))
+	clientInfo.Instance = mpm.Instance
+	mpm.ClientInfo = clientInfo
+	mpm.kPrintln(color.YellowString("从 GameManager 获取 ClientId:%s ", color.GreenString("%d", mpm.ClientInfo.Id)), color.YellowString("实例: "), color.BlueString(mpm.instanceName()))
 	return nil
 }
 
+func (mpm *MinecraftPluginManager) instanceName() string {
+	if mpm.Instance == "" {
+		return "default"
+	}
+	return mpm.Instance
+}
+
 func (mpm *MinecraftPluginManager) messageForwardWorker() {
 	mpm.kPrintln(color.YellowString("消息转发 Worker 启动"))
 	for {
@@ -178,6 +325,12 @@ func (mpm *MinecraftPluginManager) messageForwardWorker() {
 			}
 			break
 		}
+		if message.Seq != 0 {
+			mpm.messageBus.seq = message.Seq
+		}
+		if gap := message.GetHistoryGap(); gap != nil {
+			mpm.kPrintln(color.RedString("重连期间的部分消息已丢失: "), color.GreenString("%d", gap.Missed))
+		}
 		mpm.messageBus.lock.RLock()
 		for _, channel := range mpm.messageBus.channels {
 			select {
@@ -202,11 +355,14 @@ func (mpm *MinecraftPluginManager) RegisterLogProcesser(context pluginabi.Plugin
 	}
 	mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pluginName), color.YellowString(" 注册了一个日志处理器: "), color.GreenString(GetFunctionName(process)))
 	channel = mpm.RegisterManagerMessageChannel()
+	mpm.TrackResource(context, "log processor", GetFunctionName(process), func() {
+		mpm.closeManagerMessageChannel(channel)
+	})
 	go func() {
 		for msg := range channel {
-			switch msg.Type {
-			case "stdout":
-				process(msg.Content, msg.Locked)
+			switch event := msg.Event.(type) {
+			case *manager.MessageResponse_Log:
+				process(event.Log.Line, msg.Locked)
 			}
 		}
 	}()
@@ -214,7 +370,7 @@ func (mpm *MinecraftPluginManager) RegisterLogProcesser(context pluginabi.Plugin
 }
 
 func (mpm *MinecraftPluginManager) registerServerMessageListener(waitForReady bool) (err error) {
-	mpm.messageBus.client, err = mpm.client.Message(mpm.context, mpm.ClientInfo, grpc.WaitForReady(waitForReady))
+	mpm.messageBus.client, err = mpm.client.Message(mpm.context, &manager.MessageRequest{Id: mpm.ClientInfo.Id, Instance: mpm.ClientInfo.Instance, Since: mpm.messageBus.seq}, grpc.WaitForReady(waitForReady))
 	if err != nil {
 		mpm.kPrintln(color.RedString("无法注册服务消息侦听器，请检查 Backend 是否运行: %s", err.Error()))
 		return err
@@ -240,6 +396,18 @@ func (mpm *MinecraftPluginManager) UnRegisterManagerMessageChannel(channel chan
 	}
 }
 
+// closeManagerMessageChannel unregisters and closes channel unless its owner
+// already unregistered it.
+func (mpm *MinecraftPluginManager) closeManagerMessageChannel(channel chan *manager.MessageResponse) {
+	mpm.messageBus.lock.Lock()
+	defer mpm.messageBus.lock.Unlock()
+	idx := slices.Index(mpm.messageBus.channels, channel)
+	if idx >= 0 {
+		mpm.messageBus.channels = slices.Delete(mpm.messageBus.channels, idx, idx+1)
+		close(channel)
+	}
+}
+
 func (mpm *MinecraftPluginManager) getStatus() (status *manager.StatusResponse, err error) {
 	status, err = mpm.Status()
 	if err != nil {
@@ -250,7 +418,7 @@ func (mpm *MinecraftPluginManager) getStatus() (status *manager.StatusResponse,
 }
 
 func (mpm *MinecraftPluginManager) startMinecraft() (err error) {
-	_, err = mpm.Start(&manager.StartRequest{Client: mpm.ClientInfo, Path: mpm.StartScript})
+	_, err = mpm.Start(&manager.StartRequest{Client: mpm.ClientInfo, Path: mpm.StartScript, Profile: mpm.LaunchProfile})
 	if err != nil {
 		mpm.kPrintln(color.RedString("Minecraft 服务器启动失败: %s", err.Error()))
 		return err
@@ -258,54 +426,41 @@ func (mpm *MinecraftPluginManager) startMinecraft() (err error) {
 	return nil
 }
 
-func (mpm *MinecraftPluginManager) checkDepends(plugin pluginabi.Plugin) bool {
-	if plugin.Depends() == nil {
-		return true
-	}
-	mpm.pluginLock.RLock()
-	defer mpm.pluginLock.RUnlock()
-	for _, depend := range plugin.Depends() {
-		if _, ok := mpm.plugins[depend]; !ok {
-			return false
-		}
-	}
-	return true
-}
-
-func (mpm *MinecraftPluginManager) initDependsSatisfiedPlugins() {
-	mpm.pluginLock.RLock()
-	defer mpm.pluginLock.RUnlock()
-	for _, pm := range mpm.plugins {
-		if pm.inited {
+func (mpm *MinecraftPluginManager) RegisterPlugin(plugin pluginabi.Plugin) (p pluginabi.Plugin, err error) {
+	return plugin, mpm.RegisterPlugins(plugin)
+}
+
+// RegisterPlugins registers a batch of plugins and then initialises every
+// plugin whose dependencies are satisfied, so the order inside the batch does
+// not matter. The ones left blocked are reported. It must not be called from
+// the Init, Start or Pause of a plugin.
+func (mpm *MinecraftPluginManager) RegisterPlugins(plugins ...pluginabi.Plugin) error {
+	mpm.lifecycleLock.Lock()
+	defer mpm.lifecycleLock.Unlock()
+	var batch []string
+	for _, plugin := range plugins {
+		pluginName := plugin.Name()
+		mpm.pluginLock.Lock()
+		if _, ok := mpm.plugins[pluginName]; ok {
+			mpm.pluginLock.Unlock()
+			mpm.kPrintln(color.YellowString("插件 "), color.BlueString(plugin.DisplayName()), color.RedString(" 已经注册"))
 			continue
 		}
-		if mpm.checkDepends(pm.plugin) {
-			pm.Init(mpm)
-		}
-	}
-}
-
-func (mpm *MinecraftPluginManager) RegisterPlugin(plugin pluginabi.Plugin) (p pluginabi.Plugin, err error) {
-	pluginName := plugin.Name()
-	pluginDisplayName := plugin.DisplayName()
-	mpm.pluginLock.Lock()
-	if _, ok := mpm.plugins[pluginName]; !ok {
-		pm := &PluginManager{plugin: plugin}
-		mpm.plugins[pluginName] = pm
+		mpm.pluginSeq++
+		mpm.plugins[pluginName] = &PluginManager{plugin: plugin, seq: mpm.pluginSeq}
 		mpm.pluginLock.Unlock()
-		mpm.kPrintln(color.YellowString("注册新插件 "), color.BlueString(pluginDisplayName))
-		if mpm.checkDepends(plugin) {
-			err = pm.Init(mpm)
-		}
-		if err != nil {
-			return plugin, err
+		mpm.kPrintln(color.YellowString("注册新插件 "), color.BlueString(plugin.DisplayName()))
+		batch = append(batch, pluginName)
+	}
+	res := mpm.loadPlugins()
+	mpm.reportPlugins(res)
+	var errs []error
+	for _, name := range batch {
+		if err, ok := res.failed[name]; ok {
+			errs = append(errs, err)
 		}
-		mpm.initDependsSatisfiedPlugins() // delay init
-	} else {
-		mpm.pluginLock.Unlock()
-		mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pluginDisplayName), color.RedString(" 已经注册"))
 	}
-	return plugin, nil
+	return errors.Join(errs...)
 }
 
 func (mpm *MinecraftPluginManager) GetPlugin(pluginName string) pluginabi.Plugin {
@@ -317,20 +472,36 @@ func (mpm *MinecraftPluginManager) GetPlugin(pluginName string) pluginabi.Plugin
 	return nil
 }
 
-func (mpm *MinecraftPluginManager) pluginStart() {
+// registeredPlugins returns the plugins in registration order.
+func (mpm *MinecraftPluginManager) registeredPlugins() []*PluginManager {
 	mpm.pluginLock.RLock()
-	for _, plugin := range mpm.plugins {
+	defer mpm.pluginLock.RUnlock()
+	return slices.SortedFunc(maps.Values(mpm.plugins), func(a, b *PluginManager) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+}
+
+// pluginStart marks the server as running and starts the loaded plugins.
+func (mpm *MinecraftPluginManager) pluginStart() {
+	mpm.lifecycleLock.Lock()
+	defer mpm.lifecycleLock.Unlock()
+	mpm.minecraftState = manager.MinecraftState_running
+	for _, plugin := range mpm.registeredPlugins() {
 		plugin.Start()
 	}
-	mpm.pluginLock.RUnlock()
 }
 
-func (mpm *MinecraftPluginManager) pluginPause() {
-	mpm.pluginLock.RLock()
-	for _, plugin := range mpm.plugins {
+// pluginPause pauses the plugins, stopped also keeps the ones loaded
+// meanwhile from being started.
+func (mpm *MinecraftPluginManager) pluginPause(stopped bool) {
+	mpm.lifecycleLock.Lock()
+	defer mpm.lifecycleLock.Unlock()
+	if stopped {
+		mpm.minecraftState = manager.MinecraftState_stopped
+	}
+	for _, plugin := range mpm.registeredPlugins() {
 		plugin.Pause()
 	}
-	mpm.pluginLock.RUnlock()
 }
 
 func (mpm *MinecraftPluginManager) StartMinecraft() (err error) {
@@ -364,26 +535,46 @@ func (mpm *MinecraftPluginManager) StartMinecraft() (err error) {
 		mpm.UnRegisterManagerMessageChannel(minecraftStartingLog)
 		close(minecraftStartingLog)
 	}
-	mpm.minecraftState = manager.MinecraftState_running
+	mpm.seedOnlinePlayers()
 	mpm.kPrintln(color.YellowString("通知插件 Minecraft 启动完成"))
 	mpm.pluginStart()
 	return nil
 }
 
+// seedOnlinePlayers tells the event parser who joined before the daemon
+// connected, their kills would count as mob kills otherwise.
+func (mpm *MinecraftPluginManager) seedOnlinePlayers() {
+	output, err := mpm.RunCommandContext(context.Background(), "list")
+	if err != nil {
+		mpm.kPrintln(color.RedString("无法获取在线玩家列表: "), color.YellowString(err.Error()))
+		return
+	}
+	players, ok := ParsePlayerList(output)
+	if !ok {
+		mpm.kPrintln(color.RedString("无法解析在线玩家列表: "), color.YellowString(output))
+		return
+	}
+	mpm.eventBus.parser.SeedOnline(players)
+	if len(players) > 0 {
+		mpm.kPrintln(color.YellowString("已在线的玩家: "), color.GreenString(strings.Join(players, ", ")))
+	}
+}
+
 func (mpm *MinecraftPluginManager) initPlugin() (err error) {
-	mpm.kPrintln(color.YellowString("正在注册命令处理器"))
-	mpm.commandProcessor = &MinecraftCommandProcessor{}
-	mpm.RegisterPlugin(mpm.commandProcessor)
 	mpm.kPrintln(color.YellowString("正在加载内置插件"))
+	mpm.commandProcessor = &MinecraftCommandProcessor{}
 	// repl
 	mpm.Repl = &REPLPlugin{}
-	mpm.RegisterPlugin(mpm.Repl)
-
-	mpm.RegisterPlugin(&plugin.ScoreboardCore{})
-	mpm.RegisterPlugin(&plugin.TellrawManager{})
-	mpm.RegisterPlugin(&plugin.PlayerInfo{})
-	mpm.RegisterPlugin(&plugin.TeleportCore{})
-	mpm.RegisterPlugin(&plugin.SimpleCommand{})
+	mpm.RegisterPlugins(
+		mpm.commandProcessor,
+		mpm.Repl,
+		&plugin.ScoreboardCore{},
+		&plugin.TellrawManager{},
+		&plugin.PlayerInfo{},
+		&plugin.TeleportCore{},
+		&plugin.SimpleCommand{},
+		&PluginAdminPlugin{},
+	)
 	return
 }
 
@@ -402,11 +593,36 @@ func (mpm *MinecraftPluginManager) initClient(waitForReady bool) (err error) {
 }
 func (mpm *MinecraftPluginManager) monitorGameStopWorker(message chan *manager.MessageResponse) {
 	for msg := range message {
-		switch msg.Type {
-		case "StateChange":
-			switch msg.Content {
-			case "GameServerStop":
-				mpm.errBus <- errGameServerStopped
+		if report := msg.GetCrashReport(); report != nil {
+			mpm.kPrintln(color.RedString("崩溃报告: "), color.YellowString(report.Description), color.RedString(" 异常: "), color.YellowString(report.Exception), color.RedString(" 嫌疑: "), color.YellowString(report.Suspect))
+			continue
+		}
+		if hung := msg.GetHung(); hung != nil {
+			mpm.kPrintln(color.RedString("Minecraft 服务器已 %s 无响应", hung.Silent.AsDuration().Round(time.Second)), color.YellowString(" 线程转储: "), color.GreenString(hung.ThreadDump))
+			continue
+		}
+		state := msg.GetState()
+		if state == nil {
+			continue
+		}
+		switch state.Reason {
+		case manager.StateReason_server_stopped:
+			mpm.errBus <- errGameServerStopped
+		case manager.StateReason_server_crashed:
+			mpm.kPrintln(color.RedString("Minecraft 服务器崩溃, 退出码: "), color.GreenString("%d", state.ExitCode))
+		case manager.StateReason_server_restarting:
+			mpm.kPrintln(color.YellowString("GameManager 正在自动重启 Minecraft 服务器"))
+			mpm.autoRestarting = true
+		case manager.StateReason_restart_limit_reached:
+			mpm.kPrintln(color.RedString("GameManager 自动重启次数已达上限"))
+			mpm.autoRestarting = false
+		case manager.StateReason_restart_failed:
+			mpm.kPrintln(color.RedString("GameManager 自动重启失败"))
+			mpm.autoRestarting = false
+		case manager.StateReason_server_started:
+			if mpm.autoRestarting {
+				mpm.autoRestarting = false
+				mpm.errBus <- errGameServerRestarted
 			}
 		}
 	}
@@ -417,10 +633,12 @@ func (mpm *MinecraftPluginManager) errorHandler() {
 		switch err {
 		case errGameServerStopped:
 			mpm.kPrintln(color.RedString("服务器关闭，请求停止插件"))
-			mpm.pluginPause()
+			mpm.pluginPause(true)
+		case errGameServerRestarted:
+			go mpm.StartMinecraft()
 		case errGrpcChannelDisconnect:
 			mpm.ClientInfo = nil
-			mpm.pluginPause()
+			mpm.pluginPause(false)
 			go func() {
 				for {
 					err := mpm.initClient(true)
@@ -454,6 +672,7 @@ func (mpm *MinecraftPluginManager) initManager() (err error) {
 		return err
 	}
 	mpm.initErrorHandler()
+	mpm.initEventBus()
 	err = mpm.initPlugin()
 	if err != nil {
 		return err
@@ -480,12 +699,27 @@ func (mpm *MinecraftPluginManager) Init() *MinecraftPluginManager {
 func (mpm *MinecraftPluginManager) Dial(server string) (err error) {
 	mpm.Init()
 	mpm.Address = server
-	conn, err := grpc.NewClient(mpm.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if mpm.Transport == nil {
+		mpm.Transport = &manager.TransportConfig{}
+	}
+	mpm.Transport.Address = server
+	dialOptions, err := mpm.Transport.DialOptions()
+	if err != nil {
+		mpm.kPrintln(color.RedString("加载传输配置失败: %s", err.Error()))
+		return err
+	}
+	conn, err := grpc.NewClient(mpm.Transport.Target(), dialOptions...)
 	if err != nil {
 		mpm.kPrintln(color.RedString("无法连接上 Manager Backend，请检查 Backend 是否运行: %s", err.Error()))
 		return err
 	}
-	mpm.client = manager.NewManagerClient(conn)
+	return mpm.Connect(conn)
+}
 
+// Connect starts the manager on an established connection, such as the one of
+// an embedded GameManager.
+func (mpm *MinecraftPluginManager) Connect(conn grpc.ClientConnInterface) (err error) {
+	mpm.Init()
+	mpm.client = manager.NewManagerClient(conn)
 	return mpm.initManager()
 }
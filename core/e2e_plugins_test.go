@@ -0,0 +1,186 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/fakeserver"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/plugins"
+)
+
+// scriptPlugin records what the scripted players do through the core plugins.
+type scriptPlugin struct {
+	plugin.BasePlugin
+	started  chan struct{}
+	once     sync.Once
+	joined   chan string
+	commands chan []string
+	triggers chan int
+}
+
+func newScriptPlugin() *scriptPlugin {
+	return &scriptPlugin{
+		started:  make(chan struct{}),
+		joined:   make(chan string, 1),
+		commands: make(chan []string, 1),
+		triggers: make(chan int, 1),
+	}
+}
+
+func (sp *scriptPlugin) Name() string        { return "ScriptProbe" }
+func (sp *scriptPlugin) DisplayName() string { return "ScriptProbe" }
+
+func (sp *scriptPlugin) Init(pm pluginabi.PluginManager) error {
+	if err := sp.BasePlugin.Init(pm, sp); err != nil {
+		return err
+	}
+	pluginabi.Subscribe(pm, sp, func(e pluginabi.PlayerJoined) { sp.joined <- e.Player })
+	return sp.RegisterCommand("ping", func(player string, args ...string) {
+		sp.commands <- append([]string{player}, args...)
+	})
+}
+
+func (sp *scriptPlugin) Start() {
+	sp.once.Do(func() { close(sp.started) })
+}
+
+func receive[T any](t *testing.T, channel chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-channel:
+		return v
+	case <-time.After(10 * time.Second):
+		t.Fatalf("%s did not happen", what)
+		panic("unreachable")
+	}
+}
+
+// eventually runs command until its output contains want.
+func eventually(t *testing.T, mpm *core.MinecraftPluginManager, command string, want string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		output := mpm.RunCommand(command)
+		if strings.Contains(output, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s = %q, want %q", command, output, want)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// savedLastLocation reads the last location of player PlayerInfo committed to
+// data/playerinfo.json.
+func savedLastLocation(player string) *plugin.MinecraftPosition {
+	data, err := os.ReadFile(filepath.Join("data", "playerinfo.json"))
+	if err != nil {
+		return nil
+	}
+	var saved struct {
+		PlayerInfo map[string]struct{ LastLocation *plugin.MinecraftPosition }
+	}
+	if json.Unmarshal(data, &saved) != nil {
+		return nil
+	}
+	return saved.PlayerInfo[player].LastLocation
+}
+
+func TestEndToEndPlugins(t *testing.T) {
+	// PlayerInfo keeps its data in the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data", "playerinfo.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	probe := newScriptPlugin()
+	mpm := core.StartFakeServer(t, fakeserver.Vanilla, "join Steve\nmove Steve 10 70 -5\n", probe, &plugins.BackPlugin{})
+	receive(t, probe.started, "plugin start")
+	if player := receive(t, probe.joined, "PlayerJoined"); player != "Steve" {
+		t.Fatalf("PlayerJoined.Player = %q, want Steve", player)
+	}
+	// later steps depend on what the plugins did, the fake console runs them
+	// as script actions in order
+	fake := func(action string) { mpm.RunCommand("fake " + action) }
+
+	t.Run("SimpleCommand", func(t *testing.T) {
+		fake("chat Steve !!ping a b")
+		if got := receive(t, probe.commands, "!!ping"); strings.Join(got, " ") != "Steve a b" {
+			t.Fatalf("!!ping called with %q, want Steve a b", got)
+		}
+	})
+
+	t.Run("ScoreboardCore", func(t *testing.T) {
+		name := probe.RegisterTrigger(plugin.MinecraftTrigger{Trigger: func(player string, value int) {
+			if player == "Steve" {
+				probe.triggers <- value
+			}
+		}})
+		fake("trigger Steve " + name + " set 3")
+		if value := receive(t, probe.triggers, "trigger set"); value != 3 {
+			t.Fatalf("trigger set 3 = %d", value)
+		}
+		// the trigger is enabled again before it is handed out
+		fake("trigger Steve " + name + " add 2")
+		if value := receive(t, probe.triggers, "trigger add"); value != 2 {
+			t.Fatalf("trigger add 2 = %d", value)
+		}
+	})
+
+	t.Run("BackPlugin", func(t *testing.T) {
+		fake("die Steve fell from a high place")
+		// BackPlugin reads DeathTime and LastDeathLocation with data get entity
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			if location := savedLastLocation("Steve"); location != nil {
+				if location.Position != [3]float64{10, 70, -5} {
+					t.Fatalf("death location %v, want [10 70 -5]", location.Position)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("BackPlugin did not save the death location")
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		fake("respawn Steve")
+		eventually(t, mpm, "data get entity Steve Pos", "[0.5d, 64.0d, 0.5d]")
+		// !!back teleports through TeleportCore
+		fake("chat Steve !!back")
+		eventually(t, mpm, "data get entity Steve Pos", "[10.0d, 70.0d, -5.0d]")
+	})
+}
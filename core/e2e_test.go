@@ -0,0 +1,186 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/fakeserver"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/gamemanager"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+)
+
+// the test binary doubles as the java binary of the launch profile
+const (
+	fakeServerEnv       = "DAEMON_TEST_FAKESERVER"
+	fakeServerScriptEnv = "DAEMON_TEST_FAKESERVER_SCRIPT"
+)
+
+func TestMain(m *testing.M) {
+	if flavor := os.Getenv(fakeServerEnv); flavor != "" {
+		os.Exit(runFakeServer(fakeserver.Flavor(flavor), os.Getenv(fakeServerScriptEnv)))
+	}
+	os.Exit(m.Run())
+}
+
+func runFakeServer(flavor fakeserver.Flavor, script string) int {
+	server := fakeserver.NewServer(flavor, os.Stdout)
+	if script != "" {
+		go server.RunScript(strings.NewReader(script))
+	}
+	go server.Boot(100 * time.Millisecond)
+	go server.Run(os.Stdin)
+	return server.ExitCode()
+}
+
+// probePlugin records the events and the start of the plugins.
+type probePlugin struct {
+	started chan struct{}
+	once    sync.Once
+	joined  chan pluginabi.PlayerJoined
+}
+
+func (p *probePlugin) Name() string        { return "Probe" }
+func (p *probePlugin) DisplayName() string { return "Probe" }
+func (p *probePlugin) Depends() []string   { return nil }
+func (p *probePlugin) Version() string     { return "1.0.0" }
+func (p *probePlugin) Pause()              {}
+
+func (p *probePlugin) Init(pm pluginabi.PluginManager) error {
+	pluginabi.Subscribe(pm, p, func(e pluginabi.PlayerJoined) { p.joined <- e })
+	return nil
+}
+
+func (p *probePlugin) Start() {
+	p.once.Do(func() { close(p.started) })
+}
+
+// startFakeServer runs the daemon with an embedded GameManager against a fake
+// server that plays script once it is ready.
+func startFakeServer(t *testing.T, flavor fakeserver.Flavor, script string, plugins ...pluginabi.Plugin) *MinecraftPluginManager {
+	t.Helper()
+	dir := t.TempDir()
+	jar := filepath.Join(dir, "server.jar")
+	if err := os.WriteFile(jar, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedded := gamemanager.NewEmbedded(gamemanager.ManagerConfig{})
+	t.Cleanup(embedded.Close)
+	conn, err := embedded.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mpm := &MinecraftPluginManager{
+		Instance:    "e2e",
+		DisableRcon: true,
+		LaunchProfile: &manager.LaunchProfile{
+			Java: executable,
+			Jar:  jar,
+			Env:  []string{fakeServerEnv + "=" + string(flavor), fakeServerScriptEnv + "=" + script},
+		},
+	}
+	if err := mpm.Connect(conn); err != nil {
+		t.Fatal(err)
+	}
+	if err := mpm.RegisterPlugins(plugins...); err != nil {
+		t.Fatal(err)
+	}
+	return mpm
+}
+
+func TestEndToEndFakeServer(t *testing.T) {
+	probe := &probePlugin{started: make(chan struct{}), joined: make(chan pluginabi.PlayerJoined, 1)}
+	mpm := startFakeServer(t, fakeserver.Paper, "wait 200ms\njoin Steve\n", probe)
+
+	select {
+	case <-probe.started:
+	case <-time.After(20 * time.Second):
+		t.Fatal("plugins were not started")
+	}
+	select {
+	case e := <-probe.joined:
+		if e.Player != "Steve" {
+			t.Fatalf("PlayerJoined.Player = %q, want Steve", e.Player)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("PlayerJoined was not delivered")
+	}
+	if profile := mpm.logProfiles.Profile(); profile != PaperLogProfile {
+		t.Errorf("log profile %v, want paper", profile)
+	}
+
+	// tellraw is not waited for, list still gets only its own output
+	if output := mpm.RunCommand(`tellraw @a {"text":"hello"}`); output != "" {
+		t.Errorf("tellraw = %q, want no output", output)
+	}
+	output := mpm.RunCommand("list")
+	if want := "There are 1 of a max of 20 players online: Steve"; !strings.Contains(output, want) {
+		t.Fatalf("list = %q, want %q", output, want)
+	}
+}
+
+// waitStarted waits until the plugins were told the server is ready.
+func waitStarted(t *testing.T, probe *probePlugin) {
+	t.Helper()
+	select {
+	case <-probe.started:
+	case <-time.After(20 * time.Second):
+		t.Fatal("plugins were not started")
+	}
+}
+
+func TestCommandLockTimeout(t *testing.T) {
+	probe := &probePlugin{started: make(chan struct{}), joined: make(chan pluginabi.PlayerJoined, 1)}
+	mpm := startFakeServer(t, fakeserver.Vanilla, "", probe)
+	waitStarted(t, probe)
+
+	// let the plugins finish their startup commands
+	mpm.RunCommand("list")
+	// another client holds the console
+	other := &manager.Client{Id: mpm.ClientInfo.Id + 1000, Instance: mpm.Instance}
+	if _, err := mpm.client.Lock(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := mpm.RunCommandContext(ctx, "scoreboard objectives add late dummy"); !errors.Is(err, pluginabi.ErrCommandTimeout) {
+		t.Fatalf("command behind a held lock: %v, want %v", err, pluginabi.ErrCommandTimeout)
+	}
+	// the worker has left the lock queue, the command must not run once the
+	// console is free again
+	time.Sleep(100 * time.Millisecond)
+	if _, err := mpm.client.Unlock(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	output, err := mpm.RunCommandContext(context.Background(), "scoreboard objectives list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(output, "late") {
+		t.Fatalf("timed out command ran after all: %q", output)
+	}
+}
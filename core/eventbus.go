@@ -0,0 +1,120 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"slices"
+	"sync"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"github.com/fatih/color"
+)
+
+const MaxQueuedEvents = 1024
+
+type gameEventHandler struct {
+	plugin  string
+	event   string
+	channel chan pluginabi.GameEvent
+}
+
+// GameEventBus parses the server log once and hands the events to the
+// handlers of their type.
+type GameEventBus struct {
+	parser   GameEventParser
+	handlers map[string][]*gameEventHandler
+	lock     sync.RWMutex
+	started  bool
+}
+
+func (mpm *MinecraftPluginManager) RegisterEventHandler(context pluginabi.PluginName, event string, handler func(pluginabi.GameEvent)) (unsubscribe func()) {
+	var pluginName string
+	if context == nil {
+		pluginName = "anonymous"
+	} else {
+		pluginName = context.DisplayName()
+	}
+	mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pluginName), color.YellowString(" 订阅了游戏事件: "), color.GreenString(event))
+	h := &gameEventHandler{plugin: pluginName, event: event, channel: make(chan pluginabi.GameEvent, MaxQueuedEvents)}
+	bus := &mpm.eventBus
+	bus.lock.Lock()
+	if bus.handlers == nil {
+		bus.handlers = make(map[string][]*gameEventHandler)
+	}
+	bus.handlers[event] = append(bus.handlers[event], h)
+	bus.lock.Unlock()
+	go func() {
+		for e := range h.channel {
+			handler(e)
+		}
+	}()
+	var once sync.Once
+	unsubscribe = func() {
+		once.Do(func() {
+			bus.lock.Lock()
+			defer bus.lock.Unlock()
+			bus.handlers[event] = slices.DeleteFunc(bus.handlers[event], func(x *gameEventHandler) bool {
+				return x == h
+			})
+			close(h.channel)
+		})
+	}
+	mpm.TrackResource(context, "event handler", event, unsubscribe)
+	return unsubscribe
+}
+
+func (mpm *MinecraftPluginManager) publishEvent(event pluginabi.GameEvent) {
+	bus := &mpm.eventBus
+	bus.lock.RLock()
+	defer bus.lock.RUnlock()
+	for _, h := range bus.handlers[event.EventName()] {
+		select {
+		case h.channel <- event:
+		default:
+			mpm.kPrintln(color.RedString("插件 "), color.BlueString(h.plugin), color.RedString(" 的事件队列已满, 丢弃事件: "), color.GreenString(event.EventName()))
+		}
+	}
+}
+
+func (mpm *MinecraftPluginManager) gameEventWorker(channel chan *manager.MessageResponse) {
+	for msg := range channel {
+		log := msg.GetLog()
+		if log == nil {
+			continue
+		}
+		profile := mpm.logProfiles.Profile()
+		event := mpm.eventBus.parser.Parse(log.Line)
+		if detected := mpm.logProfiles.Profile(); detected != profile {
+			mpm.kPrintln(color.YellowString("检测到服务端日志格式: "), color.GreenString(detected.Name))
+		}
+		if event != nil {
+			mpm.publishEvent(event)
+		}
+	}
+}
+
+func (mpm *MinecraftPluginManager) initEventBus() {
+	if mpm.eventBus.started {
+		return
+	}
+	mpm.eventBus.started = true
+	mpm.logProfiles.Fixed = mpm.LogProfile
+	mpm.eventBus.parser.Profiles = &mpm.logProfiles
+	if mpm.LogProfile != nil {
+		mpm.kPrintln(color.YellowString("使用服务端日志格式: "), color.GreenString(mpm.LogProfile.Name))
+	}
+	go mpm.gameEventWorker(mpm.RegisterManagerMessageChannel())
+}
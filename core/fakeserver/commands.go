@@ -0,0 +1,671 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeserver
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	msgNoEntity       = "No entity was found"
+	msgNoPlayer       = "No player was found"
+	msgPlayerRequired = "A player is required to run this command here"
+)
+
+// Command runs a console command the way the dedicated server would and
+// prints its feedback. Lines starting with "fake " are script actions.
+func (s *Server) Command(line string) {
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "/"))
+	if line == "" {
+		return
+	}
+	if action, ok := strings.CutPrefix(line, "fake "); ok {
+		if err := s.Do(action); err != nil {
+			s.warn("fake: " + err.Error())
+		}
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.execute(line, nil, "")
+}
+
+// execute runs line as executor, in dimension when it is set by execute in.
+func (s *Server) execute(line string, executor *Player, dimension string) {
+	args := strings.Fields(line)
+	switch args[0] {
+	case "list":
+		s.list()
+	case "save-all":
+		s.info("Saving the game (this may take a moment!)")
+		s.info("Saved the game")
+	case "save-off":
+		s.info("Automatic saving is now disabled")
+	case "save-on":
+		s.info("Automatic saving is now enabled")
+	case "say":
+		if len(args) < 2 {
+			s.unknownCommand(line)
+			return
+		}
+		s.infof("[Server] %s", strings.Join(args[1:], " "))
+	case "stop":
+		s.shutdown()
+	case "tellraw":
+		if len(args) < 3 {
+			s.unknownCommand(line)
+			return
+		}
+		if len(s.resolve(args[1], executor)) == 0 {
+			s.info(msgNoPlayer)
+		}
+	case "tp", "teleport":
+		s.teleport(line, args[1:], executor, dimension)
+	case "execute":
+		s.executeCommand(line, args[1:], executor, dimension)
+	case "data":
+		s.data(line, args[1:], executor)
+	case "scoreboard":
+		s.scoreboard(line, args[1:], executor)
+	case "trigger":
+		s.info(msgPlayerRequired)
+	case "kick":
+		s.kick(line, args[1:], executor)
+	case "ban":
+		s.ban(line, args[1:])
+	case "pardon":
+		s.pardon(line, args[1:])
+	case "forge", "neoforge":
+		if !s.Flavor.Modded() || len(args) != 2 || args[1] != "tps" {
+			s.unknownCommand(line)
+			return
+		}
+		s.forgeTps()
+	case "tps":
+		if !s.Flavor.Bukkit() {
+			s.unknownCommand(line)
+			return
+		}
+		s.info("TPS from last 1m, 5m, 15m: 20.0, 20.0, 20.0")
+	default:
+		s.unknownCommand(line)
+	}
+}
+
+// unknownCommand prints the brigadier error, the cursor of an unknown
+// command sits at its start so the whole line is shown.
+func (s *Server) unknownCommand(line string) {
+	s.info("Unknown or incomplete command, see below for error")
+	s.info(line + "<--[HERE]")
+}
+
+func (s *Server) incorrectArgument(line string) {
+	s.info("Incorrect argument for command")
+	context := line
+	if len(context) > 10 {
+		context = "..." + context[len(context)-10:]
+	}
+	s.info(context + "<--[HERE]")
+}
+
+func (s *Server) list() {
+	names := []string{}
+	for _, p := range s.onlinePlayers() {
+		names = append(names, p.Name)
+	}
+	s.infof("There are %d of a max of %d players online: %s", len(names), s.MaxPlayers, strings.Join(names, ", "))
+}
+
+func (s *Server) shutdown() {
+	s.info("Stopping the server")
+	s.log("Server thread", "INFO", loggerServer, "Stopping server")
+	s.log("Server thread", "INFO", loggerServer, "Saving players")
+	for _, p := range s.onlinePlayers() {
+		s.infof("%s lost connection: Server closed", p.Name)
+		s.infof("%s left the game", p.Name)
+		p.Online = false
+	}
+	s.log("Server thread", "INFO", loggerServer, "Saving worlds")
+	s.log("Server thread", "INFO", loggerServer, "ThreadedAnvilChunkStorage: All dimensions are saved")
+	s.stop(0)
+}
+
+func (s *Server) forgeTps() {
+	for _, dim := range []string{"minecraft:overworld", "minecraft:the_nether", "minecraft:the_end"} {
+		s.infof("Dim %s (%s): Mean tick time: 3.521 ms. Mean TPS: 20.000", dim, dim)
+	}
+	s.info("Overall: Mean tick time: 3.521 ms. Mean TPS: 20.000")
+}
+
+// resolve turns a target selector, player name or UUID into online players.
+func (s *Server) resolve(selector string, executor *Player) []*Player {
+	online := s.onlinePlayers()
+	switch selector {
+	case "@a", "@e":
+		return online
+	case "@p", "@r":
+		if len(online) > 0 {
+			return online[:1]
+		}
+		return nil
+	case "@s":
+		if executor != nil {
+			return []*Player{executor}
+		}
+		return nil
+	}
+	for _, p := range online {
+		if strings.EqualFold(p.Name, selector) || FormatUUID(p.UUID) == selector {
+			return []*Player{p}
+		}
+	}
+	return nil
+}
+
+func (s *Server) teleport(line string, args []string, executor *Player, dimension string) {
+	var targets []*Player
+	var dest []string
+	switch len(args) {
+	case 1, 3:
+		if executor == nil {
+			s.info(msgPlayerRequired)
+			return
+		}
+		targets, dest = []*Player{executor}, args
+	case 2, 4:
+		targets, dest = s.resolve(args[0], executor), args[1:]
+	default:
+		s.unknownCommand(line)
+		return
+	}
+	if len(targets) == 0 {
+		s.info(msgNoEntity)
+		return
+	}
+	if len(dest) == 1 {
+		to := s.resolve(dest[0], executor)
+		if len(to) == 0 {
+			s.info(msgNoEntity)
+			return
+		}
+		for _, p := range targets {
+			p.Pos, p.Dimension = to[0].Pos, to[0].Dimension
+		}
+		if len(targets) == 1 {
+			s.infof("Teleported %s to %s", targets[0].Name, to[0].Name)
+		} else {
+			s.infof("Teleported %d entities to %s", len(targets), to[0].Name)
+		}
+		return
+	}
+	var pos [3]float64
+	for _, p := range targets {
+		for i, coord := range dest {
+			value, err := parseCoordinate(coord, p.Pos[i])
+			if err != nil {
+				s.incorrectArgument(line)
+				return
+			}
+			pos[i] = value
+		}
+		p.Pos = pos
+		if dimension != "" {
+			p.Dimension = dimension
+		}
+	}
+	if len(targets) == 1 {
+		s.infof("Teleported %s to %f, %f, %f", targets[0].Name, pos[0], pos[1], pos[2])
+	} else {
+		s.infof("Teleported %d entities to %f, %f, %f", len(targets), pos[0], pos[1], pos[2])
+	}
+}
+
+func parseCoordinate(coord string, current float64) (float64, error) {
+	if relative, ok := strings.CutPrefix(coord, "~"); ok {
+		if relative == "" {
+			return current, nil
+		}
+		offset, err := strconv.ParseFloat(relative, 64)
+		return current + offset, err
+	}
+	return strconv.ParseFloat(coord, 64)
+}
+
+// executeCommand understands the subcommands plugins use: as, at, in,
+// rotated and positioned.
+func (s *Server) executeCommand(line string, args []string, executor *Player, dimension string) {
+	executors := []*Player{executor}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "as":
+			if i+1 >= len(args) {
+				s.unknownCommand(line)
+				return
+			}
+			executors = s.resolve(args[i+1], executor)
+			if len(executors) == 0 {
+				// execute as with no match runs nothing and says nothing
+				return
+			}
+			i++
+		case "at":
+			i++
+		case "in":
+			if i+1 >= len(args) {
+				s.unknownCommand(line)
+				return
+			}
+			dimension = args[i+1]
+			i++
+		case "rotated":
+			// rotated as <target> and rotated <yaw> <pitch> both take two
+			i += 2
+		case "positioned":
+			if i+1 < len(args) && args[i+1] == "as" {
+				i += 2
+			} else {
+				i += 3
+			}
+		case "run":
+			if i+1 >= len(args) {
+				s.unknownCommand(line)
+				return
+			}
+			command := strings.Join(args[i+1:], " ")
+			for _, e := range executors {
+				s.execute(command, e, dimension)
+			}
+			return
+		default:
+			s.unknownCommand(line)
+			return
+		}
+	}
+	s.unknownCommand(line)
+}
+
+func (s *Server) data(line string, args []string, executor *Player) {
+	if len(args) < 3 || args[0] != "get" || args[1] != "entity" {
+		s.unknownCommand(line)
+		return
+	}
+	targets := s.resolve(args[2], executor)
+	if len(targets) == 0 {
+		s.info(msgNoEntity)
+		return
+	}
+	if len(targets) > 1 {
+		s.info("Only one entity is allowed, but the provided selector allows more than one")
+		return
+	}
+	p := targets[0]
+	path := ""
+	if len(args) > 3 {
+		path = args[3]
+	}
+	value, ok := p.nbt(path)
+	if !ok {
+		s.infof("Found no elements matching %s", path)
+		return
+	}
+	s.infof("%s has the following entity data: %s", p.Name, value)
+}
+
+func (p *Player) nbt(path string) (string, bool) {
+	switch path {
+	case "UUID":
+		return fmt.Sprintf("[I; %d, %d, %d, %d]", p.UUID[0], p.UUID[1], p.UUID[2], p.UUID[3]), true
+	case "Pos":
+		return fmt.Sprintf("[%sd, %sd, %sd]", formatDouble(p.Pos[0]), formatDouble(p.Pos[1]), formatDouble(p.Pos[2])), true
+	case "Dimension":
+		return strconv.Quote(p.Dimension), true
+	case "DeathTime":
+		return fmt.Sprintf("%ds", p.DeathTime), true
+	case "Health":
+		if p.DeathTime > 0 {
+			return "0.0f", true
+		}
+		return "20.0f", true
+	case "LastDeathLocation":
+		if p.LastDeath == nil {
+			return "", false
+		}
+		return fmt.Sprintf("{dimension: %s, pos: [I; %d, %d, %d]}", strconv.Quote(p.LastDeath.Dimension), int(p.LastDeath.Pos[0]), int(p.LastDeath.Pos[1]), int(p.LastDeath.Pos[2])), true
+	case "":
+		fields := []string{}
+		for _, key := range []string{"DeathTime", "Dimension", "Health", "LastDeathLocation", "Pos", "UUID"} {
+			if value, ok := p.nbt(key); ok {
+				fields = append(fields, key+": "+value)
+			}
+		}
+		return "{" + strings.Join(fields, ", ") + "}", true
+	}
+	return "", false
+}
+
+func formatDouble(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// FormatUUID formats a UUID int array the way it is written in commands.
+func FormatUUID(uuid [4]int32) string {
+	hex := fmt.Sprintf("%08x%08x%08x%08x", uint32(uuid[0]), uint32(uuid[1]), uint32(uuid[2]), uint32(uuid[3]))
+	return hex[0:8] + "-" + hex[8:12] + "-" + hex[12:16] + "-" + hex[16:20] + "-" + hex[20:]
+}
+
+func (s *Server) objective(name string) *objective {
+	idx := slices.IndexFunc(s.objectives, func(o *objective) bool { return o.name == name })
+	if idx < 0 {
+		return nil
+	}
+	return s.objectives[idx]
+}
+
+// scoreHolders resolves selectors to players, other names are fake players.
+func (s *Server) scoreHolders(selector string, executor *Player) []string {
+	if strings.HasPrefix(selector, "@") || len(selector) == 36 {
+		var names []string
+		for _, p := range s.resolve(selector, executor) {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+	return []string{selector}
+}
+
+func (s *Server) scoreboard(line string, args []string, executor *Player) {
+	if len(args) < 2 {
+		s.unknownCommand(line)
+		return
+	}
+	switch args[0] + " " + args[1] {
+	case "objectives add":
+		if len(args) < 4 {
+			s.unknownCommand(line)
+			return
+		}
+		if s.objective(args[2]) != nil {
+			s.info("An objective already exists by that name")
+			return
+		}
+		o := &objective{name: args[2], criterion: args[3], display: args[2], scores: map[string]int64{}, enabled: map[string]bool{}}
+		if len(args) > 4 {
+			o.display = strings.Trim(strings.Join(args[4:], " "), `"`)
+		}
+		s.objectives = append(s.objectives, o)
+		s.infof("Created new objective [%s]", o.display)
+	case "objectives remove":
+		if len(args) != 3 {
+			s.unknownCommand(line)
+			return
+		}
+		o := s.objective(args[2])
+		if o == nil {
+			s.infof("Unknown scoreboard objective '%s'", args[2])
+			return
+		}
+		s.objectives = slices.DeleteFunc(s.objectives, func(item *objective) bool { return item == o })
+		s.infof("Removed objective [%s]", o.display)
+	case "objectives list":
+		if len(s.objectives) == 0 {
+			s.info("There are no objectives")
+			return
+		}
+		names := []string{}
+		for _, o := range s.objectives {
+			names = append(names, "["+o.display+"]")
+		}
+		s.infof("There are %d objective(s): %s", len(names), strings.Join(names, ", "))
+	case "objectives setdisplay":
+		if len(args) == 3 {
+			s.infof("Cleared objective display slot %s", args[2])
+			return
+		}
+		if len(args) != 4 {
+			s.unknownCommand(line)
+			return
+		}
+		o := s.objective(args[3])
+		if o == nil {
+			s.infof("Unknown scoreboard objective '%s'", args[3])
+			return
+		}
+		s.infof("Set display slot %s to show objective [%s]", args[2], o.display)
+	case "players list":
+		s.scoreboardList(args[2:])
+	case "players get":
+		if len(args) != 4 {
+			s.unknownCommand(line)
+			return
+		}
+		o := s.objective(args[3])
+		if o == nil {
+			s.infof("Unknown scoreboard objective '%s'", args[3])
+			return
+		}
+		holders := s.scoreHolders(args[2], executor)
+		if len(holders) != 1 {
+			s.info(msgNoEntity)
+			return
+		}
+		score, ok := o.scores[holders[0]]
+		if !ok {
+			s.infof("Can't get value of %s for %s; none is set", o.name, holders[0])
+			return
+		}
+		s.infof("%s has %d [%s]", holders[0], score, o.display)
+	case "players set", "players add", "players remove":
+		if len(args) != 5 {
+			s.unknownCommand(line)
+			return
+		}
+		value, err := strconv.ParseInt(args[4], 10, 32)
+		if err != nil {
+			s.incorrectArgument(line)
+			return
+		}
+		o := s.objective(args[3])
+		if o == nil {
+			s.infof("Unknown scoreboard objective '%s'", args[3])
+			return
+		}
+		holders := s.scoreHolders(args[2], executor)
+		if len(holders) == 0 {
+			s.info(msgNoEntity)
+			return
+		}
+		for _, holder := range holders {
+			switch args[1] {
+			case "set":
+				o.scores[holder] = value
+			case "add":
+				o.scores[holder] += value
+			case "remove":
+				o.scores[holder] -= value
+			}
+		}
+		who := holders[0]
+		if len(holders) > 1 {
+			who = fmt.Sprintf("%d entities", len(holders))
+		}
+		switch args[1] {
+		case "set":
+			s.infof("Set [%s] for %s to %d", o.display, who, value)
+		case "add":
+			s.infof("Added %d to [%s] for %s (now %d)", value, o.display, who, o.scores[holders[0]])
+		case "remove":
+			s.infof("Removed %d from [%s] for %s (now %d)", value, o.display, who, o.scores[holders[0]])
+		}
+	case "players reset":
+		if len(args) < 3 {
+			s.unknownCommand(line)
+			return
+		}
+		holders := s.scoreHolders(args[2], executor)
+		for _, o := range s.objectives {
+			if len(args) > 3 && o.name != args[3] {
+				continue
+			}
+			for _, holder := range holders {
+				delete(o.scores, holder)
+			}
+		}
+		if len(holders) == 1 {
+			s.infof("Reset scores for %s", holders[0])
+		} else {
+			s.infof("Reset scores for %d entities", len(holders))
+		}
+	case "players enable":
+		if len(args) != 4 {
+			s.unknownCommand(line)
+			return
+		}
+		o := s.objective(args[3])
+		if o == nil {
+			s.infof("Unknown scoreboard objective '%s'", args[3])
+			return
+		}
+		if o.criterion != "trigger" {
+			s.info("Only trigger objectives can be enabled")
+			return
+		}
+		targets := s.resolve(args[2], executor)
+		if len(targets) == 0 {
+			s.info(msgNoPlayer)
+			return
+		}
+		for _, p := range targets {
+			o.enabled[p.Name] = true
+			p.lastTrig = o.name
+		}
+		if len(targets) == 1 {
+			s.infof("Enabled trigger [%s] for %s", o.display, targets[0].Name)
+		} else {
+			s.infof("Enabled trigger [%s] for %d entities", o.display, len(targets))
+		}
+	default:
+		s.unknownCommand(line)
+	}
+}
+
+func (s *Server) scoreboardList(args []string) {
+	if len(args) == 0 {
+		tracked := map[string]bool{}
+		for _, o := range s.objectives {
+			for holder := range o.scores {
+				tracked[holder] = true
+			}
+		}
+		if len(tracked) == 0 {
+			s.info("There are no tracked entities")
+			return
+		}
+		names := make([]string, 0, len(tracked))
+		for name := range tracked {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		s.infof("There are %d tracked entity/entities: %s", len(names), strings.Join(names, ", "))
+		return
+	}
+	holder := args[0]
+	var lines []string
+	for _, o := range s.objectives {
+		if score, ok := o.scores[holder]; ok {
+			lines = append(lines, fmt.Sprintf("[%s]: %d", o.display, score))
+		}
+	}
+	if len(lines) == 0 {
+		s.infof("%s has no scores to show", holder)
+		return
+	}
+	s.infof("%s has %d score(s):", holder, len(lines))
+	for _, line := range lines {
+		s.info(line)
+	}
+}
+
+func (s *Server) kick(line string, args []string, executor *Player) {
+	if len(args) == 0 {
+		s.unknownCommand(line)
+		return
+	}
+	targets := s.resolve(args[0], executor)
+	if len(targets) == 0 {
+		s.info(msgNoPlayer)
+		return
+	}
+	reason := "Kicked by an operator"
+	if len(args) > 1 {
+		reason = strings.Join(args[1:], " ")
+	}
+	for _, p := range targets {
+		s.infof("Kicked %s: %s", p.Name, reason)
+		s.disconnect(p, reason)
+	}
+}
+
+func (s *Server) ban(line string, args []string) {
+	if len(args) == 0 {
+		s.unknownCommand(line)
+		return
+	}
+	if _, ok := s.banned[args[0]]; ok {
+		s.info("Nothing changed. The player is already banned")
+		return
+	}
+	reason := "Banned by an operator."
+	if len(args) > 1 {
+		reason = strings.Join(args[1:], " ")
+	}
+	s.banned[args[0]] = reason
+	s.infof("Banned %s: %s", args[0], reason)
+	if p, ok := s.players[args[0]]; ok && p.Online {
+		s.disconnect(p, "You are banned from this server.")
+	}
+}
+
+func (s *Server) pardon(line string, args []string) {
+	if len(args) != 1 {
+		s.unknownCommand(line)
+		return
+	}
+	if _, ok := s.banned[args[0]]; !ok {
+		s.info("Nothing changed. The player isn't banned")
+		return
+	}
+	delete(s.banned, args[0])
+	s.infof("Unbanned %s", args[0])
+}
+
+func (s *Server) disconnect(p *Player, reason string) {
+	p.Online = false
+	s.infof("%s lost connection: %s", p.Name, reason)
+	s.infof("%s left the game", p.Name)
+}
+
+func sortPlayers(players []*Player) {
+	slices.SortFunc(players, func(a, b *Player) int {
+		return a.entityId - b.entityId
+	})
+}
@@ -0,0 +1,310 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakeserver simulates the console of a Minecraft dedicated server,
+// so GameManager and the plugins can run without a Mojang jar.
+package fakeserver
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Flavor string
+
+const (
+	Vanilla  Flavor = "vanilla"
+	Paper    Flavor = "paper"
+	Purpur   Flavor = "purpur"
+	Forge    Flavor = "forge"
+	NeoForge Flavor = "neoforge"
+	Fabric   Flavor = "fabric"
+)
+
+const (
+	DefaultVersion    = "1.20.1"
+	DefaultMaxPlayers = 20
+	DefaultDimension  = "minecraft:overworld"
+)
+
+var ErrUnknownFlavor = fmt.Errorf("unknown server flavor")
+
+func ParseFlavor(flavor string) (Flavor, error) {
+	switch Flavor(strings.ToLower(flavor)) {
+	case Vanilla, "":
+		return Vanilla, nil
+	case Paper, Purpur, Forge, NeoForge, Fabric:
+		return Flavor(strings.ToLower(flavor)), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFlavor, flavor)
+}
+
+// Bukkit tells the flavors with the Paper console and commands.
+func (f Flavor) Bukkit() bool {
+	return f == Paper || f == Purpur
+}
+
+// Modded tells the flavors with the Forge console and commands.
+func (f Flavor) Modded() bool {
+	return f == Forge || f == NeoForge
+}
+
+// loggers the server writes through, forge prints them with every line
+const (
+	loggerServer     = "net.minecraft.server.MinecraftServer/"
+	loggerDedicated  = "net.minecraft.server.dedicated.DedicatedServer/"
+	loggerPlayerList = "net.minecraft.server.players.PlayerList/"
+)
+
+type Player struct {
+	Name      string
+	UUID      [4]int32
+	Pos       [3]float64
+	Dimension string
+	DeathTime int16 // ticks spent on the death screen, 0 while alive
+	LastDeath *Location
+	Online    bool
+	entityId  int
+	lastTrig  string
+}
+
+type Location struct {
+	Pos       [3]float64
+	Dimension string
+}
+
+type objective struct {
+	name      string
+	criterion string
+	display   string
+	scores    map[string]int64
+	enabled   map[string]bool // trigger objectives only
+}
+
+type Server struct {
+	Flavor     Flavor
+	Version    string
+	MaxPlayers int
+	Spawn      Location
+	WorkDir    string // crash reports are written here
+
+	out        io.Writer
+	outLock    sync.Mutex
+	capture    *strings.Builder // feedback of an RCON command, guarded by outLock
+	lock       sync.Mutex
+	players    map[string]*Player
+	objectives []*objective
+	banned     map[string]string
+	entityId   int
+	started    time.Time
+	ready      chan struct{}
+	stopped    chan struct{}
+	stopOnce   sync.Once
+	exitCode   int
+}
+
+func NewServer(flavor Flavor, out io.Writer) *Server {
+	return &Server{
+		Flavor:     flavor,
+		Version:    DefaultVersion,
+		MaxPlayers: DefaultMaxPlayers,
+		Spawn:      Location{Pos: [3]float64{0.5, 64, 0.5}, Dimension: DefaultDimension},
+		WorkDir:    ".",
+		out:        out,
+		players:    make(map[string]*Player),
+		banned:     make(map[string]string),
+		entityId:   100,
+		ready:      make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+}
+
+// log writes one console line in the format of the flavor.
+func (s *Server) log(thread string, level string, logger string, message string) {
+	now := time.Now()
+	var line string
+	switch {
+	case s.Flavor.Bukkit():
+		line = fmt.Sprintf("[%s %s]: %s", now.Format("15:04:05"), level, message)
+	case s.Flavor.Modded():
+		line = fmt.Sprintf("[%s] [%s/%s] [%s]: %s", now.Format("02Jan2006 15:04:05.000"), thread, level, logger, message)
+	case s.Flavor == Fabric:
+		if strings.HasPrefix(logger, "net.minecraft.") {
+			logger = "Minecraft"
+		}
+		line = fmt.Sprintf("[%s] [%s/%s] (%s) %s", now.Format("15:04:05"), thread, level, logger, message)
+	default:
+		line = fmt.Sprintf("[%s] [%s/%s]: %s", now.Format("15:04:05"), thread, level, message)
+	}
+	s.outLock.Lock()
+	defer s.outLock.Unlock()
+	if s.capture != nil && thread == "Server thread" {
+		s.capture.WriteString(message + "\n")
+		return
+	}
+	fmt.Fprintln(s.out, line)
+}
+
+func (s *Server) info(message string) {
+	s.log("Server thread", "INFO", loggerServer, message)
+}
+
+func (s *Server) infof(format string, a ...any) {
+	s.info(fmt.Sprintf(format, a...))
+}
+
+func (s *Server) warn(message string) {
+	s.log("Server thread", "WARN", loggerServer, message)
+}
+
+// Boot prints the startup banner, delay is spent "preparing the level".
+func (s *Server) Boot(delay time.Duration) {
+	s.started = time.Now()
+	switch s.Flavor {
+	case Forge:
+		s.log("main", "INFO", "cpw.mods.modlauncher.Launcher/MODLAUNCHER", "ModLauncher running: args [--launchTarget, forgeserver, --fml.forgeVersion, 47.2.0, --fml.mcVersion, "+s.Version+"]")
+		s.log("main", "INFO", "net.minecraftforge.fml.loading.FMLLoader/CORE", "Forge mod loading, version 47.2.0, for MC "+s.Version)
+	case NeoForge:
+		s.log("main", "INFO", "cpw.mods.modlauncher.Launcher/MODLAUNCHER", "ModLauncher running: args [--launchTarget, forgeserver, --fml.neoForgeVersion, 20.4.80-beta, --fml.fmlVersion, 2.0.17, --fml.mcVersion, "+s.Version+"]")
+		s.log("main", "INFO", "net.neoforged.fml.loading.FMLLoader/CORE", "NeoForge mod loading, version 20.4.80-beta, for MC "+s.Version)
+	case Fabric:
+		s.log("main", "INFO", "FabricLoader/GameProvider", "Loading Minecraft "+s.Version+" with Fabric Loader 0.15.11")
+	}
+	s.log("Server thread", "INFO", loggerDedicated, "Starting minecraft server version "+s.Version)
+	s.log("Server thread", "INFO", loggerDedicated, "Loading properties")
+	s.log("Server thread", "INFO", loggerDedicated, "Default game type: SURVIVAL")
+	switch s.Flavor {
+	case Paper:
+		s.log("Server thread", "INFO", loggerDedicated, fmt.Sprintf("This server is running Paper version git-Paper-196 (MC: %s) (Implementing API version %s-R0.1-SNAPSHOT)", s.Version, s.Version))
+	case Purpur:
+		s.log("Server thread", "INFO", loggerDedicated, fmt.Sprintf("This server is running Purpur version git-Purpur-2062 (MC: %s) (Implementing API version %s-R0.1-SNAPSHOT)", s.Version, s.Version))
+	}
+	s.log("Server thread", "INFO", loggerDedicated, "Starting Minecraft server on *:25565")
+	s.log("Server thread", "INFO", loggerServer, `Preparing level "world"`)
+	s.log("Server thread", "INFO", loggerServer, "Preparing start region for dimension "+DefaultDimension)
+	time.Sleep(delay)
+	s.log("Server thread", "INFO", loggerDedicated, fmt.Sprintf(`Done (%.3fs)! For help, type "help"`, time.Since(s.started).Seconds()))
+	close(s.ready)
+}
+
+// Ready is closed once the server printed its Done line.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Stopped is closed once the server stopped, ExitCode is valid afterwards.
+func (s *Server) Stopped() <-chan struct{} {
+	return s.stopped
+}
+
+func (s *Server) ExitCode() int {
+	<-s.stopped
+	return s.exitCode
+}
+
+func (s *Server) stop(code int) {
+	s.stopOnce.Do(func() {
+		s.exitCode = code
+		close(s.stopped)
+	})
+}
+
+// Run executes console commands read from console until the server stops or
+// the console is closed.
+func (s *Server) Run(console io.Reader) {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(console)
+		for scanner.Scan() {
+			lines <- strings.TrimRight(scanner.Text(), "\r")
+		}
+	}()
+	<-s.ready
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				s.stop(0)
+				return
+			}
+			s.Command(line)
+		case <-s.stopped:
+			return
+		}
+	}
+}
+
+func (s *Server) player(name string) *Player {
+	if p, ok := s.players[name]; ok {
+		return p
+	}
+	p := &Player{Name: name, UUID: OfflineUUID(name), Pos: s.Spawn.Pos, Dimension: s.Spawn.Dimension}
+	s.players[name] = p
+	return p
+}
+
+func (s *Server) onlinePlayers() (players []*Player) {
+	for _, p := range s.players {
+		if p.Online {
+			players = append(players, p)
+		}
+	}
+	sortPlayers(players)
+	return players
+}
+
+// OfflineUUID returns the UUID an offline mode server assigns to name.
+func OfflineUUID(name string) (uuid [4]int32) {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	for i := range uuid {
+		uuid[i] = int32(binary.BigEndian.Uint32(sum[i*4:]))
+	}
+	return uuid
+}
+
+func randomPort() int {
+	return 40000 + rand.IntN(20000)
+}
+
+// ThreadDump writes a JVM style thread dump, the server thread shows up as
+// sleeping while a hang action holds the console.
+func (s *Server) ThreadDump(w io.Writer) {
+	state, frame := "RUNNABLE", "net.minecraft.server.MinecraftServer.waitUntilNextTick(MinecraftServer.java:1003)"
+	if s.lock.TryLock() {
+		s.lock.Unlock()
+	} else {
+		state, frame = "TIMED_WAITING (sleeping)", "java.lang.Thread.sleep(java.base@17.0.8/Native Method)"
+	}
+	s.outLock.Lock()
+	defer s.outLock.Unlock()
+	fmt.Fprintln(w, time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(w, "Full thread dump OpenJDK 64-Bit Server VM (17.0.8+7 mixed mode, sharing):")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `"Server thread" #30 prio=5 os_prio=0 cpu=1234.56ms elapsed=60.00s tid=0x00007f0000000000 nid=0x1e `+strings.Fields(state)[0])
+	fmt.Fprintln(w, "   java.lang.Thread.State: "+state)
+	fmt.Fprintln(w, "\tat "+frame)
+	fmt.Fprintln(w, "\tat net.minecraft.server.MinecraftServer.runServer(MinecraftServer.java:685)")
+	fmt.Fprintln(w, "\tat java.lang.Thread.run(java.base@17.0.8/Thread.java:833)")
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeserver
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/rcon"
+)
+
+// RconCommand runs line like Command, but the feedback is returned instead of
+// printed, as for a command received over RCON.
+func (s *Server) RconCommand(line string) string {
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "/"))
+	if line == "" || strings.HasPrefix(line, "fake ") {
+		s.Command(line)
+		return ""
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var feedback strings.Builder
+	s.outLock.Lock()
+	s.capture = &feedback
+	s.outLock.Unlock()
+	s.execute(line, nil, "")
+	s.outLock.Lock()
+	s.capture = nil
+	s.outLock.Unlock()
+	return strings.TrimSuffix(feedback.String(), "\n")
+}
+
+// ListenRcon starts the RCON listener on port once the server is ready, like
+// a server with enable-rcon=true.
+func (s *Server) ListenRcon(port int, password string) (*rcon.Server, error) {
+	if password == "" {
+		s.warn("No rcon password set in server.properties, rcon disabled!")
+		return nil, fmt.Errorf("%w: empty password", rcon.ErrUnavailable)
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		s.warn(fmt.Sprintf("Unable to initialise RCON on 0.0.0.0:%d : %v", port, err))
+		return nil, err
+	}
+	server := &rcon.Server{
+		Password: password,
+		Handler: func(_ *rcon.Session, command string) string {
+			return s.RconCommand(command)
+		},
+	}
+	s.log("Server thread", "INFO", loggerDedicated, "Starting remote control listener")
+	s.log("RCON Listener #1", "INFO", "net.minecraft.server.rcon.thread.GenericThread/", "Thread RCON Listener started")
+	s.log("Server thread", "INFO", "net.minecraft.server.rcon.thread.RconThread/", fmt.Sprintf("RCON running on 0.0.0.0:%d", port))
+	go server.Serve(listener)
+	go func() {
+		<-s.stopped
+		server.Close()
+	}()
+	return server, nil
+}
@@ -0,0 +1,327 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPlayerOffline = fmt.Errorf("player is not online")
+	ErrUnknownAction = fmt.Errorf("unknown action")
+	ErrBadArguments  = fmt.Errorf("bad arguments")
+)
+
+// Do runs one script action:
+//
+//	wait <duration>
+//	join <player>
+//	leave <player>
+//	chat <player> <message>
+//	die <player> [death message]          defaults to "died"
+//	respawn <player>
+//	move <player> <x> <y> <z> [dimension]
+//	advancement <player> <title>
+//	trigger <player> <objective|-> [set|add <n>]   - is the last enabled trigger
+//	log <message>                          a raw Server thread line
+//	command <console command>
+//	hang <duration>                        the console stops answering
+//	crash [description]                    writes a crash report and exits 1
+//	stop
+func (s *Server) Do(action string) error {
+	args := strings.Fields(action)
+	if len(args) == 0 {
+		return nil
+	}
+	rest := func(from int) string {
+		if len(args) <= from {
+			return ""
+		}
+		return strings.Join(args[from:], " ")
+	}
+	need := func(n int) error {
+		if len(args) < n {
+			return fmt.Errorf("%w: %s", ErrBadArguments, action)
+		}
+		return nil
+	}
+	switch args[0] {
+	case "wait", "hang":
+		if err := need(2); err != nil {
+			return err
+		}
+		duration, err := time.ParseDuration(args[1])
+		if err != nil {
+			return err
+		}
+		if args[0] == "hang" {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+		}
+		select {
+		case <-time.After(duration):
+		case <-s.stopped:
+		}
+		return nil
+	case "command":
+		if err := need(2); err != nil {
+			return err
+		}
+		s.Command(rest(1))
+		return nil
+	case "crash":
+		return s.Crash(rest(1))
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch args[0] {
+	case "join":
+		if err := need(2); err != nil {
+			return err
+		}
+		s.join(args[1])
+	case "leave":
+		if err := need(2); err != nil {
+			return err
+		}
+		p, err := s.online(args[1])
+		if err != nil {
+			return err
+		}
+		s.disconnect(p, "Disconnected")
+	case "chat":
+		if err := need(3); err != nil {
+			return err
+		}
+		p, err := s.online(args[1])
+		if err != nil {
+			return err
+		}
+		s.infof("<%s> %s", p.Name, rest(2))
+	case "die":
+		if err := need(2); err != nil {
+			return err
+		}
+		p, err := s.online(args[1])
+		if err != nil {
+			return err
+		}
+		message := rest(2)
+		if message == "" {
+			message = "died"
+		}
+		p.DeathTime = 20
+		p.LastDeath = &Location{Pos: p.Pos, Dimension: p.Dimension}
+		s.infof("%s %s", p.Name, message)
+	case "respawn":
+		if err := need(2); err != nil {
+			return err
+		}
+		p, err := s.online(args[1])
+		if err != nil {
+			return err
+		}
+		p.DeathTime = 0
+		p.Pos, p.Dimension = s.Spawn.Pos, s.Spawn.Dimension
+	case "move":
+		if err := need(5); err != nil {
+			return err
+		}
+		p, err := s.online(args[1])
+		if err != nil {
+			return err
+		}
+		for i := range p.Pos {
+			if p.Pos[i], err = parseCoordinate(args[2+i], p.Pos[i]); err != nil {
+				return err
+			}
+		}
+		if len(args) > 5 {
+			p.Dimension = args[5]
+		}
+	case "advancement":
+		if err := need(3); err != nil {
+			return err
+		}
+		p, err := s.online(args[1])
+		if err != nil {
+			return err
+		}
+		s.infof("%s has made the advancement [%s]", p.Name, rest(2))
+	case "trigger":
+		if err := need(3); err != nil {
+			return err
+		}
+		return s.trigger(args[1], args[2], args[3:])
+	case "log":
+		s.info(rest(1))
+	case "stop":
+		s.shutdown()
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownAction, args[0])
+	}
+	return nil
+}
+
+func (s *Server) online(name string) (*Player, error) {
+	if p, ok := s.players[name]; ok && p.Online {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrPlayerOffline, name)
+}
+
+func (s *Server) join(name string) {
+	p := s.player(name)
+	if p.Online {
+		return
+	}
+	address := fmt.Sprintf("/127.0.0.1:%d", randomPort())
+	if _, ok := s.banned[name]; ok {
+		s.log("Server thread", "INFO", loggerServer, fmt.Sprintf("Disconnecting %s (%s): You are banned from this server.", name, address))
+		return
+	}
+	if len(s.onlinePlayers()) >= s.MaxPlayers {
+		s.log("Server thread", "INFO", loggerServer, fmt.Sprintf("Disconnecting %s (%s): The server is full!", name, address))
+		return
+	}
+	s.entityId++
+	p.entityId = s.entityId
+	p.Online = true
+	s.log("Server thread", "INFO", loggerPlayerList, fmt.Sprintf("%s[%s] logged in with entity id %d at (%.1f, %.1f, %.1f)", name, address, p.entityId, p.Pos[0], p.Pos[1], p.Pos[2]))
+	s.infof("%s joined the game", name)
+}
+
+// trigger is a player running /trigger, the console only sees the admin
+// broadcast.
+func (s *Server) trigger(name string, objectiveName string, args []string) error {
+	p, err := s.online(name)
+	if err != nil {
+		return err
+	}
+	if objectiveName == "-" {
+		objectiveName = p.lastTrig
+	}
+	o := s.objective(objectiveName)
+	if o == nil || o.criterion != "trigger" {
+		return fmt.Errorf("%w: %s is not a trigger objective", ErrBadArguments, objectiveName)
+	}
+	if !o.enabled[p.Name] {
+		return fmt.Errorf("%w: %s can't trigger %s yet", ErrBadArguments, p.Name, objectiveName)
+	}
+	mode, value := "add", int64(1)
+	if len(args) == 2 {
+		mode = args[0]
+		if value, err = strconv.ParseInt(args[1], 10, 32); err != nil {
+			return err
+		}
+	} else if len(args) != 0 {
+		return fmt.Errorf("%w: trigger %s", ErrBadArguments, strings.Join(args, " "))
+	}
+	if s.Flavor.Bukkit() {
+		s.infof("%s issued server command: /trigger %s", p.Name, strings.Join(append([]string{objectiveName}, args...), " "))
+	}
+	o.enabled[p.Name] = false
+	switch mode {
+	case "set":
+		o.scores[p.Name] = value
+		s.infof("[%s: Triggered [%s] (set value to %d)]", p.Name, o.display, value)
+	case "add":
+		o.scores[p.Name] += value
+		s.infof("[%s: Triggered [%s] (added %d to value)]", p.Name, o.display, value)
+	default:
+		return fmt.Errorf("%w: trigger mode %s", ErrBadArguments, mode)
+	}
+	return nil
+}
+
+// Crash writes a crash report to WorkDir/crash-reports and stops the server
+// with exit code 1.
+func (s *Server) Crash(description string) error {
+	if description == "" {
+		description = "Exception in server tick loop"
+	}
+	now := time.Now()
+	dir := filepath.Join(s.WorkDir, "crash-reports")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "crash-"+now.Format("2006-01-02_15.04.05")+"-server.txt")
+	report := strings.Join([]string{
+		"---- Minecraft Crash Report ----",
+		"// Surprise! Haha. Well, this is awkward.",
+		"",
+		"Time: " + now.Format("2006-01-02 15:04:05"),
+		"Description: " + description,
+		"",
+		"java.lang.IllegalStateException: simulated crash",
+		"\tat net.minecraft.server.MinecraftServer.tickServer(MinecraftServer.java:907)",
+		"\tat net.minecraft.server.MinecraftServer.runServer(MinecraftServer.java:685)",
+		"",
+		"",
+		"A detailed walkthrough of the error, its code path and all known details is as follows:",
+		"---------------------------------------------------------------------------------------",
+		"",
+		"-- Head --",
+		"Thread: Server thread",
+		"Suspected Mods: NONE",
+		"Stacktrace:",
+		"\tat net.minecraft.server.MinecraftServer.tickServer(MinecraftServer.java:907)",
+		"",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+		return err
+	}
+	s.log("Server thread", "ERROR", loggerServer, "Encountered an unexpected exception")
+	s.log("Server thread", "ERROR", loggerServer, "java.lang.IllegalStateException: simulated crash")
+	s.log("Server thread", "ERROR", loggerServer, "This crash report has been saved to: "+path)
+	s.stop(1)
+	return nil
+}
+
+// RunScript runs the actions in script once the server is ready, one per
+// line. Empty lines and lines starting with # are skipped.
+func (s *Server) RunScript(script io.Reader) error {
+	select {
+	case <-s.ready:
+	case <-s.stopped:
+		return nil
+	}
+	scanner := bufio.NewScanner(script)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		select {
+		case <-s.stopped:
+			return nil
+		default:
+		}
+		if err := s.Do(line); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+)
+
+var (
+	joinedEvent      = regexp.MustCompile(`^(` + playerNamePattern + `)(?: \(formerly known as ` + playerNamePattern + `\))? joined the game$`)
+	leftEvent        = regexp.MustCompile(`^(` + playerNamePattern + `) left the game$`)
+	advancementEvent = regexp.MustCompile(`^(` + playerNamePattern + `) has (made the advancement|completed the challenge|reached the goal) \[(.+)\]$`)
+	triggerEvent     = regexp.MustCompile(`^\[(` + playerNamePattern + `): ?Triggered ?\[(.*?)\] ?(?:\(set value to (-?\d+)\)|\(added (-?\d+) to value\))?\]$`)
+	commandEvent     = regexp.MustCompile(`^(` + playerNamePattern + `) issued server command: /?(.*)$`)
+	startedEvent     = regexp.MustCompile(`^Done \((\d+(?:\.\d+)?)s\)! For help, type "help"`)
+	listReply        = regexp.MustCompile(`(?m)players online:(.*)$`)
+)
+
+var advancementKinds = map[string]string{
+	"made the advancement":    "task",
+	"completed the challenge": "challenge",
+	"reached the goal":        "goal",
+}
+
+// GameEventParser turns log lines into game events. It detects the log
+// profile and remembers who is online to tell player kills from mob kills, so
+// one parser should see every line in order. Players that joined before the
+// first line it saw are added with SeedOnline.
+type GameEventParser struct {
+	Profiles *LogProfileSelector
+	online   map[string]struct{}
+	lock     sync.Mutex
+}
+
+// SeedOnline marks players as online, e.g. from the answer to list.
+func (p *GameEventParser) SeedOnline(players []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.online == nil {
+		p.online = make(map[string]struct{})
+	}
+	for _, player := range players {
+		p.online[player] = struct{}{}
+	}
+}
+
+// ParsePlayerList returns the players in the output of list.
+func ParsePlayerList(output string) (players []string, ok bool) {
+	m := listReply.FindStringSubmatch(output)
+	if m == nil {
+		return nil, false
+	}
+	for _, player := range strings.Split(m[1], ",") {
+		if player = strings.TrimSpace(player); player != "" {
+			players = append(players, player)
+		}
+	}
+	return players, true
+}
+
+// Parse returns the event of line, nil if it is none.
+func (p *GameEventParser) Parse(line string) pluginabi.GameEvent {
+	line = strings.TrimRight(line, "\r\n")
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.Profiles == nil {
+		p.Profiles = &LogProfileSelector{}
+	}
+	if p.online == nil {
+		p.online = make(map[string]struct{})
+	}
+	p.Profiles.Observe(line)
+	message, ok := p.Profiles.ServerMessage(line)
+	if !ok {
+		return nil
+	}
+	info := pluginabi.EventInfo{Time: time.Now(), Line: line}
+
+	if player, text, ok := p.Profiles.ChatMessage(message); ok {
+		return pluginabi.ChatMessage{EventInfo: info, Player: player, Message: text}
+	}
+	if m := triggerEvent.FindStringSubmatch(message); m != nil {
+		event := pluginabi.TriggerFired{EventInfo: info, Player: m[1], Objective: m[2], Value: 1}
+		switch {
+		case m[3] != "":
+			event.Value, _ = strconv.Atoi(m[3])
+			event.Set = true
+		case m[4] != "":
+			event.Value, _ = strconv.Atoi(m[4])
+		}
+		return event
+	}
+	if m := joinedEvent.FindStringSubmatch(message); m != nil {
+		p.online[m[1]] = struct{}{}
+		return pluginabi.PlayerJoined{EventInfo: info, Player: m[1]}
+	}
+	if m := leftEvent.FindStringSubmatch(message); m != nil {
+		delete(p.online, m[1])
+		return pluginabi.PlayerLeft{EventInfo: info, Player: m[1]}
+	}
+	if m := advancementEvent.FindStringSubmatch(message); m != nil {
+		return pluginabi.AdvancementMade{EventInfo: info, Player: m[1], Advancement: m[3], Kind: advancementKinds[m[2]]}
+	}
+	if m := commandEvent.FindStringSubmatch(message); m != nil {
+		return pluginabi.CommandIssued{EventInfo: info, Player: m[1], Command: m[2]}
+	}
+	if m := startedEvent.FindStringSubmatch(message); m != nil {
+		seconds, _ := strconv.ParseFloat(m[1], 64)
+		p.online = make(map[string]struct{})
+		return pluginabi.ServerStarted{EventInfo: info, Took: time.Duration(math.Round(seconds*1000)) * time.Millisecond}
+	}
+	if message == "Stopping server" {
+		return pluginabi.ServerStopping{EventInfo: info}
+	}
+	if event, ok := p.parseDeath(message); ok {
+		event.EventInfo = info
+		return event
+	}
+	return nil
+}
+
+func (p *GameEventParser) parseDeath(message string) (event pluginabi.PlayerDied, ok bool) {
+	for _, death := range deathMessagePatterns {
+		m := death.pattern.FindStringSubmatch(message)
+		if m == nil {
+			continue
+		}
+		event.Key = death.key
+		for i, name := range death.pattern.SubexpNames() {
+			switch name {
+			case "player":
+				event.Player = m[i]
+			case "killer":
+				event.Killer = m[i]
+			case "item":
+				event.Item = m[i]
+			}
+		}
+		event.Message = strings.TrimSpace(strings.TrimPrefix(message, event.Player))
+		if key, ok := playerKillDeathMessages[event.Key]; ok {
+			if _, online := p.online[event.Killer]; online {
+				event.Key = key
+			}
+		}
+		return event, true
+	}
+	return event, false
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+)
+
+func TestParsePlayerList(t *testing.T) {
+	for _, test := range []struct {
+		output  string
+		players []string
+		ok      bool
+	}{
+		{"There are 0 of a max of 20 players online: ", nil, true},
+		{"There are 1 of a max of 20 players online: Steve", []string{"Steve"}, true},
+		{"There are 2 of a max of 20 players online: Steve, Alex", []string{"Steve", "Alex"}, true},
+		{"Unknown or incomplete command, see below for error", nil, false},
+	} {
+		players, ok := ParsePlayerList(test.output)
+		if ok != test.ok || !slices.Equal(players, test.players) {
+			t.Errorf("ParsePlayerList(%q) = %q, %v, want %q, %v", test.output, players, ok, test.players, test.ok)
+		}
+	}
+}
+
+func TestGameEventParserSeedOnline(t *testing.T) {
+	parser := &GameEventParser{Profiles: &LogProfileSelector{Fixed: VanillaLogProfile}}
+	kill := "[12:00:00] [Server thread/INFO]: Alex was slain by Steve"
+	died := func() string {
+		event, ok := parser.Parse(kill).(pluginabi.PlayerDied)
+		if !ok {
+			t.Fatalf("%q is no PlayerDied", kill)
+		}
+		return event.Key
+	}
+
+	if key := died(); key != "death.attack.mob" {
+		t.Fatalf("kill by an unknown Steve: %s, want death.attack.mob", key)
+	}
+	parser.SeedOnline([]string{"Steve"})
+	if key := died(); key != "death.attack.player" {
+		t.Fatalf("kill by a seeded Steve: %s, want death.attack.player", key)
+	}
+	parser.Parse("[12:00:00] [Server thread/INFO]: Steve left the game")
+	if key := died(); key != "death.attack.mob" {
+		t.Fatalf("kill by Steve after leaving: %s, want death.attack.mob", key)
+	}
+}
+
+func TestGameEventParserDeath(t *testing.T) {
+	for _, test := range []struct {
+		message string
+		online  []string
+		want    pluginabi.PlayerDied
+	}{
+		{"Steve died", nil, pluginabi.PlayerDied{Player: "Steve", Message: "died", Key: "death.attack.generic"}},
+		{"Steve fell from a high place", nil, pluginabi.PlayerDied{Player: "Steve", Message: "fell from a high place", Key: "death.fell.accident.generic"}},
+		{"Steve drowned while trying to escape Zombie", nil, pluginabi.PlayerDied{Player: "Steve", Message: "drowned while trying to escape Zombie", Key: "death.attack.drown.player", Killer: "Zombie"}},
+		{"Steve was shot by Skeleton using Bow of Doom", nil, pluginabi.PlayerDied{Player: "Steve", Message: "was shot by Skeleton using Bow of Doom", Key: "death.attack.arrow.item", Killer: "Skeleton", Item: "Bow of Doom"}},
+		// slain by is a player kill only when the killer is online
+		{"Steve was slain by Zombie", nil, pluginabi.PlayerDied{Player: "Steve", Message: "was slain by Zombie", Key: "death.attack.mob", Killer: "Zombie"}},
+		{"Steve was slain by Alex", []string{"Alex"}, pluginabi.PlayerDied{Player: "Steve", Message: "was slain by Alex", Key: "death.attack.player", Killer: "Alex"}},
+		{"Steve was slain by Alex using Excalibur", []string{"Alex"}, pluginabi.PlayerDied{Player: "Steve", Message: "was slain by Alex using Excalibur", Key: "death.attack.player.item", Killer: "Alex", Item: "Excalibur"}},
+		{"Steve was slain by Alex using Excalibur", []string{"Notch"}, pluginabi.PlayerDied{Player: "Steve", Message: "was slain by Alex using Excalibur", Key: "death.attack.mob.item", Killer: "Alex", Item: "Excalibur"}},
+	} {
+		parser := &GameEventParser{Profiles: &LogProfileSelector{Fixed: VanillaLogProfile}}
+		parser.SeedOnline(test.online)
+		line := "[12:00:00] [Server thread/INFO]: " + test.message
+		event, ok := parser.Parse(line).(pluginabi.PlayerDied)
+		if !ok {
+			t.Errorf("%q is no PlayerDied", test.message)
+			continue
+		}
+		event.EventInfo = pluginabi.EventInfo{}
+		if event != test.want {
+			t.Errorf("%q online %q = %+v, want %+v", test.message, test.online, event, test.want)
+		}
+	}
+}
+
+// The parser sees command feedback too, none of the feedback of the commands
+// the plugins run has the shape of a death message, so PlayerDied needs no
+// guard against command responses.
+func TestGameEventParserCommandFeedbackIsNoDeath(t *testing.T) {
+	parser := &GameEventParser{Profiles: &LogProfileSelector{Fixed: VanillaLogProfile}}
+	parser.SeedOnline([]string{"Steve"})
+	for _, feedback := range []string{
+		"Steve has the following entity data: 20s",
+		`Steve has the following entity data: {dimension: "minecraft:overworld", pos: [I; 10, 70, -5]}`,
+		"Found no elements matching LastDeathLocation",
+		"No entity was found",
+		"Teleported Steve to 10.000000, 70.000000, -5.000000",
+		"There are 1 of a max of 20 players online: Steve",
+		"Steve has 3 [Death]",
+		"Set [Death] for Steve to 3",
+		"Enabled trigger tri_back for Steve",
+		"Killed Steve",
+		"[Server] Steve died",
+		"Unknown scoreboard objective 'mpd000001_1'",
+	} {
+		if event, ok := parser.Parse("[12:00:00] [Server thread/INFO]: " + feedback).(pluginabi.PlayerDied); ok {
+			t.Errorf("feedback %q parsed as PlayerDied %+v", feedback, event)
+		}
+	}
+}
+
+func TestGameEventParserTrigger(t *testing.T) {
+	for _, test := range []struct {
+		message string
+		want    pluginabi.TriggerFired
+	}{
+		{"[Steve: Triggered [tri_back]]", pluginabi.TriggerFired{Player: "Steve", Objective: "tri_back", Value: 1}},
+		{"[Steve: Triggered [tri_back] (added 2 to value)]", pluginabi.TriggerFired{Player: "Steve", Objective: "tri_back", Value: 2}},
+		{"[Steve: Triggered [tri_back] (added -2 to value)]", pluginabi.TriggerFired{Player: "Steve", Objective: "tri_back", Value: -2}},
+		{"[Steve: Triggered [tri_back] (set value to 3)]", pluginabi.TriggerFired{Player: "Steve", Objective: "tri_back", Value: 3, Set: true}},
+		{"[Steve: Triggered [tri_back] (set value to 0)]", pluginabi.TriggerFired{Player: "Steve", Objective: "tri_back", Value: 0, Set: true}},
+		{"[Steve: Triggered [Back Home] (set value to -1)]", pluginabi.TriggerFired{Player: "Steve", Objective: "Back Home", Value: -1, Set: true}},
+	} {
+		parser := &GameEventParser{Profiles: &LogProfileSelector{Fixed: VanillaLogProfile}}
+		event, ok := parser.Parse("[12:00:00] [Server thread/INFO]: " + test.message).(pluginabi.TriggerFired)
+		if !ok {
+			t.Errorf("%q is no TriggerFired", test.message)
+			continue
+		}
+		event.EventInfo = pluginabi.EventInfo{}
+		if event != test.want {
+			t.Errorf("%q = %+v, want %+v", test.message, event, test.want)
+		}
+	}
+}
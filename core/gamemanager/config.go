@@ -0,0 +1,86 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ConfigFlags defines the ManagerConfig flags on fs, shared by the standalone
+// GameManager and the daemon's embedded mode. The returned function builds
+// the config once fs has been parsed.
+func ConfigFlags(fs *flag.FlagSet) func() (ManagerConfig, error) {
+	historySize := fs.Int("history", DefaultHistorySize, "number of messages kept per instance for replay")
+	restartPolicy := fs.String("restart", "never", "restart policy after the server exits on its own: never, on-failure or always")
+	restartDelay := fs.Duration("restart-backoff", 5*time.Second, "delay before the first automatic restart, doubled for each further restart")
+	restartMax := fs.Duration("restart-backoff-max", 5*time.Minute, "maximum delay between automatic restarts")
+	restartLimit := fs.Int("restart-limit", 5, "maximum automatic restarts within -restart-window, 0 for unlimited")
+	restartWindow := fs.Duration("restart-window", 30*time.Minute, "window used by -restart-limit and backoff")
+	metricsPeriod := fs.Duration("metrics-interval", 5*time.Second, "default sampling interval of the Metrics stream")
+	logDir := fs.String("log-dir", "consolelogs", "directory of the console log files, one subdirectory per instance, empty to disable")
+	logMaxSize := fs.Int64("log-max-size", 64, "rotate the console log after this many MiB, 0 for no limit")
+	logMaxAge := fs.Duration("log-max-age", 24*time.Hour, "rotate the console log after this long, 0 for no limit")
+	termTimeout := fs.Duration("stop-timeout", 10*time.Second, "time to wait after the stop command before sending SIGTERM")
+	killTimeout := fs.Duration("kill-timeout", 20*time.Second, "time to wait after SIGTERM before killing the process group")
+	watchInterval := fs.Duration("watchdog-interval", time.Minute, "probe the console after it has been silent this long, 0 disables the watchdog")
+	watchTimeout := fs.Duration("watchdog-timeout", 30*time.Second, "time the server has to answer the probe")
+	watchCommand := fs.String("watchdog-command", "list", "console command used as liveness probe")
+	watchResponse := fs.String("watchdog-response", "players online", "regexp matching the answer to -watchdog-command")
+	watchRestart := fs.Bool("watchdog-restart", false, "kill a hung server and restart it with the restart backoff")
+	dumpDir := fs.String("dump-dir", "threaddumps", "directory for thread dumps of hung servers, empty to disable")
+
+	return func() (ManagerConfig, error) {
+		policy, err := ParseRestartPolicy(*restartPolicy)
+		if err != nil {
+			return ManagerConfig{}, err
+		}
+		if *restartWindow <= 0 {
+			return ManagerConfig{}, fmt.Errorf("-restart-window: has to be positive, got %s", *restartWindow)
+		}
+		watchdogResponse, err := regexp.Compile(*watchResponse)
+		if err != nil {
+			return ManagerConfig{}, fmt.Errorf("-watchdog-response: %w", err)
+		}
+		return ManagerConfig{
+			HistorySize: *historySize,
+			Restart: RestartConfig{
+				Policy:      policy,
+				Backoff:     *restartDelay,
+				MaxBackoff:  *restartMax,
+				MaxRestarts: *restartLimit,
+				Window:      *restartWindow,
+			},
+			MetricsInterval: *metricsPeriod,
+			ConsoleLog: ConsoleLogConfig{
+				Dir:     *logDir,
+				MaxSize: *logMaxSize * 1024 * 1024,
+				MaxAge:  *logMaxAge,
+			},
+			TermTimeout: *termTimeout,
+			KillTimeout: *killTimeout,
+			Watchdog: WatchdogConfig{
+				Interval: *watchInterval,
+				Timeout:  *watchTimeout,
+				Command:  *watchCommand,
+				Response: watchdogResponse,
+				DumpDir:  *dumpDir,
+				Restart:  *watchRestart,
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,368 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/fatih/color"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	consoleLogPrefix     = "console-"
+	consoleLogExt        = ".log"
+	consoleLogTimeFormat = "20060102-150405.000"
+	DefaultReadLogLimit  = 1000
+	MaxReadLogLimit      = 10000
+)
+
+var ErrInvalidLogCursor = fmt.Errorf("invalid log cursor")
+
+type ConsoleLogConfig struct {
+	Dir     string // empty disables the console log
+	MaxSize int64  // rotate once the segment grows past this many bytes, 0 for no limit
+	MaxAge  time.Duration
+}
+
+// ConsoleLog writes every console line of an instance to segment files named
+// after the time they were opened. Closed segments are gzipped.
+//
+// Each line is stored as "<RFC3339Nano time>\t<stream>\t<text>".
+type ConsoleLog struct {
+	config ConsoleLogConfig
+	dir    string
+	owner  *MinecraftVistor
+	lock   sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+func NewConsoleLog(mv *MinecraftVistor, config ConsoleLogConfig) *ConsoleLog {
+	if config.Dir == "" {
+		return nil
+	}
+	cl := &ConsoleLog{config: config, dir: filepath.Join(config.Dir, mv.name), owner: mv}
+	// before the first segment is opened, it would be taken for a leftover
+	cl.compressLeftovers()
+	return cl
+}
+
+// compressLeftovers gzips plain segments left behind by an earlier run.
+func (cl *ConsoleLog) compressLeftovers() {
+	segments, err := cl.segments()
+	if err != nil {
+		return
+	}
+	for _, segment := range segments {
+		if !segment.compressed {
+			cl.compress(filepath.Join(cl.dir, segment.name+consoleLogExt))
+		}
+	}
+}
+
+func (cl *ConsoleLog) Write(stream manager.LogStream, received time.Time, line string) {
+	if cl == nil {
+		return
+	}
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	if cl.file != nil && ((cl.config.MaxSize > 0 && cl.size >= cl.config.MaxSize) || (cl.config.MaxAge > 0 && received.Sub(cl.opened) >= cl.config.MaxAge)) {
+		cl.rotate()
+	}
+	if cl.file == nil {
+		if err := cl.open(received); err != nil {
+			cl.owner.Println(color.RedString("无法创建控制台日志文件: %v", err))
+			return
+		}
+	}
+	n, err := cl.file.WriteString(received.Format(time.RFC3339Nano) + "\t" + stream.String() + "\t" + line + "\n")
+	cl.size += int64(n)
+	if err != nil {
+		cl.owner.Println(color.RedString("写入控制台日志失败: %v", err))
+	}
+}
+
+func (cl *ConsoleLog) open(now time.Time) (err error) {
+	if err = os.MkdirAll(cl.dir, 0755); err != nil {
+		return err
+	}
+	name := consoleLogPrefix + now.Format(consoleLogTimeFormat)
+	if cl.exists(name) {
+		// same millisecond as the last segment
+		name += "-" + strconv.FormatInt(now.UnixNano(), 10)
+	}
+	cl.file, err = os.OpenFile(filepath.Join(cl.dir, name+consoleLogExt), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	cl.size = 0
+	cl.opened = now
+	return nil
+}
+
+func (cl *ConsoleLog) exists(name string) bool {
+	for _, path := range []string{name + consoleLogExt, name + consoleLogExt + ".gz"} {
+		if _, err := os.Stat(filepath.Join(cl.dir, path)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (cl *ConsoleLog) rotate() {
+	path := cl.file.Name()
+	cl.file.Close()
+	cl.file = nil
+	go cl.compress(path)
+}
+
+func (cl *ConsoleLog) Close() {
+	if cl == nil {
+		return
+	}
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	if cl.file != nil {
+		path := cl.file.Name()
+		cl.file.Close()
+		cl.file = nil
+		cl.compress(path)
+	}
+}
+
+func (cl *ConsoleLog) compress(path string) {
+	err := gzipFile(path)
+	if err != nil {
+		cl.owner.Println(color.RedString("压缩控制台日志 %s 失败: %v", filepath.Base(path), err))
+	}
+}
+
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+type consoleLogSegment struct {
+	name       string // without extension, doubles as the cursor key
+	start      time.Time
+	compressed bool
+}
+
+func (cl *ConsoleLog) segments() ([]consoleLogSegment, error) {
+	entries, err := os.ReadDir(cl.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var segments []consoleLogSegment
+	for _, entry := range entries {
+		name := entry.Name()
+		compressed := strings.HasSuffix(name, ".gz")
+		name = strings.TrimSuffix(name, ".gz")
+		if !strings.HasPrefix(name, consoleLogPrefix) || !strings.HasSuffix(name, consoleLogExt) {
+			continue
+		}
+		name = strings.TrimSuffix(name, consoleLogExt)
+		stamp := strings.TrimPrefix(name, consoleLogPrefix)
+		if len(stamp) < len(consoleLogTimeFormat) {
+			continue
+		}
+		start, err := time.ParseInLocation(consoleLogTimeFormat, stamp[:len(consoleLogTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		if idx := slices.IndexFunc(segments, func(s consoleLogSegment) bool { return s.name == name }); idx >= 0 {
+			// both the plain and the gzipped file exist while compressing, read the plain one
+			if !compressed {
+				segments[idx].compressed = false
+			}
+			continue
+		}
+		segments = append(segments, consoleLogSegment{name: name, start: start, compressed: compressed})
+	}
+	slices.SortFunc(segments, func(a, b consoleLogSegment) int {
+		if c := a.start.Compare(b.start); c != 0 {
+			return c
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return segments, nil
+}
+
+func (cl *ConsoleLog) openSegment(segment consoleLogSegment) (io.ReadCloser, error) {
+	path := filepath.Join(cl.dir, segment.name+consoleLogExt)
+	if !segment.compressed {
+		file, err := os.Open(path)
+		if err == nil || !os.IsNotExist(err) {
+			return file, err
+		}
+		// compressed in the meantime
+	}
+	file, err := os.Open(path + ".gz")
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, file}, nil
+}
+
+func parseConsoleLogLine(text string) (*manager.LogLine, time.Time, bool) {
+	stamp, rest, ok := strings.Cut(text, "\t")
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	stream, line, ok := strings.Cut(rest, "\t")
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	received, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	return &manager.LogLine{
+		Stream:   manager.LogStream(manager.LogStream_value[stream]),
+		Line:     line,
+		Received: timestamppb.New(received),
+	}, received, true
+}
+
+func parseLogCursor(cursor string) (segment string, line int, err error) {
+	segment, lineStr, ok := strings.Cut(cursor, ":")
+	if !ok {
+		return "", 0, ErrInvalidLogCursor
+	}
+	line, err = strconv.Atoi(lineStr)
+	if err != nil || line < 0 {
+		return "", 0, ErrInvalidLogCursor
+	}
+	return segment, line, nil
+}
+
+// Read returns up to limit lines received in [since, until), a zero until
+// means no upper bound. cursor continues a previous Read, the returned
+// cursor is empty once the range is exhausted.
+func (cl *ConsoleLog) Read(since time.Time, until time.Time, limit int, cursor string) (lines []*manager.LogLine, next string, err error) {
+	if limit <= 0 {
+		limit = DefaultReadLogLimit
+	}
+	limit = min(limit, MaxReadLogLimit)
+	var cursorSegment string
+	var cursorLine int
+	if cursor != "" {
+		cursorSegment, cursorLine, err = parseLogCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	segments, err := cl.segments()
+	if err != nil {
+		return nil, "", err
+	}
+	first := 0
+	if cursorSegment != "" {
+		first = slices.IndexFunc(segments, func(s consoleLogSegment) bool { return s.name == cursorSegment })
+		if first < 0 {
+			return nil, "", ErrInvalidLogCursor
+		}
+	}
+	for i := first; i < len(segments); i++ {
+		segment := segments[i]
+		if i+1 < len(segments) && !segments[i+1].start.After(since) {
+			// the whole segment is older than since
+			continue
+		}
+		if !until.IsZero() && !segment.start.Before(until) {
+			break
+		}
+		skip := 0
+		if segment.name == cursorSegment {
+			skip = cursorLine
+		}
+		reader, err := cl.openSegment(segment)
+		if err != nil {
+			return nil, "", err
+		}
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 1048576), 1048576)
+		lineNo := 0
+		for scanner.Scan() {
+			lineNo++
+			if lineNo <= skip {
+				continue
+			}
+			line, received, ok := parseConsoleLogLine(scanner.Text())
+			if !ok || received.Before(since) {
+				continue
+			}
+			if !until.IsZero() && !received.Before(until) {
+				reader.Close()
+				return lines, "", nil
+			}
+			if len(lines) == limit {
+				reader.Close()
+				return lines, fmt.Sprintf("%s:%d", segment.name, lineNo-1), nil
+			}
+			lines = append(lines, line)
+		}
+		reader.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, "", err
+		}
+	}
+	return lines, "", nil
+}
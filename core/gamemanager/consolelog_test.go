@@ -0,0 +1,60 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+)
+
+func TestConsoleLogLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "test"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	leftover := filepath.Join(dir, "test", consoleLogPrefix+time.Now().Add(-time.Hour).Format(consoleLogTimeFormat)+consoleLogExt)
+	if err := os.WriteFile(leftover, []byte("earlier run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mv := NewMinecraftVistor("test", ManagerConfig{ConsoleLog: ConsoleLogConfig{Dir: dir}})
+	mv.consoleLog.Write(manager.LogStream_stdout, time.Now(), "first line")
+
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Fatalf("leftover segment not compressed: %v", err)
+	}
+	if _, err := os.Stat(leftover + ".gz"); err != nil {
+		t.Fatal(err)
+	}
+	segments, err := mv.consoleLog.segments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 2 || segments[1].compressed {
+		t.Fatalf("segments %+v, want the leftover and a plain current one", segments)
+	}
+	current, err := os.ReadFile(filepath.Join(dir, "test", segments[1].name+consoleLogExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(current), "\tfirst line\n") {
+		t.Fatalf("current segment %q, want the first line", current)
+	}
+	mv.consoleLog.Close()
+}
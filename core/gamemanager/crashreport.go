@@ -0,0 +1,195 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/fatih/color"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// only the headline is needed, don't read huge reports to the end
+const crashReportMaxLines = 4096
+
+var (
+	crashStackFrame     = regexp.MustCompile(`^\s*at ([\w$.]+)\.[\w$<>]+\(`)
+	crashExceptionLine  = regexp.MustCompile(`^([\w$]+\.)+[\w$]*(Exception|Error|Throwable)\b`)
+	jvmSignalLine       = regexp.MustCompile(`^#\s+(\w+ \(0x[0-9a-f]+\))`)
+	jvmProblematicFrame = regexp.MustCompile(`^#\s+\w+\s+\[([^+\]]+)`)
+)
+
+// frames of these packages never point at the culprit
+var crashIgnoredPackages = []string{
+	"java.", "javax.", "jdk.", "sun.", "com.sun.",
+	"net.minecraft.", "com.mojang.",
+	"net.minecraftforge.", "net.neoforged.", "cpw.mods.", "net.fabricmc.", "org.quiltmc.",
+	"org.bukkit.", "org.spigotmc.", "io.papermc.", "com.destroystokyo.", "org.purpurmc.",
+	"org.spongepowered.", "io.netty.", "com.google.", "org.apache.", "it.unimi.",
+}
+
+// findCrashReports parses the crash files in workdir written since the
+// server was started.
+func findCrashReports(workdir string, since time.Time, exitCode int32) (reports []*manager.CrashReport) {
+	var paths []string
+	if matches, err := filepath.Glob(filepath.Join(workdir, "crash-reports", "crash-*.txt")); err == nil {
+		paths = append(paths, matches...)
+	}
+	if matches, err := filepath.Glob(filepath.Join(workdir, "hs_err_pid*.log")); err == nil {
+		paths = append(paths, matches...)
+	}
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil || stat.ModTime().Before(since) {
+			continue
+		}
+		lines, err := readCrashHead(path)
+		if err != nil {
+			continue
+		}
+		var report *manager.CrashReport
+		if strings.HasPrefix(filepath.Base(path), "hs_err_pid") {
+			report = parseJvmFatalError(lines)
+		} else {
+			report = parseMinecraftCrash(lines)
+		}
+		report.Path = path
+		report.Time = timestamppb.New(stat.ModTime())
+		report.ExitCode = exitCode
+		reports = append(reports, report)
+	}
+	slices.SortFunc(reports, func(a, b *manager.CrashReport) int {
+		return a.Time.AsTime().Compare(b.Time.AsTime())
+	})
+	return reports
+}
+
+func readCrashHead(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 65536), 1048576)
+	for scanner.Scan() && len(lines) < crashReportMaxLines {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return lines, scanner.Err()
+}
+
+func parseMinecraftCrash(lines []string) *manager.CrashReport {
+	report := &manager.CrashReport{Kind: manager.CrashReportKind_minecraft_crash}
+	inHead := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case report.Description == "" && strings.HasPrefix(line, "Description:"):
+			report.Description = strings.TrimSpace(strings.TrimPrefix(line, "Description:"))
+		case report.Exception == "" && report.Description != "" && crashExceptionLine.MatchString(trimmed):
+			report.Exception = trimmed
+			if report.Suspect == "" {
+				report.Suspect = firstForeignFrame(lines[i+1:])
+			}
+		case trimmed == "-- Head --":
+			inHead = true
+		case inHead && strings.HasPrefix(trimmed, "--"):
+			inHead = false
+		case inHead && (strings.HasPrefix(trimmed, "Suspected Mod:") || strings.HasPrefix(trimmed, "Suspected Mods:")):
+			_, value, _ := strings.Cut(trimmed, ":")
+			value = strings.TrimSpace(value)
+			if value == "" && i+1 < len(lines) {
+				value = strings.TrimSpace(lines[i+1])
+			}
+			if value != "" && !strings.EqualFold(value, "NONE") && !strings.EqualFold(value, "Unknown") {
+				report.Suspect = value
+			}
+		}
+	}
+	return report
+}
+
+// firstForeignFrame returns the package of the first stack frame that belongs
+// to neither the JDK, the game nor a mod loader.
+func firstForeignFrame(lines []string) string {
+	for _, line := range lines {
+		match := crashStackFrame.FindStringSubmatch(line)
+		if match == nil {
+			if strings.TrimSpace(line) == "" {
+				return ""
+			}
+			continue
+		}
+		class := match[1]
+		if slices.ContainsFunc(crashIgnoredPackages, func(prefix string) bool { return strings.HasPrefix(class, prefix) }) {
+			continue
+		}
+		if idx := strings.LastIndex(class, "."); idx > 0 {
+			return class[:idx]
+		}
+		return class
+	}
+	return ""
+}
+
+func parseJvmFatalError(lines []string) *manager.CrashReport {
+	report := &manager.CrashReport{Kind: manager.CrashReportKind_jvm_fatal_error}
+	for i, line := range lines {
+		content := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		switch {
+		case report.Description == "" && (strings.HasPrefix(content, "A fatal error has been detected") || strings.HasPrefix(content, "There is insufficient memory")):
+			report.Description = content
+			if strings.HasPrefix(content, "There is insufficient memory") && i+1 < len(lines) {
+				if detail := strings.TrimSpace(strings.TrimPrefix(lines[i+1], "#")); detail != "" {
+					report.Exception = detail
+				}
+			}
+		case report.Exception == "" && jvmSignalLine.MatchString(line):
+			report.Exception = jvmSignalLine.FindStringSubmatch(line)[1]
+		case report.Suspect == "" && content == "Problematic frame:" && i+1 < len(lines):
+			if match := jvmProblematicFrame.FindStringSubmatch(lines[i+1]); match != nil {
+				report.Suspect = strings.TrimSpace(match[1])
+			}
+		}
+		if !strings.HasPrefix(line, "#") && report.Description != "" {
+			break
+		}
+	}
+	return report
+}
+
+func (mv *MinecraftVistor) reportCrashes(code int32) {
+	if mv.process == nil || mv.process.Dir == "" {
+		return
+	}
+	for _, report := range findCrashReports(mv.process.Dir, mv.startedAt, code) {
+		mv.Println(color.RedString("发现崩溃报告: "), color.GreenString(report.Path))
+		mv.Println(color.RedString("  描述: "), color.YellowString(report.Description), color.RedString(" 异常: "), color.YellowString(report.Exception), color.RedString(" 嫌疑: "), color.YellowString(report.Suspect))
+		mv.publish(&manager.MessageResponse{
+			Type:    "CrashReport",
+			Content: report.Description,
+			Event:   &manager.MessageResponse_CrashReport{CrashReport: report},
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+)
+
+func TestFindCrashReports(t *testing.T) {
+	workdir := filepath.Join("testdata", "crash")
+	want := map[string]*manager.CrashReport{
+		"crash-2024-05-01_12.00.00-server.txt": {
+			Kind:        manager.CrashReportKind_minecraft_crash,
+			Description: "Exception in server tick loop",
+			Exception:   "java.lang.IllegalStateException: Listener still registered",
+			// the first frame outside the JDK and the game
+			Suspect: "org.example.myplugin",
+		},
+		"crash-2024-05-02_08.30.00-server.txt": {
+			Kind:        manager.CrashReportKind_minecraft_crash,
+			Description: "Ticking entity",
+			Exception:   `java.lang.NullPointerException: Cannot invoke "Object.toString()" because "target" is null`,
+			// the suspected mod of the head wins over the stack
+			Suspect: "Bad Mod (badmod), Version: 1.0",
+		},
+		"hs_err_pid1234.log": {
+			Kind:        manager.CrashReportKind_jvm_fatal_error,
+			Description: "A fatal error has been detected by the Java Runtime Environment:",
+			Exception:   "SIGSEGV (0xb)",
+			Suspect:     "libnative.so",
+		},
+	}
+	reports := findCrashReports(workdir, time.Time{}, 1)
+	if len(reports) != len(want) {
+		t.Fatalf("%d crash reports, want %d", len(reports), len(want))
+	}
+	for _, report := range reports {
+		expected, ok := want[filepath.Base(report.Path)]
+		if !ok {
+			t.Errorf("unexpected crash report %s", report.Path)
+			continue
+		}
+		if report.Kind != expected.Kind || report.Description != expected.Description || report.Exception != expected.Exception || report.Suspect != expected.Suspect {
+			t.Errorf("%s = %s %q %q %q, want %s %q %q %q", filepath.Base(report.Path),
+				report.Kind, report.Description, report.Exception, report.Suspect,
+				expected.Kind, expected.Description, expected.Exception, expected.Suspect)
+		}
+		if report.ExitCode != 1 {
+			t.Errorf("%s exit code %d, want 1", filepath.Base(report.Path), report.ExitCode)
+		}
+	}
+
+	// reports older than the launch belong to an earlier crash
+	if reports := findCrashReports(workdir, time.Now().Add(time.Hour), 1); len(reports) != 0 {
+		t.Errorf("%d crash reports from before the launch", len(reports))
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const embeddedBufferSize = 1024 * 1024
+
+// Embedded runs a ManagerServer inside the calling process, clients reach it
+// over an in-memory connection. The Minecraft server goes down together with
+// the process, run the standalone GameManager to keep it alive across daemon
+// restarts.
+type Embedded struct {
+	server    *ManagerServer
+	rpcServer *grpc.Server
+	listener  *bufconn.Listener
+}
+
+func NewEmbedded(config ManagerConfig) *Embedded {
+	e := &Embedded{
+		server:   NewManagerServer(config),
+		listener: bufconn.Listen(embeddedBufferSize),
+	}
+	e.rpcServer = e.server.NewGRPCServer()
+	go e.rpcServer.Serve(e.listener)
+	return e
+}
+
+// Dial returns a client connection to the embedded server.
+func (e *Embedded) Dial() (*grpc.ClientConn, error) {
+	return grpc.NewClient("passthrough:///embedded",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return e.listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+}
+
+// Close stops every instance and then the server.
+func (e *Embedded) Close() {
+	e.server.StopAll()
+	e.rpcServer.Stop()
+}
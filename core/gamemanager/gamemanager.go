@@ -12,36 +12,34 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package main
+package gamemanager
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
-	"net"
 	"os"
-	"os/exec"
-	"os/signal"
-	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
-	"syscall"
 	"time"
 
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
 	"github.com/fatih/color"
-	"github.com/shirou/gopsutil/v3/process"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/stats"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// Output receives the log of the manager, an embedding process can point it
+// at its own terminal.
+var Output io.Writer = os.Stdout
+
 func Printf(format string, a ...any) (n int, err error) {
 
-	return fmt.Printf(color.YellowString("[")+color.RedString("GameManager")+color.YellowString("] ")+strings.TrimRight(format, "\r\n")+"\r\n", a...)
+	return fmt.Fprintf(Output, color.YellowString("[")+color.RedString("GameManager")+color.YellowString("] ")+strings.TrimRight(format, "\r\n")+"\r\n", a...)
 }
 
 func Println(a ...any) (n int, err error) {
@@ -49,81 +47,33 @@ func Println(a ...any) (n int, err error) {
 	return Printf("%s", strings.TrimRight(fmt.Sprint(a...), "\n"))
 }
 
-type MinecraftVistor struct {
-	process *exec.Cmd
-	pty     io.ReadWriteCloser
-	state   manager.MinecraftState
-}
-
-type WriteLock struct {
-	mutex        sync.Mutex
-	lockedClient *manager.Client
-	time         *time.Timer
-	clientLock   sync.RWMutex
-}
-
-const lockMaxTime = 10 * time.Second
-
-func (wl *WriteLock) Unlock(client *manager.Client) {
-	wl.clientLock.Lock()
-	defer wl.clientLock.Unlock()
-	if wl.lockedClient != nil && wl.lockedClient.Id == client.Id {
-		if wl.time != nil {
-			wl.time.Stop()
-			wl.time = nil
-		}
-		wl.lockedClient = nil
-		wl.mutex.Unlock()
-	}
-}
-
-func (wl *WriteLock) Lock(client *manager.Client, internal bool) {
-	wl.clientLock.RLock()
-	if wl.lockedClient != nil && client.Id == wl.lockedClient.Id {
-		wl.time.Reset(lockMaxTime)
-		if !internal {
-			Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]续期写入锁"))
-		}
-		wl.clientLock.RUnlock()
-		return
-	} else {
-		wl.clientLock.RUnlock()
-	}
-	wl.mutex.Lock()
-	Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]获取写入锁"))
-	wl.clientLock.Lock()
-	wl.lockedClient = client
-	wl.clientLock.Unlock()
-	wl.time = time.AfterFunc(lockMaxTime, func() {
-		Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]的写入锁因超时而被取消"))
-		wl.Unlock(client)
-	})
-}
-
-type ForwardChannelMessage struct {
-	message string
-	locked  bool
-}
-
-type ForwardChannel struct {
-	channel chan *ForwardChannelMessage
-	id      uint64
-}
 type ManagerServer struct {
 	manager.UnimplementedManagerServer
 
-	minecraftInstance  MinecraftVistor
-	forwardWorker      bool
-	forwardChannels    []*ForwardChannel
-	forwardChannelLock sync.RWMutex
-	writeLock          WriteLock
-	messageBus         chan *manager.MessageResponse
+	instances    map[string]*MinecraftVistor
+	instanceLock sync.RWMutex
+	config       ManagerConfig
+}
+
+type ManagerConfig struct {
+	HistorySize     int
+	Restart         RestartConfig
+	MetricsInterval time.Duration
+	ConsoleLog      ConsoleLogConfig
+	TermTimeout     time.Duration // stop command to SIGTERM
+	KillTimeout     time.Duration // SIGTERM to SIGKILL
+	Watchdog        WatchdogConfig
 }
 
 var (
 	ErrMinecraftNotRunning     = fmt.Errorf("minecraft server isn't running")
 	ErrMinecraftAlreadyRunning = fmt.Errorf("minecraft server is already running")
 	ErrNoLockAcquired          = fmt.Errorf("no lock acquired")
+	ErrStaleLockToken          = fmt.Errorf("stale lock token")
+	ErrInvalidInstanceName     = fmt.Errorf("invalid instance name")
+	ErrInstanceNotFound        = fmt.Errorf("instance not found")
+	ErrConsoleLogDisabled      = fmt.Errorf("console log is disabled")
+	ErrNeverStarted            = fmt.Errorf("minecraft server was never started")
 )
 
 type RPCHandler struct {
@@ -142,7 +92,12 @@ func (h *RPCHandler) HandleConn(c context.Context, s stats.ConnStats) {
 	case *stats.ConnEnd:
 		clientId := c.Value(RPCConnInfo("id")).(uint64)
 		Println(color.RedString("客户端 Id:"), color.GreenString("%d ", clientId), color.RedString("断开连接"))
-		h.managerServer.writeLock.Unlock(&manager.Client{Id: clientId})
+		h.managerServer.instanceLock.RLock()
+		for _, instance := range h.managerServer.instances {
+			instance.writeLock.Unlock(&manager.Client{Id: clientId})
+			instance.publishClient(clientId, false)
+		}
+		h.managerServer.instanceLock.RUnlock()
 	}
 }
 
@@ -153,43 +108,6 @@ func (h *RPCHandler) TagRPC(ctx context.Context, s *stats.RPCTagInfo) context.Co
 func (h *RPCHandler) HandleRPC(context.Context, stats.RPCStats) {
 }
 
-type MinecraftState string
-
-func (ms *ManagerServer) logForwardWorker() {
-	if ms.forwardWorker {
-		return
-	}
-	ms.forwardWorker = true
-	if ms.minecraftInstance.pty == nil {
-		ms.forwardWorker = false
-		return
-	}
-	scanner := bufio.NewScanner(ms.minecraftInstance.pty)
-	scanner.Buffer(make([]byte, 1048576), 1048576)
-	for scanner.Scan() {
-		line := scanner.Text()
-		ms.writeLock.clientLock.RLock()
-		locked := ms.writeLock.lockedClient != nil
-		ms.writeLock.clientLock.RUnlock()
-		ms.forwardChannelLock.RLock()
-		for _, target := range ms.forwardChannels {
-			select {
-			default:
-				// 防止阻塞线程
-				Println(color.YellowString("客户端["), color.GreenString("%d", target.id), color.YellowString("]"), color.RedString("日志被丢弃："), color.YellowString(line))
-			case target.channel <- &ForwardChannelMessage{message: line, locked: locked}:
-				// do nothing
-			}
-		}
-		ms.forwardChannelLock.RUnlock()
-	}
-	err := scanner.Err()
-	if err != nil {
-		Println(color.RedString("scanner 意外关闭:%v", err))
-	}
-	ms.forwardWorker = false
-}
-
 type MinecraftPty struct {
 	Stdin     io.WriteCloser
 	Stdout    io.ReadCloser
@@ -238,76 +156,71 @@ func (pty *MinecraftPty) Close() error {
 	return nil
 }
 
-func (ms *ManagerServer) RegisterForwardChannel() (channel *ForwardChannel) {
-	ms.forwardChannelLock.Lock()
-	defer ms.forwardChannelLock.Unlock()
-	channel = &ForwardChannel{channel: make(chan *ForwardChannelMessage, 16384)}
-	ms.forwardChannels = append(ms.forwardChannels, channel)
-	return
-}
-
-func (ms *ManagerServer) UnregisterForwardChannel(channel *ForwardChannel) {
-	ms.forwardChannelLock.Lock()
-	defer ms.forwardChannelLock.Unlock()
-	idx := slices.Index(ms.forwardChannels, channel)
-	if idx >= 0 {
-		ms.forwardChannels = slices.Delete(ms.forwardChannels, idx, idx+1)
+func (ms *ManagerServer) getInstance(client *manager.Client, create bool) (*MinecraftVistor, error) {
+	name := DefaultInstance
+	if client != nil && client.Instance != "" {
+		name = client.Instance
 	}
-}
-
-func (ms *ManagerServer) stopDetect() {
-	if ms.minecraftInstance.process != nil {
-		ms.minecraftInstance.process.Process.Wait()
-		ms.minecraftInstance.pty.Close()
-		ms.minecraftInstance.state = manager.MinecraftState_stopped
-		ms.messageBus <- &manager.MessageResponse{Type: "StateChange", Content: "GameServerStop"}
-		Println(color.RedString("服务器关闭"))
+	if strings.ContainsAny(name, "/\\ ") {
+		return nil, ErrInvalidInstanceName
 	}
-}
-
-func (ms *ManagerServer) Start(ctx context.Context, req *manager.StartRequest) (c *manager.StatusResponse, err error) {
-	if ms.minecraftInstance.state != manager.MinecraftState_stopped {
-		return nil, ErrMinecraftAlreadyRunning
+	ms.instanceLock.RLock()
+	instance, ok := ms.instances[name]
+	ms.instanceLock.RUnlock()
+	if ok {
+		return instance, nil
 	}
-	ms.minecraftInstance.state = manager.MinecraftState_running
-	Println(color.YellowString("客户端["), color.GreenString("%d", req.Client.Id), color.YellowString("]: 启动服务器: "), color.MagentaString(req.Path))
-	cmd := exec.Command(filepath.Clean(req.Path))
-
-	cmd.Dir = filepath.Dir(filepath.Clean(req.Path))
-	cmd.SysProcAttr = MinecraftProcess_SysProcAttr
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, err
+	if !create {
+		return nil, ErrInstanceNotFound
 	}
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
+	ms.instanceLock.Lock()
+	defer ms.instanceLock.Unlock()
+	if instance, ok = ms.instances[name]; !ok {
+		Println(color.YellowString("创建实例: "), color.BlueString(name))
+		instance = NewMinecraftVistor(name, ms.config)
+		ms.instances[name] = instance
 	}
-	stderr, err := cmd.StderrPipe()
+	return instance, nil
+}
+
+func (ms *ManagerServer) Start(ctx context.Context, req *manager.StartRequest) (c *manager.StatusResponse, err error) {
+	instance, err := ms.getInstance(req.Client, true)
 	if err != nil {
 		return nil, err
 	}
-	mcpty := &MinecraftPty{Stdin: stdin, Stdout: stdout, Stderr: stderr}
-	mcpty.Init()
-	err = cmd.Start()
+	err = instance.Start(req)
 	if err != nil {
 		return nil, err
 	}
-	ms.minecraftInstance.process = cmd
-	ms.minecraftInstance.pty = mcpty
-	ms.messageBus <- &manager.MessageResponse{Type: "StateChange", Content: "StartGameServer"}
-	if !ms.forwardWorker {
-		go ms.logForwardWorker()
-	}
-	go ms.stopDetect()
 	return &manager.StatusResponse{
-		State: ms.minecraftInstance.state,
+		State: instance.state,
 	}, nil
 }
 
-func (ms *ManagerServer) Message(client *manager.Client, server manager.Manager_MessageServer) error {
-	Println(color.YellowString("接受客户端["), color.GreenString("%d", client.Id), color.YellowString("]的消息流监听请求"))
-	message := ms.RegisterForwardChannel()
+func (ms *ManagerServer) Message(req *manager.MessageRequest, server manager.Manager_MessageServer) error {
+	client := &manager.Client{Id: req.Id, Instance: req.Instance}
+	instance, err := ms.getInstance(client, true)
+	if err != nil {
+		return err
+	}
+	instance.Println(color.YellowString("接受客户端["), color.GreenString("%d", client.GetId()), color.YellowString("]的消息流监听请求"))
+	message, replay, gap, missed := instance.RegisterForwardChannelSince(client.GetId(), req.Since)
+	send := func(msg *manager.MessageResponse) {
+		msg = proto.Clone(msg).(*manager.MessageResponse)
+		msg.Id = message.id
+		server.Send(msg)
+		message.id++
+	}
+	if gap {
+		instance.Println(color.RedString("客户端["), color.GreenString("%d", client.GetId()), color.RedString("]请求的历史消息已被覆盖, 丢失: "), color.GreenString("%d", missed))
+		send(&manager.MessageResponse{Type: "HistoryGap", Instance: instance.name, Event: &manager.MessageResponse_HistoryGap{HistoryGap: &manager.HistoryGap{Missed: missed}}})
+	}
+	if len(replay) > 0 {
+		instance.Println(color.YellowString("向客户端["), color.GreenString("%d", client.GetId()), color.YellowString("]重放 "), color.GreenString("%d", len(replay)), color.YellowString(" 条历史消息"))
+		for _, msg := range replay {
+			send(msg)
+		}
+	}
 forward:
 	for {
 		select {
@@ -315,55 +228,56 @@ forward:
 			if !ok {
 				break forward
 			}
-			server.Send(&manager.MessageResponse{Id: message.id, Type: "stdout", Content: msg.message, Locked: msg.locked})
-			message.id++
-		case msg := <-ms.messageBus:
-			msg.Id = message.id
-			server.Send(msg)
-			message.id++
+			send(msg.response)
 		case <-server.Context().Done():
-			Println(color.RedString("取消注册客户端["), color.GreenString("%d", client.Id), color.RedString("]的消息流监听请求"))
-			ms.UnregisterForwardChannel(message)
+			instance.Println(color.RedString("取消注册客户端["), color.GreenString("%d", client.GetId()), color.RedString("]的消息流监听请求, 丢弃消息: "), color.GreenString("%d", message.dropped.Load()))
+			instance.UnregisterForwardChannel(message)
 			break forward
 		}
 	}
 	return nil
 }
 
-func (ms *ManagerServer) Lock(ctx context.Context, client *manager.Client) (e *emptypb.Empty, err error) {
-	ms.writeLock.Lock(client, false)
-	select {
-	case <-ctx.Done():
-		Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]已离开排队队列，释放锁"))
-		ms.writeLock.Unlock(client)
-		return nil, nil
-	default:
+func (ms *ManagerServer) Lock(ctx context.Context, client *manager.Client) (r *manager.LockResponse, err error) {
+	instance, err := ms.getInstance(client, true)
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	token, err := instance.writeLock.Lock(ctx, client)
+	if err != nil {
+		instance.Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]已离开排队队列"))
+		return nil, err
+	}
+	return &manager.LockResponse{Token: token}, nil
 }
+
+func (ms *ManagerServer) LockStatus(ctx context.Context, client *manager.Client) (r *manager.LockStatusResponse, err error) {
+	instance, err := ms.getInstance(client, false)
+	if err == ErrInstanceNotFound {
+		return &manager.LockStatusResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return instance.writeLock.Status(), nil
+}
+
 func (ms *ManagerServer) Unlock(ctx context.Context, client *manager.Client) (e *emptypb.Empty, err error) {
-	Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]主动释放锁"))
-	ms.writeLock.Unlock(client)
+	instance, err := ms.getInstance(client, false)
+	if err != nil {
+		return nil, err
+	}
+	instance.Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]主动释放锁"))
+	instance.writeLock.Unlock(client)
 	return nil, nil
 }
 
 func (ms *ManagerServer) Write(ctx context.Context, req *manager.WriteRequest) (e *emptypb.Empty, err error) {
-	ms.writeLock.clientLock.RLock()
-	lockedClient := ms.writeLock.lockedClient
-	ms.writeLock.clientLock.RUnlock()
-	if lockedClient == nil || req.Client == nil {
-		return nil, ErrNoLockAcquired
-	}
-	if lockedClient.Id != req.Client.Id {
-		return nil, ErrNoLockAcquired
-	}
-	if ms.minecraftInstance.state != manager.MinecraftState_running {
-		return nil, ErrMinecraftNotRunning
+	instance, err := ms.getInstance(req.Client, false)
+	if err != nil {
+		return nil, err
 	}
-	ms.writeLock.Lock(req.Client, true)
-	Println(color.YellowString("客户端["), color.GreenString("%d", req.Client.Id), color.YellowString("]向控制台写入[Seq: "), color.GreenString("%d", req.Id), color.YellowString("]: "), color.CyanString(req.Content))
-	ms.minecraftInstance.pty.Write([]byte(req.Content + "\n"))
-	return nil, nil
+	return nil, instance.Write(req.Client, req.Id, req.Token, req.Content)
 }
 
 func (ms *ManagerServer) Login(ctx context.Context, req *emptypb.Empty) (c *manager.Client, err error) {
@@ -371,116 +285,171 @@ func (ms *ManagerServer) Login(ctx context.Context, req *emptypb.Empty) (c *mana
 		Id: ctx.Value(RPCConnInfo("id")).(uint64),
 	}
 	Println(color.YellowString("接受新客户端链接，分配 Id:%s", color.GreenString("%d", c.Id)))
+	ms.instanceLock.RLock()
+	for _, instance := range ms.instances {
+		instance.publishClient(c.Id, true)
+	}
+	ms.instanceLock.RUnlock()
 	return
 }
 
 func (ms *ManagerServer) Status(ctx context.Context, client *manager.Client) (c *manager.StatusResponse, err error) {
-	if ms.minecraftInstance.process == nil || ms.minecraftInstance.process.Process == nil {
+	instance, err := ms.getInstance(client, false)
+	if err == ErrInstanceNotFound {
 		return &manager.StatusResponse{
 			State: manager.MinecraftState_stopped,
 		}, nil
 	}
-	MinecraftProcess, err := process.NewProcess(int32(ms.minecraftInstance.process.Process.Pid))
-	Usedmemory := uint64(0)
-	if err == nil {
-		memoryInfo, err := MinecraftProcess.MemoryInfo()
-		if err == nil {
-			Usedmemory += memoryInfo.RSS
-		}
-		children, err := MinecraftProcess.Children()
-		if err == nil {
-			for _, p := range children {
-				memoryInfo, err = p.MemoryInfo()
-				if err == nil {
-					Usedmemory += memoryInfo.RSS
-				}
-			}
-		}
+	if err != nil {
+		return nil, err
 	}
-	return &manager.StatusResponse{
-		State:      ms.minecraftInstance.state,
-		Usedmemory: Usedmemory,
-	}, nil
+	return instance.Status(), nil
 }
 
-func (ms *ManagerServer) printLogWorker() {
-	message := ms.RegisterForwardChannel()
+func (ms *ManagerServer) ListInstances(ctx context.Context, client *manager.Client) (c *manager.InstanceList, err error) {
+	ms.instanceLock.RLock()
+	instances := make([]*MinecraftVistor, 0, len(ms.instances))
+	for _, instance := range ms.instances {
+		instances = append(instances, instance)
+	}
+	ms.instanceLock.RUnlock()
+	slices.SortFunc(instances, func(a *MinecraftVistor, b *MinecraftVistor) int {
+		return strings.Compare(a.name, b.name)
+	})
+	c = &manager.InstanceList{}
+	for _, instance := range instances {
+		status := instance.Status()
+		c.Instances = append(c.Instances, &manager.InstanceStatus{Name: instance.name, State: status.State, Usedmemory: status.Usedmemory})
+	}
+	return c, nil
+}
 
-	go func() {
-		for {
-			msg, ok := <-message.channel
-			if !ok {
-				break
-			}
-			ms.writeLock.clientLock.RLock()
-			lockedClient := ms.writeLock.lockedClient
-			ms.writeLock.clientLock.RUnlock()
-			if lockedClient != nil {
-				Println(color.YellowString("服务器日志[Locked Client: "), color.GreenString("%d", lockedClient.Id), color.YellowString("]: "), color.CyanString(msg.message))
-			}
+func (ms *ManagerServer) Metrics(req *manager.MetricsRequest, server manager.Manager_MetricsServer) error {
+	instance, err := ms.getInstance(req.Client, false)
+	if err != nil {
+		return err
+	}
+	interval := ms.config.MetricsInterval
+	if req.Interval != nil {
+		interval = req.Interval.AsDuration()
+	}
+	interval = max(interval, minMetricsInterval)
+	instance.Println(color.YellowString("客户端["), color.GreenString("%d", req.Client.GetId()), color.YellowString("]订阅资源监控, 间隔: "), color.GreenString("%s", interval))
+	sampler := NewMetricsSampler(instance)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := server.Send(sampler.Sample()); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-server.Context().Done():
+			return nil
 		}
-	}()
+	}
 }
 
-func (ms *ManagerServer) Stop(ctx context.Context, client *manager.Client) (c *emptypb.Empty, err error) {
-	Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]请求关闭服务器"))
-	message := ms.RegisterForwardChannel()
+func (ms *ManagerServer) ReadLog(ctx context.Context, req *manager.ReadLogRequest) (r *manager.ReadLogResponse, err error) {
+	instance, err := ms.getInstance(req.Client, false)
+	if err != nil {
+		return nil, err
+	}
+	if instance.consoleLog == nil {
+		return nil, ErrConsoleLogDisabled
+	}
+	var until time.Time
+	if req.Until != nil {
+		until = req.Until.AsTime()
+	}
+	lines, cursor, err := instance.consoleLog.Read(req.Since.AsTime(), until, int(req.Limit), req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	return &manager.ReadLogResponse{Lines: lines, Cursor: cursor}, nil
+}
 
-	go func() {
-		for {
-			msg, ok := <-message.channel
-			if !ok {
-				break
-			}
-			Println(color.YellowString("服务器日志: "), color.CyanString(msg.message))
-		}
-	}()
-	if ms.minecraftInstance.state == manager.MinecraftState_running {
-		ms.minecraftInstance.pty.Write([]byte("stop\n"))
-		time.AfterFunc(10*time.Second, func() {
-			err := ms.minecraftInstance.process.Process.Signal(syscall.SIGTERM)
-			Println(color.RedString("服务器关闭超时，发送 SIGTERM 信号 err:"), color.GreenString("%v", err))
-		})
-		ms.minecraftInstance.process.Process.Wait()
-		ms.minecraftInstance.pty.Close()
-	}
-	ms.UnregisterForwardChannel(message)
+func (ms *ManagerServer) Stop(ctx context.Context, client *manager.Client) (c *emptypb.Empty, err error) {
+	instance, err := ms.getInstance(client, false)
+	if err != nil {
+		return nil, err
+	}
+	instance.Stop(client, ms.config.TermTimeout, ms.config.KillTimeout)
 	return nil, nil
 }
 
-func NewManagerServer() (m *ManagerServer) {
-	m = &ManagerServer{
-		messageBus: make(chan *manager.MessageResponse, 32),
+func (ms *ManagerServer) stopTimeouts(req *manager.StopRequest) (termTimeout time.Duration, killTimeout time.Duration) {
+	termTimeout, killTimeout = ms.config.TermTimeout, ms.config.KillTimeout
+	if req.TermTimeout != nil {
+		termTimeout = req.TermTimeout.AsDuration()
 	}
-	m.printLogWorker()
-	return m
+	if req.KillTimeout != nil {
+		killTimeout = req.KillTimeout.AsDuration()
+	}
+	return
 }
 
-func main() {
+func (ms *ManagerServer) Shutdown(ctx context.Context, req *manager.StopRequest) (r *manager.StopResponse, err error) {
+	instance, err := ms.getInstance(req.Client, false)
+	if err != nil {
+		return nil, err
+	}
+	termTimeout, killTimeout := ms.stopTimeouts(req)
+	stage, code := instance.Stop(req.Client, termTimeout, killTimeout)
+	return &manager.StopResponse{Stage: stage, ExitCode: code, State: instance.state}, nil
+}
 
-	managerServer := NewManagerServer()
-	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", 12345))
+func (ms *ManagerServer) Kill(ctx context.Context, client *manager.Client) (r *manager.StopResponse, err error) {
+	instance, err := ms.getInstance(client, false)
 	if err != nil {
-		os.Exit(1)
-	}
-	rpcServer := grpc.NewServer(grpc.StatsHandler(&RPCHandler{managerServer: managerServer}))
-	manager.RegisterManagerServer(rpcServer, managerServer)
-	go func() {
-		rpcServer.Serve(listener)
-	}()
-	sysSignals := make(chan os.Signal, 1)
-	signal.Notify(sysSignals, syscall.SIGINT, syscall.SIGTERM)
-	Println(color.YellowString("GameManager已启动, 等待客户端链接"))
-	for {
-		<-sysSignals
-		Println(color.RedString("接受到 SIGTERM/SIGINT 信号，正在关闭服务器"))
+		return nil, err
+	}
+	stage, code := instance.Kill(client)
+	return &manager.StopResponse{Stage: stage, ExitCode: code, State: instance.state}, nil
+}
+
+func (ms *ManagerServer) Restart(ctx context.Context, req *manager.StopRequest) (r *manager.StopResponse, err error) {
+	instance, err := ms.getInstance(req.Client, false)
+	if err != nil {
+		return nil, err
+	}
+	termTimeout, killTimeout := ms.stopTimeouts(req)
+	stage, code, err := instance.Restart(req.Client, termTimeout, killTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &manager.StopResponse{Stage: stage, ExitCode: code, State: instance.state}, nil
+}
 
-		if managerServer.minecraftInstance.process != nil {
-			managerServer.Stop(context.Background(), &manager.Client{
-				Id: 0,
-			})
+func (ms *ManagerServer) StopAll() {
+	ms.instanceLock.RLock()
+	defer ms.instanceLock.RUnlock()
+	var wg sync.WaitGroup
+	for _, instance := range ms.instances {
+		if instance.process == nil {
+			continue
 		}
-		Println(color.RedString("GameManager已关闭"))
-		os.Exit(0)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instance.Stop(&manager.Client{Id: 0, Instance: instance.name}, ms.config.TermTimeout, ms.config.KillTimeout)
+			instance.consoleLog.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+// NewGRPCServer returns a gRPC server serving ms.
+func (ms *ManagerServer) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	rpcServer := grpc.NewServer(append(opts, grpc.StatsHandler(&RPCHandler{managerServer: ms}))...)
+	manager.RegisterManagerServer(rpcServer, ms)
+	return rpcServer
+}
+
+func NewManagerServer(config ManagerConfig) (m *ManagerServer) {
+	m = &ManagerServer{
+		instances: make(map[string]*MinecraftVistor),
+		config:    config,
 	}
+	return m
 }
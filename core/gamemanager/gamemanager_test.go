@@ -0,0 +1,94 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"context"
+	"testing"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// embeddedClient returns a logged in client of a fresh embedded GameManager.
+func embeddedClient(t *testing.T, config ManagerConfig) (manager.ManagerClient, *manager.Client) {
+	t.Helper()
+	embedded := NewEmbedded(config)
+	t.Cleanup(embedded.Close)
+	conn, err := embedded.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := manager.NewManagerClient(conn)
+	info, err := client.Login(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, info
+}
+
+func TestMessageHistoryGap(t *testing.T) {
+	client, info := embeddedClient(t, ManagerConfig{HistorySize: 4})
+	ctx := context.Background()
+	// every lock change is a message of the instance
+	for range 5 {
+		if _, err := client.Lock(ctx, info); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Unlock(ctx, info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream, err := client.Message(ctx, &manager.MessageRequest{Id: info.Id, Instance: info.Instance, Since: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gap := first.GetHistoryGap()
+	if gap == nil {
+		t.Fatalf("first message %v, want a HistoryGap", first)
+	}
+	// 10 messages, 4 kept, the client has seen the first one
+	if gap.Missed != 5 {
+		t.Errorf("missed %d, want 5", gap.Missed)
+	}
+	next, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.GetLock() == nil || next.Seq != 7 {
+		t.Errorf("replay starts with %v, want the lock change with seq 7", next)
+	}
+}
+
+func TestMessageRequestAcceptsClient(t *testing.T) {
+	// what a daemon from before MessageRequest sends
+	old, err := proto.Marshal(&manager.Client{Id: 7, Instance: "survival"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &manager.MessageRequest{}
+	if err := proto.Unmarshal(old, req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Id != 7 || req.Instance != "survival" || req.Since != 0 {
+		t.Fatalf("decoded %v, want id 7 of survival without since", req)
+	}
+}
@@ -14,10 +14,11 @@
 
 //go:build unix
 
-package main
+package gamemanager
 
 import (
 	"io"
+	"os"
 	"syscall"
 )
 
@@ -32,3 +33,8 @@ func (pty *MinecraftPty) readerWrapper(r io.Reader) io.Reader {
 func (pty *MinecraftPty) writerWrapper(w io.WriteCloser) io.WriteCloser {
 	return w
 }
+
+// killProcessGroup kills the server together with everything it spawned.
+func killProcessGroup(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
@@ -14,10 +14,11 @@
 
 //go:build windows
 
-package main
+package gamemanager
 
 import (
 	"io"
+	"os"
 	"syscall"
 
 	"golang.org/x/text/encoding/simplifiedchinese"
@@ -35,3 +36,7 @@ func (pty *MinecraftPty) readerWrapper(r io.Reader) io.Reader {
 func (pty *MinecraftPty) writerWrapper(w io.WriteCloser) io.WriteCloser {
 	return transform.NewWriter(w, simplifiedchinese.GBK.NewEncoder())
 }
+
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
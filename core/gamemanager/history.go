@@ -0,0 +1,89 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"sync"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+)
+
+const DefaultHistorySize = 8192
+
+// MessageHistory is a fixed size ring buffer of the most recent messages of
+// an instance, used to replay what a client missed while reconnecting.
+type MessageHistory struct {
+	lock   sync.RWMutex
+	buffer []*manager.MessageResponse
+	start  int
+	size   int
+	seq    uint64
+}
+
+func NewMessageHistory(capacity int) *MessageHistory {
+	if capacity <= 0 {
+		capacity = DefaultHistorySize
+	}
+	return &MessageHistory{buffer: make([]*manager.MessageResponse, capacity)}
+}
+
+// Append assigns the next sequence number to msg and stores it. msg must not
+// be modified afterwards.
+func (h *MessageHistory) Append(msg *manager.MessageResponse) uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.seq++
+	msg.Seq = h.seq
+	if h.size < len(h.buffer) {
+		h.buffer[(h.start+h.size)%len(h.buffer)] = msg
+		h.size++
+	} else {
+		h.buffer[h.start] = msg
+		h.start = (h.start + 1) % len(h.buffer)
+	}
+	return h.seq
+}
+
+func (h *MessageHistory) Seq() uint64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.seq
+}
+
+// Since returns the stored messages newer than seq. gap reports that some
+// messages after seq are no longer available, missed is their count or 0 if
+// seq comes from an earlier GameManager run.
+func (h *MessageHistory) Since(seq uint64) (msgs []*manager.MessageResponse, gap bool, missed uint64) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	if seq == h.seq {
+		return nil, false, 0
+	}
+	oldest := h.seq - uint64(h.size) + 1
+	if seq > h.seq {
+		gap = true
+		seq = oldest - 1
+	} else if seq+1 < oldest {
+		gap = true
+		missed = oldest - seq - 1
+		seq = oldest - 1
+	}
+	count := int(h.seq - seq)
+	msgs = make([]*manager.MessageResponse, 0, count)
+	for i := h.size - count; i < h.size; i++ {
+		msgs = append(msgs, h.buffer[(h.start+i)%len(h.buffer)])
+	}
+	return msgs, gap, missed
+}
@@ -0,0 +1,368 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/fatih/color"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const DefaultInstance = "default"
+
+// stoppingLine is what every flavor logs once the server is shutting down,
+// "]:" or fabric's ")" keeps chat from matching.
+var stoppingLine = regexp.MustCompile(`(?:\]:|\)) Stopping server$`)
+
+type MinecraftVistor struct {
+	name               string
+	process            *exec.Cmd
+	pty                io.ReadWriteCloser
+	state              manager.MinecraftState
+	forwardChannels    []*ForwardChannel
+	forwardChannelLock sync.RWMutex
+	writeLock          WriteLock
+	history            *MessageHistory
+	launch             *manager.StartRequest
+	stopCommand        string
+	stopRequested      atomic.Bool
+	restart            RestartConfig
+	restartPolicy      atomic.Int32 // manager.RestartPolicy of the current launch, restart.Policy unless the StartRequest sets one
+	stoppingLogged     atomic.Bool  // the server logged that it is stopping
+	restartTimes       []time.Time
+	restartTimer       *time.Timer
+	restartLock        sync.Mutex
+	consoleLog         *ConsoleLog
+	exited             chan struct{}
+	exitCode           int32
+	stopStage          atomic.Int32
+	watchdogConfig     WatchdogConfig
+	lastOutput         atomic.Int64
+	hungRestart        atomic.Bool
+	startedAt          time.Time
+}
+
+func NewMinecraftVistor(name string, config ManagerConfig) (mv *MinecraftVistor) {
+	mv = &MinecraftVistor{
+		name:    name,
+		history: NewMessageHistory(config.HistorySize),
+		restart: config.Restart,
+
+		watchdogConfig: config.Watchdog,
+	}
+	mv.writeLock.instance = name
+	mv.writeLock.onChange = mv.publishLock
+	mv.consoleLog = NewConsoleLog(mv, config.ConsoleLog)
+	mv.printLogWorker()
+	return mv
+}
+
+func (mv *MinecraftVistor) Println(a ...any) (n int, err error) {
+	return Println(append([]any{color.YellowString("<"), color.BlueString(mv.name), color.YellowString("> ")}, a...)...)
+}
+
+type ForwardChannelMessage struct {
+	message  string
+	response *manager.MessageResponse
+}
+
+type ForwardChannel struct {
+	channel chan *ForwardChannelMessage
+	id      uint64
+	client  uint64
+	dropped atomic.Uint64
+}
+
+// forward stores msg in the history and fans it out to every registered
+// channel, a subscriber that can't keep up loses the message instead of
+// blocking the others.
+func (mv *MinecraftVistor) forward(msg *manager.MessageResponse, line string) {
+	mv.forwardChannelLock.RLock()
+	defer mv.forwardChannelLock.RUnlock()
+	mv.history.Append(msg)
+	for _, target := range mv.forwardChannels {
+		select {
+		default:
+			// 防止阻塞线程
+			dropped := target.dropped.Add(1)
+			mv.Println(color.YellowString("客户端["), color.GreenString("%d", target.client), color.YellowString("]"), color.RedString("消息被丢弃(共 %d 条)：", dropped), color.YellowString(msg.Type+" "+line))
+		case target.channel <- &ForwardChannelMessage{message: line, response: msg}:
+			// do nothing
+		}
+	}
+}
+
+func (mv *MinecraftVistor) logForwardWorker(stream manager.LogStream, reader io.Reader) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 1048576), 1048576)
+	for scanner.Scan() {
+		line := scanner.Text()
+		received := time.Now()
+		mv.lastOutput.Store(received.UnixNano())
+		if stream == manager.LogStream_stdout && stoppingLine.MatchString(line) {
+			mv.stoppingLogged.Store(true)
+		}
+		mv.consoleLog.Write(stream, received, line)
+		mv.writeLock.clientLock.RLock()
+		locked := mv.writeLock.lockedClient != nil
+		mv.writeLock.clientLock.RUnlock()
+		msg := &manager.MessageResponse{
+			Type:     "stdout",
+			Content:  line,
+			Locked:   locked,
+			Instance: mv.name,
+			Event:    &manager.MessageResponse_Log{Log: &manager.LogLine{Stream: stream, Line: line, Received: timestamppb.New(received)}},
+		}
+		mv.forward(msg, line)
+	}
+	err := scanner.Err()
+	if err != nil && !errors.Is(err, os.ErrClosed) {
+		mv.Println(color.RedString("%s scanner 意外关闭:%v", stream, err))
+	}
+}
+
+func (mv *MinecraftVistor) RegisterForwardChannel() (channel *ForwardChannel) {
+	mv.forwardChannelLock.Lock()
+	defer mv.forwardChannelLock.Unlock()
+	channel = &ForwardChannel{channel: make(chan *ForwardChannelMessage, 16384)}
+	mv.forwardChannels = append(mv.forwardChannels, channel)
+	return
+}
+
+// RegisterForwardChannelSince registers a forward channel and returns the
+// history after since in one step, so no line is lost or duplicated between
+// the replay and the channel.
+func (mv *MinecraftVistor) RegisterForwardChannelSince(client uint64, since uint64) (channel *ForwardChannel, replay []*manager.MessageResponse, gap bool, missed uint64) {
+	mv.forwardChannelLock.Lock()
+	defer mv.forwardChannelLock.Unlock()
+	if since != 0 {
+		replay, gap, missed = mv.history.Since(since)
+	}
+	channel = &ForwardChannel{channel: make(chan *ForwardChannelMessage, 16384), client: client}
+	mv.forwardChannels = append(mv.forwardChannels, channel)
+	return
+}
+
+var stateContent = map[manager.StateReason]string{
+	manager.StateReason_server_started:        "StartGameServer",
+	manager.StateReason_server_stopped:        "GameServerStop",
+	manager.StateReason_server_crashed:        "GameServerCrashed",
+	manager.StateReason_server_restarting:     "GameServerRestarting",
+	manager.StateReason_restart_limit_reached: "RestartLimitReached",
+	manager.StateReason_restart_failed:        "RestartFailed",
+}
+
+func (mv *MinecraftVistor) publishState(reason manager.StateReason, oldState manager.MinecraftState, exitCode int32) {
+	var stopStage manager.StopStage
+	if reason == manager.StateReason_server_stopped && mv.stopRequested.Load() {
+		stopStage = manager.StopStage(mv.stopStage.Load())
+	}
+	mv.publish(&manager.MessageResponse{
+		Type:    "StateChange",
+		Content: stateContent[reason],
+		Event: &manager.MessageResponse_State{State: &manager.StateTransition{
+			Reason:    reason,
+			OldState:  oldState,
+			NewState:  mv.state,
+			ExitCode:  exitCode,
+			StopStage: stopStage,
+		}},
+	})
+}
+
+func (mv *MinecraftVistor) publishLock(owner uint64, previous uint64) {
+	mv.publish(&manager.MessageResponse{
+		Type:  "LockChange",
+		Event: &manager.MessageResponse_Lock{Lock: &manager.LockChange{Owner: owner, Previous: previous}},
+	})
+}
+
+func (mv *MinecraftVistor) publishClient(client uint64, connected bool) {
+	mv.publish(&manager.MessageResponse{
+		Type:  "ClientEvent",
+		Event: &manager.MessageResponse_Client{Client: &manager.ClientEvent{Client: client, Connected: connected}},
+	})
+}
+
+func (mv *MinecraftVistor) publish(msg *manager.MessageResponse) {
+	msg.Instance = mv.name
+	mv.forward(msg, msg.Content)
+}
+
+func (mv *MinecraftVistor) UnregisterForwardChannel(channel *ForwardChannel) {
+	mv.forwardChannelLock.Lock()
+	defer mv.forwardChannelLock.Unlock()
+	idx := slices.Index(mv.forwardChannels, channel)
+	if idx >= 0 {
+		mv.forwardChannels = slices.Delete(mv.forwardChannels, idx, idx+1)
+	}
+}
+
+func (mv *MinecraftVistor) stopDetect(exited chan struct{}) {
+	if mv.process != nil {
+		state, _ := mv.process.Process.Wait()
+		mv.pty.Close()
+		oldState := mv.state
+		mv.state = manager.MinecraftState_stopped
+		mv.exitCode = exitCode(state)
+		if !mv.stopRequested.Load() && state != nil && state.Success() && mv.stoppingLogged.Load() {
+			// stopped from the game or over RCON
+			mv.stopRequested.Store(true)
+			mv.stopStage.Store(int32(manager.StopStage_stop_command))
+		}
+		exit := launchExit{
+			state:         state,
+			stopRequested: mv.stopRequested.Load(),
+			hungRestart:   mv.hungRestart.Load(),
+			policy:        manager.RestartPolicy(mv.restartPolicy.Load()),
+		}
+		mv.publishState(manager.StateReason_server_stopped, oldState, mv.exitCode)
+		mv.Println(color.RedString("服务器关闭"))
+		mv.handleExit(exit)
+		// a waiting Restart starts the next launch as soon as this is closed,
+		// everything about this one has to be done by then
+		close(exited)
+	}
+}
+
+func (mv *MinecraftVistor) Start(req *manager.StartRequest) (err error) {
+	if mv.state != manager.MinecraftState_stopped {
+		return ErrMinecraftAlreadyRunning
+	}
+	cmd, stopCommand, err := buildCommand(req)
+	if err != nil {
+		mv.Println(color.RedString("客户端["), color.GreenString("%d", req.Client.GetId()), color.RedString("]的启动参数无效: %v", err))
+		return err
+	}
+	mv.cancelRestart()
+	mv.state = manager.MinecraftState_running
+	mv.launch = &manager.StartRequest{Path: req.Path, Profile: req.Profile, Restart: req.Restart}
+	mv.stopCommand = stopCommand
+	mv.stopRequested.Store(false)
+	mv.stoppingLogged.Store(false)
+	mv.hungRestart.Store(false)
+	mv.stopStage.Store(int32(manager.StopStage_stop_stage_unknown))
+	policy := mv.restart.Policy
+	if req.Restart != manager.RestartPolicy_restart_default {
+		policy = req.Restart
+	}
+	mv.restartPolicy.Store(int32(policy))
+	mv.Println(color.YellowString("客户端["), color.GreenString("%d", req.Client.GetId()), color.YellowString("]: 启动服务器: "), color.MagentaString(strings.Join(cmd.Args, " ")), color.YellowString(" 工作目录: "), color.MagentaString(cmd.Dir))
+	cmd.SysProcAttr = MinecraftProcess_SysProcAttr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		mv.state = manager.MinecraftState_stopped
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		mv.state = manager.MinecraftState_stopped
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		mv.state = manager.MinecraftState_stopped
+		return err
+	}
+	mcpty := &MinecraftPty{Stdin: stdin, Stdout: stdout, Stderr: stderr}
+	mcpty.Init()
+	err = cmd.Start()
+	if err != nil {
+		mv.state = manager.MinecraftState_stopped
+		return err
+	}
+	mv.process = cmd
+	mv.pty = mcpty
+	exited := make(chan struct{})
+	mv.exited = exited
+	mv.startedAt = time.Now()
+	mv.lastOutput.Store(time.Now().UnixNano())
+	mv.publishState(manager.StateReason_server_started, manager.MinecraftState_stopped, 0)
+	go mv.logForwardWorker(manager.LogStream_stdout, mcpty.stdout)
+	go mv.logForwardWorker(manager.LogStream_stderr, mcpty.stderr)
+	go mv.stopDetect(exited)
+	go mv.watchdog(exited)
+	return nil
+}
+
+func (mv *MinecraftVistor) Write(client *manager.Client, id uint64, token uint64, content string) error {
+	if err := mv.writeLock.Check(client, token); err != nil {
+		return err
+	}
+	if mv.state != manager.MinecraftState_running {
+		return ErrMinecraftNotRunning
+	}
+	if strings.TrimSpace(content) == mv.stopCommand {
+		mv.stopRequested.Store(true)
+		mv.stopStage.Store(int32(manager.StopStage_stop_command))
+	}
+	mv.Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]向控制台写入[Seq: "), color.GreenString("%d", id), color.YellowString("]: "), color.CyanString(content))
+	mv.pty.Write([]byte(content + "\n"))
+	return nil
+}
+
+func (mv *MinecraftVistor) Status() *manager.StatusResponse {
+	tree := mv.processTree()
+	if tree == nil {
+		return &manager.StatusResponse{
+			State: manager.MinecraftState_stopped,
+		}
+	}
+	Usedmemory := uint64(0)
+	for _, p := range tree {
+		memoryInfo, err := p.MemoryInfo()
+		if err == nil {
+			Usedmemory += memoryInfo.RSS
+		}
+	}
+	return &manager.StatusResponse{
+		State:      mv.state,
+		Usedmemory: Usedmemory,
+	}
+}
+
+func (mv *MinecraftVistor) printLogWorker() {
+	message := mv.RegisterForwardChannel()
+
+	go func() {
+		for {
+			msg, ok := <-message.channel
+			if !ok {
+				break
+			}
+			if msg.response.GetLog() == nil {
+				continue
+			}
+			mv.writeLock.clientLock.RLock()
+			lockedClient := mv.writeLock.lockedClient
+			mv.writeLock.clientLock.RUnlock()
+			if lockedClient != nil {
+				mv.Println(color.YellowString("服务器日志[Locked Client: "), color.GreenString("%d", lockedClient.Id), color.YellowString("]: "), color.CyanString(msg.message))
+			}
+		}
+	}()
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForwardConcurrentSubscribers(t *testing.T) {
+	const (
+		subscribers = 8
+		events      = 1000
+	)
+	mv := NewMinecraftVistor("test", ManagerConfig{})
+
+	channels := make([]*ForwardChannel, subscribers)
+	var registered sync.WaitGroup
+	for i := range channels {
+		registered.Add(1)
+		go func() {
+			defer registered.Done()
+			channels[i], _, _, _ = mv.RegisterForwardChannelSince(uint64(i+1), 0)
+		}()
+	}
+	registered.Wait()
+
+	received := make([][]uint64, subscribers)
+	var readers sync.WaitGroup
+	for i, channel := range channels {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for len(received[i]) < events {
+				select {
+				case msg := <-channel.channel:
+					received[i] = append(received[i], msg.response.GetClient().GetClient())
+				case <-time.After(5 * time.Second):
+					return
+				}
+			}
+		}()
+	}
+
+	for n := range uint64(events) {
+		mv.publishClient(n, true)
+	}
+	readers.Wait()
+
+	for i, channel := range channels {
+		if len(received[i]) != events {
+			t.Fatalf("subscriber %d received %d of %d events", i, len(received[i]), events)
+		}
+		for n, client := range received[i] {
+			if client != uint64(n) {
+				t.Fatalf("subscriber %d: event %d is %d, out of order", i, n, client)
+			}
+		}
+		if dropped := channel.dropped.Load(); dropped != 0 {
+			t.Errorf("subscriber %d dropped %d events", i, dropped)
+		}
+	}
+}
+
+func TestForwardSlowSubscriber(t *testing.T) {
+	const events = 100
+	mv := NewMinecraftVistor("test", ManagerConfig{})
+	fast, _, _, _ := mv.RegisterForwardChannelSince(1, 0)
+	// never read, fills up after a few events
+	slow := &ForwardChannel{channel: make(chan *ForwardChannelMessage, 4), client: 2}
+	mv.forwardChannelLock.Lock()
+	mv.forwardChannels = append(mv.forwardChannels, slow)
+	mv.forwardChannelLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := range uint64(events) {
+			mv.publishClient(n, true)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish blocked on the slow subscriber")
+	}
+
+	for n := range uint64(events) {
+		msg := <-fast.channel
+		if client := msg.response.GetClient().GetClient(); client != n {
+			t.Fatalf("fast subscriber: event %d is %d", n, client)
+		}
+	}
+	if dropped := fast.dropped.Load(); dropped != 0 {
+		t.Errorf("fast subscriber dropped %d events", dropped)
+	}
+	if dropped := slow.dropped.Load(); dropped != events-4 {
+		t.Errorf("slow subscriber dropped %d events, want %d", dropped, events-4)
+	}
+}
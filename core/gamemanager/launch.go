@@ -0,0 +1,151 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+)
+
+const defaultStopCommand = "stop"
+
+var ErrInvalidLaunchProfile = fmt.Errorf("invalid launch profile")
+
+func launchError(format string, a ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidLaunchProfile, fmt.Sprintf(format, a...))
+}
+
+// buildCommand turns a StartRequest into the command to spawn, checking that
+// everything it refers to exists. It returns the stop command to use as well.
+func buildCommand(req *manager.StartRequest) (cmd *exec.Cmd, stopCommand string, err error) {
+	profile := req.GetProfile()
+	if profile == nil {
+		if req.GetPath() == "" {
+			return nil, "", launchError("neither a start script nor a launch profile given")
+		}
+		path := filepath.Clean(req.Path)
+		if err := checkFile("start script", path); err != nil {
+			return nil, "", err
+		}
+		cmd = exec.Command(path)
+		cmd.Dir = filepath.Dir(path)
+		return cmd, defaultStopCommand, nil
+	}
+
+	if profile.Jar != "" && profile.ArgsFile != "" {
+		return nil, "", launchError("jar and args_file are mutually exclusive")
+	}
+	if profile.Jar == "" && profile.ArgsFile == "" {
+		return nil, "", launchError("either jar or args_file is required")
+	}
+	target := profile.Jar
+	if target == "" {
+		target = profile.ArgsFile
+	}
+
+	workdir := profile.Workdir
+	if workdir == "" {
+		if !filepath.IsAbs(target) {
+			return nil, "", launchError("workdir is required when %q is a relative path", target)
+		}
+		workdir = filepath.Dir(target)
+	}
+	workdir, err = filepath.Abs(workdir)
+	if err != nil {
+		return nil, "", launchError("workdir %q: %v", profile.Workdir, err)
+	}
+	stat, err := os.Stat(workdir)
+	if err != nil {
+		return nil, "", launchError("workdir %q: %v", workdir, err)
+	}
+	if !stat.IsDir() {
+		return nil, "", launchError("workdir %q is not a directory", workdir)
+	}
+
+	java, err := findJava(profile.Java, workdir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	args := append([]string{}, profile.JvmArgs...)
+	if profile.Jar != "" {
+		if err := checkFile("jar", resolvePath(workdir, profile.Jar)); err != nil {
+			return nil, "", err
+		}
+		args = append(args, "-jar", profile.Jar)
+	} else {
+		if err := checkFile("args file", resolvePath(workdir, profile.ArgsFile)); err != nil {
+			return nil, "", err
+		}
+		args = append(args, "@"+profile.ArgsFile)
+	}
+	args = append(args, profile.Args...)
+
+	for _, env := range profile.Env {
+		if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+			return nil, "", launchError("env %q is not KEY=VALUE", env)
+		}
+	}
+
+	cmd = exec.Command(java, args...)
+	cmd.Dir = workdir
+	cmd.Env = append(os.Environ(), profile.Env...)
+	stopCommand = profile.StopCommand
+	if stopCommand == "" {
+		stopCommand = defaultStopCommand
+	}
+	return cmd, stopCommand, nil
+}
+
+func resolvePath(workdir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workdir, path)
+}
+
+func checkFile(what string, path string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return launchError("%s %q: %v", what, path, err)
+	}
+	if stat.IsDir() {
+		return launchError("%s %q is a directory", what, path)
+	}
+	return nil
+}
+
+func findJava(java string, workdir string) (string, error) {
+	if java == "" {
+		java = "java"
+	}
+	if !strings.ContainsAny(java, `/\`) {
+		path, err := exec.LookPath(java)
+		if err != nil {
+			return "", launchError("java binary %q not found in PATH", java)
+		}
+		return path, nil
+	}
+	java = resolvePath(workdir, java)
+	if err := checkFile("java binary", java); err != nil {
+		return "", err
+	}
+	return java, nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/fatih/color"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const lockMaxTime = 10 * time.Second
+
+type lockWaiter struct {
+	client *manager.Client
+	ready  chan struct{}
+	token  uint64
+}
+
+// WriteLock grants the console to one client at a time in request order.
+// Every grant gets a new fencing token, so a client whose lease expired can't
+// keep writing with its old one.
+type WriteLock struct {
+	instance     string
+	lockedClient *manager.Client
+	token        uint64
+	acquired     time.Time
+	expires      time.Time
+	waiters      []*lockWaiter
+	time         *time.Timer
+	clientLock   sync.RWMutex
+	onChange     func(owner uint64, previous uint64)
+}
+
+func (wl *WriteLock) Println(a ...any) (n int, err error) {
+	return Println(append([]any{color.YellowString("<"), color.BlueString(wl.instance), color.YellowString("> ")}, a...)...)
+}
+
+// grant hands the lock to client, clientLock must be held.
+func (wl *WriteLock) grant(client *manager.Client) uint64 {
+	wl.lockedClient = client
+	wl.token++
+	wl.acquired = time.Now()
+	wl.expires = wl.acquired.Add(lockMaxTime)
+	token := wl.token
+	wl.time = time.AfterFunc(lockMaxTime, func() {
+		wl.expire(token)
+	})
+	return token
+}
+
+func (wl *WriteLock) expire(token uint64) {
+	wl.clientLock.RLock()
+	client := wl.lockedClient
+	current := wl.token
+	wl.clientLock.RUnlock()
+	if client == nil || current != token {
+		return
+	}
+	wl.Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]的写入锁因超时而被取消"))
+	wl.release(client.Id, token)
+}
+
+func (wl *WriteLock) Unlock(client *manager.Client) {
+	wl.release(client.Id, 0)
+}
+
+// release frees the lock held by id and passes it to the next waiter. A
+// non-zero token only releases that exact grant.
+func (wl *WriteLock) release(id uint64, token uint64) {
+	wl.clientLock.Lock()
+	if wl.lockedClient == nil || wl.lockedClient.Id != id || (token != 0 && wl.token != token) {
+		wl.clientLock.Unlock()
+		return
+	}
+	if wl.time != nil {
+		wl.time.Stop()
+		wl.time = nil
+	}
+	wl.lockedClient = nil
+	var owner uint64
+	if len(wl.waiters) > 0 {
+		next := wl.waiters[0]
+		wl.waiters = slices.Delete(wl.waiters, 0, 1)
+		next.token = wl.grant(next.client)
+		owner = next.client.Id
+		close(next.ready)
+		wl.Println(color.YellowString("客户端["), color.GreenString("%d", owner), color.YellowString("]获取写入锁, Token: "), color.GreenString("%d", next.token))
+	}
+	wl.clientLock.Unlock()
+	if wl.onChange != nil {
+		wl.onChange(owner, id)
+	}
+}
+
+// Lock waits for the lock in FIFO order and returns its fencing token. If
+// client already holds the lock the lease is renewed instead.
+func (wl *WriteLock) Lock(ctx context.Context, client *manager.Client) (uint64, error) {
+	wl.clientLock.Lock()
+	if wl.lockedClient != nil && client.Id == wl.lockedClient.Id {
+		wl.renew()
+		token := wl.token
+		wl.clientLock.Unlock()
+		wl.Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]续期写入锁"))
+		return token, nil
+	}
+	if wl.lockedClient == nil && len(wl.waiters) == 0 {
+		token := wl.grant(client)
+		wl.clientLock.Unlock()
+		wl.Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]获取写入锁, Token: "), color.GreenString("%d", token))
+		if wl.onChange != nil {
+			wl.onChange(client.Id, 0)
+		}
+		return token, nil
+	}
+	waiter := &lockWaiter{client: client, ready: make(chan struct{})}
+	wl.waiters = append(wl.waiters, waiter)
+	position := len(wl.waiters)
+	wl.clientLock.Unlock()
+	wl.Println(color.YellowString("客户端["), color.GreenString("%d", client.Id), color.YellowString("]排队等待写入锁, 位置: "), color.GreenString("%d", position))
+	select {
+	case <-waiter.ready:
+		return waiter.token, nil
+	case <-ctx.Done():
+	}
+	wl.clientLock.Lock()
+	if idx := slices.Index(wl.waiters, waiter); idx >= 0 {
+		wl.waiters = slices.Delete(wl.waiters, idx, idx+1)
+		wl.clientLock.Unlock()
+		return 0, ctx.Err()
+	}
+	wl.clientLock.Unlock()
+	// granted while giving up
+	wl.release(client.Id, waiter.token)
+	return 0, ctx.Err()
+}
+
+// renew extends the lease of the current holder, clientLock must be held.
+func (wl *WriteLock) renew() {
+	if wl.time != nil {
+		wl.time.Reset(lockMaxTime)
+	}
+	wl.expires = time.Now().Add(lockMaxTime)
+}
+
+// Check verifies that client holds the lock with token and renews the lease.
+func (wl *WriteLock) Check(client *manager.Client, token uint64) error {
+	wl.clientLock.Lock()
+	defer wl.clientLock.Unlock()
+	if wl.lockedClient == nil || client == nil || wl.lockedClient.Id != client.Id {
+		return ErrNoLockAcquired
+	}
+	if token != wl.token {
+		return ErrStaleLockToken
+	}
+	wl.renew()
+	return nil
+}
+
+func (wl *WriteLock) Status() *manager.LockStatusResponse {
+	wl.clientLock.RLock()
+	defer wl.clientLock.RUnlock()
+	status := &manager.LockStatusResponse{Token: wl.token}
+	if wl.lockedClient != nil {
+		status.Holder = wl.lockedClient.Id
+		status.Acquired = timestamppb.New(wl.acquired)
+		status.Expires = timestamppb.New(wl.expires)
+	}
+	for _, waiter := range wl.waiters {
+		status.Waiters = append(status.Waiters, waiter.client.Id)
+	}
+	return status
+}
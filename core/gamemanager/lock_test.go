@@ -0,0 +1,142 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+)
+
+// queueLock queues client behind the holder and returns where its token
+// arrives.
+func queueLock(t *testing.T, wl *WriteLock, ctx context.Context, client *manager.Client) chan uint64 {
+	t.Helper()
+	waiting := len(wl.Status().Waiters)
+	granted := make(chan uint64, 1)
+	go func() {
+		token, err := wl.Lock(ctx, client)
+		if err == nil {
+			granted <- token
+		}
+	}()
+	// the order of the queue is the order of the calls
+	deadline := time.Now().Add(5 * time.Second)
+	for len(wl.Status().Waiters) == waiting {
+		if time.Now().After(deadline) {
+			t.Fatalf("client %d did not queue", client.Id)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return granted
+}
+
+func TestWriteLockOrder(t *testing.T) {
+	mv := NewMinecraftVistor("test", ManagerConfig{})
+	wl := &mv.writeLock
+	clients := []*manager.Client{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}}
+	token, err := wl.Lock(context.Background(), clients[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := queueLock(t, wl, context.Background(), clients[1])
+	ctx, cancel := context.WithCancel(context.Background())
+	third := queueLock(t, wl, ctx, clients[2])
+	fourth := queueLock(t, wl, context.Background(), clients[3])
+	if waiters := wl.Status().Waiters; len(waiters) != 3 || waiters[0] != 2 || waiters[1] != 3 || waiters[2] != 4 {
+		t.Fatalf("waiters %v, want [2 3 4]", waiters)
+	}
+	// a waiter that gives up leaves the queue
+	cancel()
+	for len(wl.Status().Waiters) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	holder, previous := clients[0], token
+	for _, next := range []struct {
+		client  *manager.Client
+		granted chan uint64
+	}{{clients[1], second}, {clients[3], fourth}} {
+		wl.Unlock(holder)
+		select {
+		case token = <-next.granted:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("client %d did not get the lock", next.client.Id)
+		}
+		if token <= previous {
+			t.Fatalf("token %d after %d, want a newer one", token, previous)
+		}
+		if status := wl.Status(); status.Holder != next.client.Id || status.Token != token {
+			t.Fatalf("holder %d with token %d, want %d with %d", status.Holder, status.Token, next.client.Id, token)
+		}
+		holder, previous = next.client, token
+	}
+	select {
+	case <-third:
+		t.Fatal("the cancelled waiter got the lock")
+	default:
+	}
+	wl.Unlock(holder)
+	if status := wl.Status(); status.Holder != 0 || len(status.Waiters) != 0 {
+		t.Fatalf("lock still held by %d with waiters %v", status.Holder, status.Waiters)
+	}
+}
+
+func TestWriteLockToken(t *testing.T) {
+	mv := NewMinecraftVistor("test", ManagerConfig{})
+	owner, other := &manager.Client{Id: 1}, &manager.Client{Id: 2}
+
+	if err := mv.Write(owner, 1, 0, "list"); !errors.Is(err, ErrNoLockAcquired) {
+		t.Fatalf("Write without the lock: %v, want %v", err, ErrNoLockAcquired)
+	}
+	old, err := mv.writeLock.Lock(context.Background(), owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mv.writeLock.Unlock(owner)
+	token, err := mv.writeLock.Lock(context.Background(), owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the token is checked before the state of the server
+	if err := mv.Write(owner, 2, token, "list"); !errors.Is(err, ErrMinecraftNotRunning) {
+		t.Fatalf("Write with the current token: %v, want %v", err, ErrMinecraftNotRunning)
+	}
+	if err := mv.Write(owner, 3, old, "list"); !errors.Is(err, ErrStaleLockToken) {
+		t.Fatalf("Write with the token of an earlier grant: %v, want %v", err, ErrStaleLockToken)
+	}
+	if err := mv.Write(other, 4, token, "list"); !errors.Is(err, ErrNoLockAcquired) {
+		t.Fatalf("Write by another client with the token: %v, want %v", err, ErrNoLockAcquired)
+	}
+
+	// an expired lease takes the lock away from the owner
+	mv.writeLock.expire(token)
+	if err := mv.Write(owner, 5, token, "list"); !errors.Is(err, ErrNoLockAcquired) {
+		t.Fatalf("Write after the lease expired: %v, want %v", err, ErrNoLockAcquired)
+	}
+	// an expiry of an earlier grant leaves the current one alone
+	token, err = mv.writeLock.Lock(context.Background(), owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mv.writeLock.expire(old)
+	if err := mv.writeLock.Check(owner, token); err != nil {
+		t.Fatalf("Check after a stale expiry: %v", err)
+	}
+	mv.writeLock.Unlock(owner)
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/shirou/gopsutil/v3/process"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const minMetricsInterval = 200 * time.Millisecond
+
+// processTree returns the server process followed by all of its descendants.
+func (mv *MinecraftVistor) processTree() []*process.Process {
+	if mv.process == nil || mv.process.Process == nil {
+		return nil
+	}
+	root, err := process.NewProcess(int32(mv.process.Process.Pid))
+	if err != nil {
+		return nil
+	}
+	tree := []*process.Process{root}
+	for i := 0; i < len(tree); i++ {
+		children, err := tree[i].Children()
+		if err == nil {
+			tree = append(tree, children...)
+		}
+	}
+	return tree
+}
+
+// MetricsSampler keeps the gopsutil handles between samples, CPU percent is
+// computed from the difference to the previous sample of the same process.
+type MetricsSampler struct {
+	instance  *MinecraftVistor
+	processes map[int32]*process.Process
+}
+
+func NewMetricsSampler(mv *MinecraftVistor) *MetricsSampler {
+	return &MetricsSampler{instance: mv, processes: make(map[int32]*process.Process)}
+}
+
+func (ms *MetricsSampler) Sample() *manager.ProcessMetrics {
+	metrics := &manager.ProcessMetrics{Time: timestamppb.Now(), State: ms.instance.state}
+	tree := ms.instance.processTree()
+	seen := make(map[int32]*process.Process, len(tree))
+	for _, p := range tree {
+		if last, ok := ms.processes[p.Pid]; ok {
+			p = last
+		}
+		seen[p.Pid] = p
+		if cpuPercent, err := p.Percent(0); err == nil {
+			metrics.CpuPercent += cpuPercent
+		}
+		if threads, err := p.NumThreads(); err == nil {
+			metrics.Threads += threads
+		}
+		if fds, err := p.NumFDs(); err == nil {
+			metrics.OpenFiles += fds
+		}
+		if io, err := p.IOCounters(); err == nil {
+			metrics.ReadBytes += io.ReadBytes
+			metrics.WriteBytes += io.WriteBytes
+		}
+		if memoryInfo, err := p.MemoryInfo(); err == nil {
+			metrics.Rss += memoryInfo.RSS
+			metrics.Vms += memoryInfo.VMS
+		}
+	}
+	ms.processes = seen
+	if len(tree) > 0 {
+		metrics.Pid = tree[0].Pid
+		if created, err := tree[0].CreateTime(); err == nil {
+			metrics.Uptime = durationpb.New(time.Since(time.UnixMilli(created)))
+		}
+	}
+	return metrics
+}
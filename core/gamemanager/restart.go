@@ -0,0 +1,181 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/fatih/color"
+)
+
+type RestartConfig struct {
+	Policy      manager.RestartPolicy
+	Backoff     time.Duration // delay before the first restart, doubled for every restart in Window
+	MaxBackoff  time.Duration
+	MaxRestarts int           // give up after this many restarts in Window, 0 for unlimited
+	Window      time.Duration // has to be positive, 0 forgets every restart at once and disables MaxRestarts and the growing backoff
+}
+
+func ParseRestartPolicy(policy string) (manager.RestartPolicy, error) {
+	switch strings.ReplaceAll(policy, "-", "_") {
+	case "never", "":
+		return manager.RestartPolicy_never, nil
+	case "on_failure":
+		return manager.RestartPolicy_on_failure, nil
+	case "always":
+		return manager.RestartPolicy_always, nil
+	}
+	return manager.RestartPolicy_restart_default, fmt.Errorf("unknown restart policy %q", policy)
+}
+
+// exitSignal returns the signal that killed the process, 0 if it exited.
+func exitSignal(state *os.ProcessState) syscall.Signal {
+	if state == nil {
+		return 0
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return status.Signal()
+	}
+	return 0
+}
+
+// exitCode is the exit status of the process, 128 + the signal like a shell
+// reports it when it was killed by one, -1 if it is unknown.
+func exitCode(state *os.ProcessState) int32 {
+	if state == nil {
+		return -1
+	}
+	if signal := exitSignal(state); signal != 0 {
+		return 128 + int32(signal)
+	}
+	return int32(state.ExitCode())
+}
+
+// launchExit is what stopDetect read about the launch that ended, a launch
+// started after it can't change it anymore.
+type launchExit struct {
+	state         *os.ProcessState
+	stopRequested bool
+	hungRestart   bool
+	policy        manager.RestartPolicy
+}
+
+func (exit launchExit) shouldRestart() bool {
+	if exit.stopRequested {
+		return false
+	}
+	if exit.hungRestart {
+		return true
+	}
+	switch exit.policy {
+	case manager.RestartPolicy_always:
+		return true
+	case manager.RestartPolicy_on_failure:
+		return exit.state == nil || !exit.state.Success()
+	}
+	return false
+}
+
+// handleExit is called by stopDetect once the process is gone, it reports a
+// crash and schedules a restart according to the restart policy.
+func (mv *MinecraftVistor) handleExit(exit launchExit) {
+	if exit.stopRequested {
+		return
+	}
+	code := exitCode(exit.state)
+	signal := exitSignal(exit.state)
+	if signal != 0 {
+		mv.Println(color.RedString("服务器被信号 %s 终止, 退出码: ", signal), color.GreenString("%d", code))
+		mv.publishState(manager.StateReason_server_crashed, manager.MinecraftState_running, code)
+	} else if code != 0 {
+		mv.Println(color.RedString("服务器意外退出, 退出码: "), color.GreenString("%d", code))
+		mv.publishState(manager.StateReason_server_crashed, manager.MinecraftState_running, code)
+	}
+	// a clean exit crashed nothing, a report found then is someone else's
+	if signal != 0 || code != 0 {
+		mv.reportCrashes(code)
+	}
+	if !exit.shouldRestart() {
+		return
+	}
+	mv.scheduleRestart(code)
+}
+
+// scheduleRestart starts the last launch again after the backoff, a restart
+// that fails to start counts against the limit like any other and is tried
+// again.
+func (mv *MinecraftVistor) scheduleRestart(code int32) {
+	mv.restartLock.Lock()
+	defer mv.restartLock.Unlock()
+	now := time.Now()
+	recent := mv.restartTimes[:0]
+	for _, t := range mv.restartTimes {
+		if now.Sub(t) < mv.restart.Window {
+			recent = append(recent, t)
+		}
+	}
+	mv.restartTimes = recent
+	if mv.restart.MaxRestarts > 0 && len(recent) >= mv.restart.MaxRestarts {
+		mv.Println(color.RedString("%s 内已重启 %d 次, 放弃自动重启", mv.restart.Window, len(recent)))
+		mv.publishState(manager.StateReason_restart_limit_reached, mv.state, code)
+		return
+	}
+	backoff := mv.restart.Backoff << len(recent)
+	if backoff > mv.restart.MaxBackoff || backoff <= 0 {
+		backoff = mv.restart.MaxBackoff
+	}
+	mv.restartTimes = append(mv.restartTimes, now)
+	mv.Println(color.YellowString("将在 "), color.GreenString("%s", backoff), color.YellowString(" 后重启服务器 (第 %d 次)", len(mv.restartTimes)))
+	mv.publishState(manager.StateReason_server_restarting, mv.state, code)
+	var timer *time.Timer
+	timer = time.AfterFunc(backoff, func() {
+		mv.restartLock.Lock()
+		if mv.restartTimer != timer {
+			// cancelled after it fired
+			mv.restartLock.Unlock()
+			return
+		}
+		mv.restartTimer = nil
+		mv.restartLock.Unlock()
+		req := &manager.StartRequest{Client: &manager.Client{Id: 0, Instance: mv.name}, Path: mv.launch.Path, Profile: mv.launch.Profile, Restart: mv.launch.Restart}
+		err := mv.Start(req)
+		if err == nil || errors.Is(err, ErrMinecraftAlreadyRunning) {
+			return
+		}
+		mv.Println(color.RedString("自动重启失败: %v", err))
+		mv.publishState(manager.StateReason_restart_failed, mv.state, code)
+		mv.scheduleRestart(code)
+	})
+	mv.restartTimer = timer
+}
+
+// cancelRestart drops a pending restart, returns whether one was pending.
+func (mv *MinecraftVistor) cancelRestart() bool {
+	mv.restartLock.Lock()
+	defer mv.restartLock.Unlock()
+	if mv.restartTimer == nil {
+		return false
+	}
+	mv.restartTimer.Stop()
+	mv.restartTimer = nil
+	mv.Println(color.YellowString("取消等待中的自动重启"))
+	return true
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package gamemanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+)
+
+func startScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newRestartVistor(t *testing.T, policy manager.RestartPolicy) (*MinecraftVistor, *ForwardChannel) {
+	mv := NewMinecraftVistor("test", ManagerConfig{Restart: RestartConfig{
+		Policy:      policy,
+		Backoff:     10 * time.Millisecond,
+		MaxBackoff:  10 * time.Millisecond,
+		MaxRestarts: 1,
+		Window:      time.Minute,
+	}})
+	channel, _, _, _ := mv.RegisterForwardChannelSince(1, 0)
+	t.Cleanup(func() { mv.cancelRestart() })
+	return mv, channel
+}
+
+// nextState returns the next state transition, nil if there is none within
+// timeout.
+func nextState(channel *ForwardChannel, timeout time.Duration) *manager.StateTransition {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-channel.channel:
+			if state := msg.response.GetState(); state != nil {
+				return state
+			}
+		case <-deadline:
+			return nil
+		}
+	}
+}
+
+func expectStates(t *testing.T, channel *ForwardChannel, reasons ...manager.StateReason) []*manager.StateTransition {
+	t.Helper()
+	var states []*manager.StateTransition
+	for _, reason := range reasons {
+		state := nextState(channel, 5*time.Second)
+		if state == nil {
+			t.Fatalf("no %s event", reason)
+		}
+		if state.Reason != reason {
+			t.Fatalf("got %s, want %s", state.Reason, reason)
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+func expectNoState(t *testing.T, channel *ForwardChannel) {
+	t.Helper()
+	if state := nextState(channel, 300*time.Millisecond); state != nil {
+		t.Fatalf("unexpected %s event", state.Reason)
+	}
+}
+
+func TestRestartPolicyPerLaunch(t *testing.T) {
+	mv, channel := newRestartVistor(t, manager.RestartPolicy_never)
+	script := startScript(t, "exit 1")
+	client := &manager.Client{Id: 1}
+
+	if err := mv.Start(&manager.StartRequest{Client: client, Path: script, Restart: manager.RestartPolicy_always}); err != nil {
+		t.Fatal(err)
+	}
+	expectStates(t, channel,
+		manager.StateReason_server_started, manager.StateReason_server_stopped, manager.StateReason_server_crashed, manager.StateReason_server_restarting,
+		// the restart keeps the policy of the launch
+		manager.StateReason_server_started, manager.StateReason_server_stopped, manager.StateReason_server_crashed, manager.StateReason_restart_limit_reached,
+	)
+
+	if err := mv.Start(&manager.StartRequest{Client: client, Path: script}); err != nil {
+		t.Fatal(err)
+	}
+	expectStates(t, channel, manager.StateReason_server_started, manager.StateReason_server_stopped, manager.StateReason_server_crashed)
+	expectNoState(t, channel)
+}
+
+func TestRestartCleanShutdown(t *testing.T) {
+	mv, channel := newRestartVistor(t, manager.RestartPolicy_always)
+	script := startScript(t, `echo "[12:00:00] [Server thread/INFO]: Stopping server"; sleep 0.2; exit 0`)
+	if err := mv.Start(&manager.StartRequest{Client: &manager.Client{Id: 1}, Path: script}); err != nil {
+		t.Fatal(err)
+	}
+	states := expectStates(t, channel, manager.StateReason_server_started, manager.StateReason_server_stopped)
+	if stage := states[1].StopStage; stage != manager.StopStage_stop_command {
+		t.Errorf("stop stage %s, want %s", stage, manager.StopStage_stop_command)
+	}
+	expectNoState(t, channel)
+}
+
+func TestRestartChatIsNoShutdown(t *testing.T) {
+	mv, channel := newRestartVistor(t, manager.RestartPolicy_always)
+	script := startScript(t, `echo "[12:00:00] [Server thread/INFO]: <Steve> Stopping server"; sleep 0.2; exit 0`)
+	if err := mv.Start(&manager.StartRequest{Client: &manager.Client{Id: 1}, Path: script}); err != nil {
+		t.Fatal(err)
+	}
+	expectStates(t, channel, manager.StateReason_server_started, manager.StateReason_server_stopped, manager.StateReason_server_restarting)
+}
+
+func TestRestartSignalIsCrash(t *testing.T) {
+	mv, channel := newRestartVistor(t, manager.RestartPolicy_never)
+	script := startScript(t, "kill -9 $$")
+	if err := mv.Start(&manager.StartRequest{Client: &manager.Client{Id: 1}, Path: script}); err != nil {
+		t.Fatal(err)
+	}
+	states := expectStates(t, channel, manager.StateReason_server_started, manager.StateReason_server_stopped, manager.StateReason_server_crashed)
+	if code := states[2].ExitCode; code != 128+9 {
+		t.Errorf("exit code %d, want %d", code, 128+9)
+	}
+	expectNoState(t, channel)
+}
+
+func TestRestartWhileExiting(t *testing.T) {
+	mv, channel := newRestartVistor(t, manager.RestartPolicy_always)
+	// the old process is still exiting when Restart sees the stop command
+	script := startScript(t, "read line; sleep 0.1; exit 1")
+	client := &manager.Client{Id: 1}
+	if err := mv.Start(&manager.StartRequest{Client: client, Path: script}); err != nil {
+		t.Fatal(err)
+	}
+	expectStates(t, channel, manager.StateReason_server_started)
+	stage, code, err := mv.Restart(client, 5*time.Second, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if stage != manager.StopStage_stop_command || code != 1 {
+		t.Errorf("Restart stopped at %s with %d, want %s with 1", stage, code, manager.StopStage_stop_command)
+	}
+	// the requested stop is neither a crash nor a reason to restart again
+	expectStates(t, channel, manager.StateReason_server_stopped, manager.StateReason_server_started)
+	expectNoState(t, channel)
+	mv.Stop(client, 5*time.Second, 5*time.Second)
+}
+
+func TestRestartFailed(t *testing.T) {
+	mv, channel := newRestartVistor(t, manager.RestartPolicy_always)
+	// the second launch finds no script to run
+	script := startScript(t, `rm "$0"; exit 1`)
+	if err := mv.Start(&manager.StartRequest{Client: &manager.Client{Id: 1}, Path: script}); err != nil {
+		t.Fatal(err)
+	}
+	expectStates(t, channel,
+		manager.StateReason_server_started, manager.StateReason_server_stopped, manager.StateReason_server_crashed, manager.StateReason_server_restarting,
+		// the failed start counts against the limit
+		manager.StateReason_restart_failed, manager.StateReason_restart_limit_reached,
+	)
+	expectNoState(t, channel)
+}
+
+// crashReports collects the crash reports published within timeout.
+func crashReports(channel *ForwardChannel, timeout time.Duration) (reports []*manager.CrashReport) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-channel.channel:
+			if report := msg.response.GetCrashReport(); report != nil {
+				reports = append(reports, report)
+			}
+		case <-deadline:
+			return reports
+		}
+	}
+}
+
+func TestCrashReportOnlyOnCrash(t *testing.T) {
+	// the report is written by each launch, only the failing one is a crash.
+	// The sleep keeps its time after the start of the launch.
+	writeReport := `sleep 0.1; mkdir -p crash-reports; printf 'Description: Exception in server tick loop\n' > crash-reports/crash-$$-server.txt; `
+	for _, test := range []struct {
+		exit    string
+		reports int
+	}{
+		{"exit 0", 0},
+		{"exit 1", 1},
+		{"kill -9 $$", 1},
+	} {
+		mv, channel := newRestartVistor(t, manager.RestartPolicy_never)
+		script := startScript(t, writeReport+test.exit)
+		if err := mv.Start(&manager.StartRequest{Client: &manager.Client{Id: 1}, Path: script}); err != nil {
+			t.Fatal(err)
+		}
+		reports := crashReports(channel, 500*time.Millisecond)
+		if len(reports) != test.reports {
+			t.Errorf("%s: %d crash reports, want %d", test.exit, len(reports), test.reports)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"syscall"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/fatih/color"
+)
+
+// printStopLog prints the console until the returned function is called, so
+// the shutdown is visible even while no client holds the lock.
+func (mv *MinecraftVistor) printStopLog() (done func()) {
+	message := mv.RegisterForwardChannel()
+	go func() {
+		for msg := range message.channel {
+			if msg.response.GetLog() == nil {
+				continue
+			}
+			mv.Println(color.YellowString("服务器日志: "), color.CyanString(msg.message))
+		}
+	}()
+	return func() {
+		mv.UnregisterForwardChannel(message)
+		close(message.channel)
+	}
+}
+
+// Stop sends the stop command, then SIGTERM after termTimeout and finally
+// kills the process group after killTimeout. It returns the stage that ended
+// the process and its exit code.
+func (mv *MinecraftVistor) Stop(client *manager.Client, termTimeout time.Duration, killTimeout time.Duration) (manager.StopStage, int32) {
+	mv.Println(color.YellowString("客户端["), color.GreenString("%d", client.GetId()), color.YellowString("]请求关闭服务器"))
+	mv.stopRequested.Store(true)
+	mv.cancelRestart()
+	if mv.state != manager.MinecraftState_running {
+		return manager.StopStage_not_running, 0
+	}
+	exited := mv.exited
+	defer mv.printStopLog()()
+
+	mv.stopStage.Store(int32(manager.StopStage_stop_command))
+	mv.pty.Write([]byte(mv.stopCommand + "\n"))
+	termTimer := time.NewTimer(termTimeout)
+	defer termTimer.Stop()
+	select {
+	case <-exited:
+		return mv.stopResult()
+	case <-termTimer.C:
+	}
+
+	mv.stopStage.Store(int32(manager.StopStage_sigterm))
+	err := mv.process.Process.Signal(syscall.SIGTERM)
+	mv.Println(color.RedString("服务器关闭超时，发送 SIGTERM 信号 err:"), color.GreenString("%v", err))
+	killTimer := time.NewTimer(killTimeout)
+	defer killTimer.Stop()
+	select {
+	case <-exited:
+		return mv.stopResult()
+	case <-killTimer.C:
+	}
+
+	mv.stopStage.Store(int32(manager.StopStage_sigkill))
+	err = killProcessGroup(mv.process.Process)
+	mv.Println(color.RedString("服务器仍未退出，强制结束进程组 err:"), color.GreenString("%v", err))
+	<-exited
+	return mv.stopResult()
+}
+
+// Kill ends the whole process group without asking the server first.
+func (mv *MinecraftVistor) Kill(client *manager.Client) (manager.StopStage, int32) {
+	mv.Println(color.YellowString("客户端["), color.GreenString("%d", client.GetId()), color.RedString("]请求强制结束服务器"))
+	mv.stopRequested.Store(true)
+	mv.cancelRestart()
+	if mv.state != manager.MinecraftState_running {
+		return manager.StopStage_not_running, 0
+	}
+	exited := mv.exited
+	mv.stopStage.Store(int32(manager.StopStage_sigkill))
+	err := killProcessGroup(mv.process.Process)
+	if err != nil {
+		mv.Println(color.RedString("结束进程组失败: %v", err))
+	}
+	<-exited
+	return mv.stopResult()
+}
+
+// Restart stops the server and starts it again with the last launch settings.
+func (mv *MinecraftVistor) Restart(client *manager.Client, termTimeout time.Duration, killTimeout time.Duration) (manager.StopStage, int32, error) {
+	if mv.launch == nil {
+		return manager.StopStage_stop_stage_unknown, 0, ErrNeverStarted
+	}
+	stage, code := mv.Stop(client, termTimeout, killTimeout)
+	return stage, code, mv.Start(&manager.StartRequest{Client: client, Path: mv.launch.Path, Profile: mv.launch.Profile, Restart: mv.launch.Restart})
+}
+
+func (mv *MinecraftVistor) stopResult() (manager.StopStage, int32) {
+	return manager.StopStage(mv.stopStage.Load()), mv.exitCode
+}
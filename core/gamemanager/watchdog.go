@@ -0,0 +1,204 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamemanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"github.com/fatih/color"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const threadDumpWindow = 3 * time.Second
+
+var ErrNoJavaProcess = fmt.Errorf("no java process found")
+
+// the watchdog takes the write lock as this client so its probe doesn't end
+// up in the middle of a client's command output
+var watchdogClient = &manager.Client{Id: 0}
+
+type WatchdogConfig struct {
+	Interval time.Duration // 0 disables the watchdog
+	Timeout  time.Duration
+	Command  string
+	Response *regexp.Regexp
+	DumpDir  string
+	Restart  bool // kill a hung server and let the restart policy bring it back
+}
+
+// watchdog probes the console whenever it has been silent for an interval. It
+// only reports hangs after the server answered a probe once, a long world
+// generation at startup is not a hang.
+func (mv *MinecraftVistor) watchdog(exited chan struct{}) {
+	config := mv.watchdogConfig
+	if config.Interval <= 0 || config.Command == "" || config.Response == nil {
+		return
+	}
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+	armed := false
+	reported := false
+	for {
+		select {
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+		silent := time.Since(time.Unix(0, mv.lastOutput.Load()))
+		if silent < config.Interval {
+			reported = false
+			continue
+		}
+		if reported {
+			continue
+		}
+		if mv.probe(exited) {
+			armed = true
+			continue
+		}
+		if !armed {
+			continue
+		}
+		reported = true
+		mv.handleHang(exited, time.Since(time.Unix(0, mv.lastOutput.Load())))
+	}
+}
+
+// probe writes the probe command and reports whether the expected response
+// arrived in time.
+func (mv *MinecraftVistor) probe(exited chan struct{}) bool {
+	config := mv.watchdogConfig
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	_, err := mv.writeLock.Lock(ctx, watchdogClient)
+	cancel()
+	if err == nil {
+		defer mv.writeLock.Unlock(watchdogClient)
+	}
+	// without the lock the holder has been waiting on a silent console for
+	// a whole timeout already, probe anyway
+	message := mv.RegisterForwardChannel()
+	defer func() {
+		mv.UnregisterForwardChannel(message)
+		close(message.channel)
+	}()
+	mv.pty.Write([]byte(config.Command + "\n"))
+	timer := time.NewTimer(config.Timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case msg := <-message.channel:
+			if msg.response.GetLog() != nil && config.Response.MatchString(msg.message) {
+				return true
+			}
+		case <-timer.C:
+			return false
+		case <-exited:
+			return true
+		}
+	}
+}
+
+func (mv *MinecraftVistor) handleHang(exited chan struct{}, silent time.Duration) {
+	config := mv.watchdogConfig
+	mv.Println(color.RedString("控制台已 %s 无响应, 服务器可能已卡死", silent.Round(time.Second)))
+	dump, err := mv.captureThreadDump(exited)
+	if err != nil {
+		mv.Println(color.RedString("无法获取线程转储: %v", err))
+	} else if dump != "" {
+		mv.Println(color.YellowString("线程转储已保存到: "), color.GreenString(dump))
+	}
+	mv.publish(&manager.MessageResponse{
+		Type:  "Hung",
+		Event: &manager.MessageResponse_Hung{Hung: &manager.HungEvent{Silent: durationpb.New(silent), ThreadDump: dump, Restarting: config.Restart}},
+	})
+	if config.Restart {
+		mv.Println(color.RedString("结束卡死的服务器以便自动重启"))
+		mv.hungRestart.Store(true)
+		if err := killProcessGroup(mv.process.Process); err != nil {
+			mv.Println(color.RedString("结束进程组失败: %v", err))
+		}
+	}
+}
+
+// captureThreadDump sends SIGQUIT to the JVM and saves what it prints during
+// threadDumpWindow. The signal goes to java processes only, a start script
+// would just die from it.
+func (mv *MinecraftVistor) captureThreadDump(exited chan struct{}) (string, error) {
+	dumpDir := mv.watchdogConfig.DumpDir
+	if dumpDir == "" {
+		return "", nil
+	}
+	var targets []int32
+	for _, p := range mv.processTree() {
+		name, err := p.Name()
+		if err == nil && strings.HasPrefix(strings.ToLower(name), "java") {
+			targets = append(targets, p.Pid)
+		}
+	}
+	if len(targets) == 0 {
+		return "", ErrNoJavaProcess
+	}
+	message := mv.RegisterForwardChannel()
+	defer func() {
+		mv.UnregisterForwardChannel(message)
+		close(message.channel)
+	}()
+	for _, pid := range targets {
+		if err := sendThreadDumpSignal(int(pid)); err != nil {
+			return "", err
+		}
+	}
+	var dump []string
+	timer := time.NewTimer(threadDumpWindow)
+	defer timer.Stop()
+collect:
+	for {
+		select {
+		case msg := <-message.channel:
+			if msg.response.GetLog() != nil {
+				dump = append(dump, msg.message)
+			}
+		case <-timer.C:
+			break collect
+		case <-exited:
+			break collect
+		}
+	}
+	dir := filepath.Join(dumpDir, mv.name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "threaddump-"+time.Now().Format("20060102-150405")+".txt")
+	if err := os.WriteFile(path, []byte(strings.Join(dump, "\n")+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func sendThreadDumpSignal(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(syscall.SIGQUIT)
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+)
+
+// LogCorpus holds logcorpus/<profile>.log, real console lines of every
+// built-in profile. A line can be followed by what it should parse to:
+//
+//	=> PlayerJoined Player=Steve    the event, a line without one must give none
+//	=: Steve joined the game        the message the server thread logged
+//	=-                              not a message of the server thread
+//
+// Lines starting with # are comments.
+//
+//go:embed logcorpus/*.log
+var LogCorpus embed.FS
+
+var ErrCorpusMismatch = fmt.Errorf("log corpus mismatch")
+
+type corpusLine struct {
+	number  int
+	line    string
+	event   string // expected event, empty for none
+	message *string
+}
+
+// CheckLogCorpus replays a corpus through a GameEventParser that detects the
+// profile itself, it has to end up at profile.
+func CheckLogCorpus(profile string, r io.Reader) (errs []error) {
+	lines, err := readCorpus(r)
+	if err != nil {
+		return []error{err}
+	}
+	return checkCorpus(profile, lines, &LogProfileSelector{})
+}
+
+// checkCorpus replays lines through selector, a Fixed one skips the detection.
+func checkCorpus(profile string, lines []corpusLine, selector *LogProfileSelector) (errs []error) {
+	parser := &GameEventParser{Profiles: selector}
+	fail := func(l corpusLine, format string, a ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s:%d: %s", ErrCorpusMismatch, profile, l.number, fmt.Sprintf(format, a...)))
+	}
+	for _, l := range lines {
+		event := parser.Parse(l.line)
+		if err := matchEvent(event, l.event); err != nil {
+			fail(l, "%v", err)
+		}
+		if l.message == nil {
+			continue
+		}
+		message, ok := selector.ServerMessage(l.line)
+		switch {
+		case *l.message == "" && ok:
+			fail(l, "expected no server message, got %q", message)
+		case *l.message != "" && !ok:
+			fail(l, "expected server message %q, got none", *l.message)
+		case message != *l.message:
+			fail(l, "expected server message %q, got %q", *l.message, message)
+		}
+	}
+	detected := "none"
+	if p := selector.Profile(); p != nil {
+		detected = p.Name
+	}
+	if detected != profile {
+		errs = append(errs, fmt.Errorf("%w: %s: detected as %s", ErrCorpusMismatch, profile, detected))
+	}
+	return errs
+}
+
+func readCorpus(r io.Reader) (lines []corpusLine, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := scanner.Text()
+		switch {
+		case strings.TrimSpace(text) == "", strings.HasPrefix(text, "#"):
+		case strings.HasPrefix(text, "=>"), strings.HasPrefix(text, "=:"), strings.HasPrefix(text, "=-"):
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("%w: line %d: expectation without a log line", ErrCorpusMismatch, number)
+			}
+			last := &lines[len(lines)-1]
+			switch text[:2] {
+			case "=>":
+				last.event = strings.TrimSpace(text[2:])
+			case "=:":
+				message := strings.TrimPrefix(text[2:], " ")
+				last.message = &message
+			case "=-":
+				message := ""
+				last.message = &message
+			}
+		default:
+			lines = append(lines, corpusLine{number: number, line: text})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// matchEvent compares event with "Name Field=value ...", values may be quoted.
+func matchEvent(event pluginabi.GameEvent, expected string) error {
+	if expected == "" {
+		if event != nil {
+			return fmt.Errorf("expected no event, got %s %+v", event.EventName(), event)
+		}
+		return nil
+	}
+	name, fields, _ := strings.Cut(expected, " ")
+	if event == nil {
+		return fmt.Errorf("expected %s, got no event", name)
+	}
+	if event.EventName() != name {
+		return fmt.Errorf("expected %s, got %s %+v", name, event.EventName(), event)
+	}
+	value := reflect.ValueOf(event)
+	for fields = strings.TrimSpace(fields); fields != ""; fields = strings.TrimSpace(fields) {
+		key, rest, ok := strings.Cut(fields, "=")
+		if !ok {
+			return fmt.Errorf("bad expectation %q", expected)
+		}
+		var want string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return fmt.Errorf("bad expectation %q: %w", expected, err)
+			}
+			want, _ = strconv.Unquote(quoted)
+			fields = rest[len(quoted):]
+		} else {
+			want, fields, _ = strings.Cut(rest, " ")
+		}
+		field := value.FieldByName(key)
+		if !field.IsValid() {
+			return fmt.Errorf("%s has no field %s", name, key)
+		}
+		if got := fmt.Sprint(field.Interface()); got != want {
+			return fmt.Errorf("%s.%s: expected %q, got %q", name, key, want, got)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestLogCorpus(t *testing.T) {
+	files, err := fs.Glob(LogCorpus, "logcorpus/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	covered := make(map[string]bool)
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".log")
+		t.Run(name, func(t *testing.T) {
+			profile, err := FindLogProfile(name)
+			if err != nil || profile == nil {
+				t.Fatalf("corpus %s has no log profile: %v", file, err)
+			}
+			covered[profile.Name] = true
+			data, err := LogCorpus.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines, err := readCorpus(strings.NewReader(string(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Run("detected", func(t *testing.T) {
+				for _, err := range checkCorpus(name, lines, &LogProfileSelector{}) {
+					t.Error(err)
+				}
+			})
+			t.Run("fixed", func(t *testing.T) {
+				for _, err := range checkCorpus(name, lines, &LogProfileSelector{Fixed: profile}) {
+					t.Error(err)
+				}
+			})
+		})
+	}
+	for _, profile := range LogProfiles {
+		if !covered[profile.Name] {
+			t.Errorf("log profile %s has no corpus", profile.Name)
+		}
+	}
+}
+
+func TestDedicatedServerMessage(t *testing.T) {
+	tests := []struct {
+		line    string
+		message string
+		ok      bool
+	}{
+		{"[12:00:00] [Server thread/INFO]: Steve joined the game", "Steve joined the game", true},
+		{"[12:00:00] [Server thread/WARN]: Can't keep up!", "", false},
+		{"[12:00:00] [User Authenticator #1/INFO]: UUID of player Steve is 0", "", false},
+	}
+	for _, tt := range tests {
+		match := DedicatedServerMessage.FindStringSubmatch(tt.line)
+		if (match != nil) != tt.ok || (match != nil && match[1] != tt.message) {
+			t.Errorf("DedicatedServerMessage(%q) = %q, want %q", tt.line, match, tt.message)
+		}
+		message, ok := VanillaLogProfile.ServerMessage(tt.line)
+		if ok != tt.ok || message != tt.message {
+			t.Errorf("VanillaLogProfile.ServerMessage(%q) = %q, %v, want %q", tt.line, message, ok, tt.message)
+		}
+	}
+}
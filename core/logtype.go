@@ -14,10 +14,238 @@
 
 package core
 
-import "regexp"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
 
-var DedicatedServerMessage = regexp.MustCompile(`Server thread/INFO.*\[.*\]: (.*)$`)
-var PlayerMessage = regexp.MustCompile(`\]\:(?: \[[^\]]+\])? <(.*?)>(.*)`)
-var PlayerJoinLeaveMessage = regexp.MustCompile(`\w+ (left|joined) the game`)
-var LoginMessage = regexp.MustCompile(`\[.*\]:.*? logged in with`)
-var PlayerCommandMessage = regexp.MustCompile(`\[.*\]: \[\w+:.*\]$`)
+// message level regexes, matched against LogLine.Message
+var PlayerMessage = regexp.MustCompile(`^(?:\[Not Secure\] )?<([^>]+)> ?(.*)$`)
+var PlayerJoinLeaveMessage = regexp.MustCompile(`^\S+ (left|joined) the game$`)
+var LoginMessage = regexp.MustCompile(`^\S+\[.*\] logged in with`)
+var PlayerCommandMessage = regexp.MustCompile(`^\[\S+: .*\]$`)
+
+// LogLine is one console line split by a LogProfile.
+type LogLine struct {
+	Thread  string // empty if the layout has none
+	Level   string
+	Logger  string
+	Message string
+}
+
+// LogProfile is the console layout of one server flavor.
+type LogProfile struct {
+	Name   string
+	Banner *regexp.Regexp   // the message of a startup line only this flavor prints
+	Layout []*regexp.Regexp // named groups thread, level, logger and message
+	Prefix *regexp.Regexp   // cut from the message, like Paper's [minecraft/DedicatedServer]
+	Chat   *regexp.Regexp   // player and message of a chat line
+}
+
+const (
+	vanillaLayout = `^\[[^\]]+\] \[(?P<thread>[^\]]+)/(?P<level>[A-Z]+)\]: (?P<message>.*)$`
+	forgeLayout   = `^\[[^\]]+\] \[(?P<thread>[^\]]+)/(?P<level>[A-Z]+)\] \[(?P<logger>[^\]]*)\]: (?P<message>.*)$`
+	fabricLayout  = `^\[[^\]]+\] \[(?P<thread>[^\]]+)/(?P<level>[A-Z]+)\] \((?P<logger>[^)]*)\) (?P<message>.*)$`
+	paperLayout   = `^\[\d{2}:\d{2}:\d{2} (?P<level>[A-Z]+)\]: (?P<message>.*)$`
+)
+
+// DedicatedServerMessage matches an INFO line of the server thread in the
+// vanilla layout, the message is the first group.
+//
+// Deprecated: use LogProfile.ServerMessage or LogProfileSelector.ServerMessage,
+// which know the layouts of the other flavors.
+var DedicatedServerMessage = regexp.MustCompile(strings.NewReplacer(
+	`(?P<thread>[^\]]+)`, `Server thread`,
+	`(?P<level>[A-Z]+)`, `INFO`,
+	`(?P<message>`, `(`,
+).Replace(vanillaLayout))
+
+var (
+	VanillaLogProfile = &LogProfile{
+		Name:   "vanilla",
+		Layout: []*regexp.Regexp{regexp.MustCompile(vanillaLayout)},
+		Chat:   PlayerMessage,
+	}
+	PaperLogProfile = &LogProfile{
+		Name:   "paper",
+		Banner: regexp.MustCompile(`^This server is running Paper version`),
+		Layout: []*regexp.Regexp{regexp.MustCompile(paperLayout), regexp.MustCompile(vanillaLayout)},
+		Prefix: regexp.MustCompile(`^\[minecraft/[\w$]+\]:? `),
+		Chat:   PlayerMessage,
+	}
+	PurpurLogProfile = &LogProfile{
+		Name:   "purpur",
+		Banner: regexp.MustCompile(`^This server is running Purpur version`),
+		Layout: PaperLogProfile.Layout,
+		Prefix: PaperLogProfile.Prefix,
+		Chat:   PlayerMessage,
+	}
+	ForgeLogProfile = &LogProfile{
+		Name:   "forge",
+		Banner: regexp.MustCompile(`^ModLauncher running: .*--fml\.forgeVersion|^Forge mod loading, version`),
+		Layout: []*regexp.Regexp{regexp.MustCompile(forgeLayout)},
+		Chat:   PlayerMessage,
+	}
+	NeoForgeLogProfile = &LogProfile{
+		Name:   "neoforge",
+		Banner: regexp.MustCompile(`^ModLauncher running: .*--fml\.neoForgeVersion|^NeoForge mod loading, version`),
+		Layout: ForgeLogProfile.Layout,
+		Chat:   PlayerMessage,
+	}
+	FabricLogProfile = &LogProfile{
+		Name:   "fabric",
+		Banner: regexp.MustCompile(`^Loading Minecraft \S+ with Fabric Loader`),
+		Layout: []*regexp.Regexp{regexp.MustCompile(fabricLayout)},
+		Chat:   PlayerMessage,
+	}
+)
+
+// LogProfiles are the built-in profiles in the order their layouts are tried
+// while the flavor is unknown, the more specific layouts first. The forks come
+// before the flavor they print the banner of.
+var LogProfiles = []*LogProfile{NeoForgeLogProfile, ForgeLogProfile, FabricLogProfile, PurpurLogProfile, PaperLogProfile, VanillaLogProfile}
+
+var ErrUnknownLogProfile = fmt.Errorf("unknown log profile")
+
+// FindLogProfile returns the built-in profile name, "auto" or "" return nil.
+func FindLogProfile(name string) (*LogProfile, error) {
+	name = strings.ToLower(name)
+	if name == "" || name == "auto" {
+		return nil, nil
+	}
+	for _, profile := range LogProfiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownLogProfile, name)
+}
+
+func (lp *LogProfile) Parse(line string) (LogLine, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	for _, layout := range lp.Layout {
+		match := layout.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		var parsed LogLine
+		for i, name := range layout.SubexpNames() {
+			switch name {
+			case "thread":
+				parsed.Thread = match[i]
+			case "level":
+				parsed.Level = match[i]
+			case "logger":
+				parsed.Logger = match[i]
+			case "message":
+				parsed.Message = match[i]
+			}
+		}
+		if lp.Prefix != nil {
+			parsed.Message = lp.Prefix.ReplaceAllString(parsed.Message, "")
+		}
+		return parsed, true
+	}
+	return LogLine{}, false
+}
+
+// ServerMessage returns what the server thread logged at INFO, where the
+// layout has no thread any INFO line counts.
+func (lp *LogProfile) ServerMessage(line string) (string, bool) {
+	parsed, ok := lp.Parse(line)
+	if !ok || parsed.Level != "INFO" || (parsed.Thread != "" && parsed.Thread != "Server thread") {
+		return "", false
+	}
+	return parsed.Message, true
+}
+
+// LogProfileSelector picks the profile of the server's log. Unless Fixed is
+// set it is detected from the startup banner, until then every built-in
+// layout is tried.
+type LogProfileSelector struct {
+	Fixed    *LogProfile
+	detected atomic.Pointer[LogProfile]
+}
+
+// Profile returns nil while the flavor is unknown.
+func (s *LogProfileSelector) Profile() *LogProfile {
+	if s.Fixed != nil {
+		return s.Fixed
+	}
+	return s.detected.Load()
+}
+
+// Observe looks for the banner in line. A vanilla server prints none, it is
+// told by its layout once the server is done starting.
+func (s *LogProfileSelector) Observe(line string) {
+	if s.Fixed != nil {
+		return
+	}
+	_, parsed, ok := guessLogProfile(line)
+	if !ok {
+		return
+	}
+	for _, profile := range LogProfiles {
+		if profile.Banner != nil && profile.Banner.MatchString(parsed.Message) {
+			s.detected.Store(profile)
+			return
+		}
+	}
+	if s.detected.Load() == nil && parsed.Level == "INFO" && startedEvent.MatchString(parsed.Message) {
+		s.detected.Store(plainLogProfile(line))
+	}
+}
+
+// plainLogProfile returns the last built-in profile with a layout of line, the
+// flavor the others with that layout fork from.
+func plainLogProfile(line string) (plain *LogProfile) {
+	for _, profile := range LogProfiles {
+		if _, ok := profile.Parse(line); ok {
+			plain = profile
+		}
+	}
+	return plain
+}
+
+// guessLogProfile returns the first built-in profile with a layout of line.
+func guessLogProfile(line string) (*LogProfile, LogLine, bool) {
+	for _, profile := range LogProfiles {
+		if parsed, ok := profile.Parse(line); ok {
+			return profile, parsed, true
+		}
+	}
+	return nil, LogLine{}, false
+}
+
+func (s *LogProfileSelector) Parse(line string) (LogLine, bool) {
+	if profile := s.Profile(); profile != nil {
+		return profile.Parse(line)
+	}
+	_, parsed, ok := guessLogProfile(line)
+	return parsed, ok
+}
+
+func (s *LogProfileSelector) ServerMessage(line string) (string, bool) {
+	profile := s.Profile()
+	if profile == nil {
+		if profile, _, _ = guessLogProfile(line); profile == nil {
+			return "", false
+		}
+	}
+	return profile.ServerMessage(line)
+}
+
+// ChatMessage returns the player and text of a chat message.
+func (s *LogProfileSelector) ChatMessage(message string) (player string, text string, ok bool) {
+	chat := PlayerMessage
+	if profile := s.Profile(); profile != nil && profile.Chat != nil {
+		chat = profile.Chat
+	}
+	match := chat.FindStringSubmatch(message)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
@@ -14,7 +14,7 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.28.1
+// 	protoc-gen-go v1.36.6
 // 	protoc        v4.25.3
 // source: core/manager/manager.proto
 
@@ -23,9 +23,12 @@ package manager
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -81,34 +84,1574 @@ func (MinecraftState) EnumDescriptor() ([]byte, []int) {
 	return file_core_manager_manager_proto_rawDescGZIP(), []int{0}
 }
 
+type RestartPolicy int32
+
+const (
+	// use the policy GameManager was started with
+	RestartPolicy_restart_default RestartPolicy = 0
+	RestartPolicy_never           RestartPolicy = 1
+	// restart only after a non-zero exit that wasn't requested by Stop
+	RestartPolicy_on_failure RestartPolicy = 2
+	// restart after every exit that wasn't requested by Stop
+	RestartPolicy_always RestartPolicy = 3
+)
+
+// Enum value maps for RestartPolicy.
+var (
+	RestartPolicy_name = map[int32]string{
+		0: "restart_default",
+		1: "never",
+		2: "on_failure",
+		3: "always",
+	}
+	RestartPolicy_value = map[string]int32{
+		"restart_default": 0,
+		"never":           1,
+		"on_failure":      2,
+		"always":          3,
+	}
+)
+
+func (x RestartPolicy) Enum() *RestartPolicy {
+	p := new(RestartPolicy)
+	*p = x
+	return p
+}
+
+func (x RestartPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RestartPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_core_manager_manager_proto_enumTypes[1].Descriptor()
+}
+
+func (RestartPolicy) Type() protoreflect.EnumType {
+	return &file_core_manager_manager_proto_enumTypes[1]
+}
+
+func (x RestartPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RestartPolicy.Descriptor instead.
+func (RestartPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{1}
+}
+
+type LogStream int32
+
+const (
+	LogStream_stdout LogStream = 0
+	LogStream_stderr LogStream = 1
+)
+
+// Enum value maps for LogStream.
+var (
+	LogStream_name = map[int32]string{
+		0: "stdout",
+		1: "stderr",
+	}
+	LogStream_value = map[string]int32{
+		"stdout": 0,
+		"stderr": 1,
+	}
+)
+
+func (x LogStream) Enum() *LogStream {
+	p := new(LogStream)
+	*p = x
+	return p
+}
+
+func (x LogStream) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LogStream) Descriptor() protoreflect.EnumDescriptor {
+	return file_core_manager_manager_proto_enumTypes[2].Descriptor()
+}
+
+func (LogStream) Type() protoreflect.EnumType {
+	return &file_core_manager_manager_proto_enumTypes[2]
+}
+
+func (x LogStream) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LogStream.Descriptor instead.
+func (LogStream) EnumDescriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{2}
+}
+
+type StateReason int32
+
+const (
+	StateReason_state_unknown  StateReason = 0
+	StateReason_server_started StateReason = 1
+	// the process exited, followed by server_crashed on a non-zero exit
+	StateReason_server_stopped        StateReason = 2
+	StateReason_server_crashed        StateReason = 3
+	StateReason_server_restarting     StateReason = 4
+	StateReason_restart_limit_reached StateReason = 5
+	// an automatic restart could not start the server, it is tried again
+	// after the backoff unless the limit is reached
+	StateReason_restart_failed StateReason = 6
+)
+
+// Enum value maps for StateReason.
+var (
+	StateReason_name = map[int32]string{
+		0: "state_unknown",
+		1: "server_started",
+		2: "server_stopped",
+		3: "server_crashed",
+		4: "server_restarting",
+		5: "restart_limit_reached",
+		6: "restart_failed",
+	}
+	StateReason_value = map[string]int32{
+		"state_unknown":         0,
+		"server_started":        1,
+		"server_stopped":        2,
+		"server_crashed":        3,
+		"server_restarting":     4,
+		"restart_limit_reached": 5,
+		"restart_failed":        6,
+	}
+)
+
+func (x StateReason) Enum() *StateReason {
+	p := new(StateReason)
+	*p = x
+	return p
+}
+
+func (x StateReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StateReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_core_manager_manager_proto_enumTypes[3].Descriptor()
+}
+
+func (StateReason) Type() protoreflect.EnumType {
+	return &file_core_manager_manager_proto_enumTypes[3]
+}
+
+func (x StateReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StateReason.Descriptor instead.
+func (StateReason) EnumDescriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{3}
+}
+
+// which step of the stop sequence ended the process
+type StopStage int32
+
+const (
+	StopStage_stop_stage_unknown StopStage = 0
+	// the server shut down after the stop command
+	StopStage_stop_command StopStage = 1
+	StopStage_sigterm      StopStage = 2
+	// the whole process group was killed
+	StopStage_sigkill StopStage = 3
+	// the process wasn't running
+	StopStage_not_running StopStage = 4
+)
+
+// Enum value maps for StopStage.
+var (
+	StopStage_name = map[int32]string{
+		0: "stop_stage_unknown",
+		1: "stop_command",
+		2: "sigterm",
+		3: "sigkill",
+		4: "not_running",
+	}
+	StopStage_value = map[string]int32{
+		"stop_stage_unknown": 0,
+		"stop_command":       1,
+		"sigterm":            2,
+		"sigkill":            3,
+		"not_running":        4,
+	}
+)
+
+func (x StopStage) Enum() *StopStage {
+	p := new(StopStage)
+	*p = x
+	return p
+}
+
+func (x StopStage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StopStage) Descriptor() protoreflect.EnumDescriptor {
+	return file_core_manager_manager_proto_enumTypes[4].Descriptor()
+}
+
+func (StopStage) Type() protoreflect.EnumType {
+	return &file_core_manager_manager_proto_enumTypes[4]
+}
+
+func (x StopStage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StopStage.Descriptor instead.
+func (StopStage) EnumDescriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{4}
+}
+
+type CrashReportKind int32
+
+const (
+	CrashReportKind_crash_unknown CrashReportKind = 0
+	// crash-reports/crash-*.txt written by the game
+	CrashReportKind_minecraft_crash CrashReportKind = 1
+	// hs_err_pid*.log written by the JVM
+	CrashReportKind_jvm_fatal_error CrashReportKind = 2
+)
+
+// Enum value maps for CrashReportKind.
+var (
+	CrashReportKind_name = map[int32]string{
+		0: "crash_unknown",
+		1: "minecraft_crash",
+		2: "jvm_fatal_error",
+	}
+	CrashReportKind_value = map[string]int32{
+		"crash_unknown":   0,
+		"minecraft_crash": 1,
+		"jvm_fatal_error": 2,
+	}
+)
+
+func (x CrashReportKind) Enum() *CrashReportKind {
+	p := new(CrashReportKind)
+	*p = x
+	return p
+}
+
+func (x CrashReportKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CrashReportKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_core_manager_manager_proto_enumTypes[5].Descriptor()
+}
+
+func (CrashReportKind) Type() protoreflect.EnumType {
+	return &file_core_manager_manager_proto_enumTypes[5]
+}
+
+func (x CrashReportKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CrashReportKind.Descriptor instead.
+func (CrashReportKind) EnumDescriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{5}
+}
+
+type LogLine struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Stream LogStream              `protobuf:"varint,1,opt,name=stream,proto3,enum=LogStream" json:"stream,omitempty"`
+	Line   string                 `protobuf:"bytes,2,opt,name=line,proto3" json:"line,omitempty"`
+	// when GameManager read the line from the process
+	Received      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=received,proto3" json:"received,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogLine) Reset() {
+	*x = LogLine{}
+	mi := &file_core_manager_manager_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogLine) ProtoMessage() {}
+
+func (x *LogLine) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogLine.ProtoReflect.Descriptor instead.
+func (*LogLine) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LogLine) GetStream() LogStream {
+	if x != nil {
+		return x.Stream
+	}
+	return LogStream_stdout
+}
+
+func (x *LogLine) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+func (x *LogLine) GetReceived() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Received
+	}
+	return nil
+}
+
+type StateTransition struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Reason   StateReason            `protobuf:"varint,1,opt,name=reason,proto3,enum=StateReason" json:"reason,omitempty"`
+	OldState MinecraftState         `protobuf:"varint,2,opt,name=old_state,json=oldState,proto3,enum=MinecraftState" json:"old_state,omitempty"`
+	NewState MinecraftState         `protobuf:"varint,3,opt,name=new_state,json=newState,proto3,enum=MinecraftState" json:"new_state,omitempty"`
+	// set for server_stopped and server_crashed, -1 if the exit status is unknown,
+	// 128 + the signal if a signal killed the server
+	ExitCode int32 `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// set for server_stopped when the exit was requested
+	StopStage     StopStage `protobuf:"varint,5,opt,name=stop_stage,json=stopStage,proto3,enum=StopStage" json:"stop_stage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateTransition) Reset() {
+	*x = StateTransition{}
+	mi := &file_core_manager_manager_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateTransition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateTransition) ProtoMessage() {}
+
+func (x *StateTransition) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateTransition.ProtoReflect.Descriptor instead.
+func (*StateTransition) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StateTransition) GetReason() StateReason {
+	if x != nil {
+		return x.Reason
+	}
+	return StateReason_state_unknown
+}
+
+func (x *StateTransition) GetOldState() MinecraftState {
+	if x != nil {
+		return x.OldState
+	}
+	return MinecraftState_stopped
+}
+
+func (x *StateTransition) GetNewState() MinecraftState {
+	if x != nil {
+		return x.NewState
+	}
+	return MinecraftState_stopped
+}
+
+func (x *StateTransition) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *StateTransition) GetStopStage() StopStage {
+	if x != nil {
+		return x.StopStage
+	}
+	return StopStage_stop_stage_unknown
+}
+
+type LockChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// client id now holding the write lock, 0 when released
+	Owner         uint64 `protobuf:"varint,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	Previous      uint64 `protobuf:"varint,2,opt,name=previous,proto3" json:"previous,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockChange) Reset() {
+	*x = LockChange{}
+	mi := &file_core_manager_manager_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockChange) ProtoMessage() {}
+
+func (x *LockChange) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockChange.ProtoReflect.Descriptor instead.
+func (*LockChange) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LockChange) GetOwner() uint64 {
+	if x != nil {
+		return x.Owner
+	}
+	return 0
+}
+
+func (x *LockChange) GetPrevious() uint64 {
+	if x != nil {
+		return x.Previous
+	}
+	return 0
+}
+
+type ClientEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Client        uint64                 `protobuf:"varint,1,opt,name=client,proto3" json:"client,omitempty"`
+	Connected     bool                   `protobuf:"varint,2,opt,name=connected,proto3" json:"connected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientEvent) Reset() {
+	*x = ClientEvent{}
+	mi := &file_core_manager_manager_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientEvent) ProtoMessage() {}
+
+func (x *ClientEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientEvent.ProtoReflect.Descriptor instead.
+func (*ClientEvent) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ClientEvent) GetClient() uint64 {
+	if x != nil {
+		return x.Client
+	}
+	return 0
+}
+
+func (x *ClientEvent) GetConnected() bool {
+	if x != nil {
+		return x.Connected
+	}
+	return false
+}
+
+// the console didn't answer the watchdog probe
+type HungEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// time since the last console output
+	Silent *durationpb.Duration `protobuf:"bytes,1,opt,name=silent,proto3" json:"silent,omitempty"`
+	// file the thread dump was written to, empty if it couldn't be captured
+	ThreadDump string `protobuf:"bytes,2,opt,name=thread_dump,json=threadDump,proto3" json:"thread_dump,omitempty"`
+	// the watchdog kills the server to let it restart
+	Restarting    bool `protobuf:"varint,3,opt,name=restarting,proto3" json:"restarting,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HungEvent) Reset() {
+	*x = HungEvent{}
+	mi := &file_core_manager_manager_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HungEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HungEvent) ProtoMessage() {}
+
+func (x *HungEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HungEvent.ProtoReflect.Descriptor instead.
+func (*HungEvent) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HungEvent) GetSilent() *durationpb.Duration {
+	if x != nil {
+		return x.Silent
+	}
+	return nil
+}
+
+func (x *HungEvent) GetThreadDump() string {
+	if x != nil {
+		return x.ThreadDump
+	}
+	return ""
+}
+
+func (x *HungEvent) GetRestarting() bool {
+	if x != nil {
+		return x.Restarting
+	}
+	return false
+}
+
+// headline of a crash file found after the server exited unexpectedly
+type CrashReport struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Kind        CrashReportKind        `protobuf:"varint,1,opt,name=kind,proto3,enum=CrashReportKind" json:"kind,omitempty"`
+	Path        string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Time        *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	// exception or signal, e.g. "java.lang.NullPointerException: ..." or "SIGSEGV (0xb)"
+	Exception string `protobuf:"bytes,5,opt,name=exception,proto3" json:"exception,omitempty"`
+	// suspected mod, or the first foreign stack frame / problematic native frame
+	Suspect       string `protobuf:"bytes,6,opt,name=suspect,proto3" json:"suspect,omitempty"`
+	ExitCode      int32  `protobuf:"varint,7,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrashReport) Reset() {
+	*x = CrashReport{}
+	mi := &file_core_manager_manager_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrashReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrashReport) ProtoMessage() {}
+
+func (x *CrashReport) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrashReport.ProtoReflect.Descriptor instead.
+func (*CrashReport) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CrashReport) GetKind() CrashReportKind {
+	if x != nil {
+		return x.Kind
+	}
+	return CrashReportKind_crash_unknown
+}
+
+func (x *CrashReport) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *CrashReport) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *CrashReport) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CrashReport) GetException() string {
+	if x != nil {
+		return x.Exception
+	}
+	return ""
+}
+
+func (x *CrashReport) GetSuspect() string {
+	if x != nil {
+		return x.Suspect
+	}
+	return ""
+}
+
+func (x *CrashReport) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
 type WriteRequest struct {
-	state         protoimpl.MessageState
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Content string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Client  *Client                `protobuf:"bytes,3,opt,name=client,proto3" json:"client,omitempty"`
+	// fencing token returned by Lock, writes with an outdated token are rejected
+	Token         uint64 `protobuf:"varint,4,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteRequest) Reset() {
+	*x = WriteRequest{}
+	mi := &file_core_manager_manager_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteRequest) ProtoMessage() {}
+
+func (x *WriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WriteRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *WriteRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *WriteRequest) GetClient() *Client {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *WriteRequest) GetToken() uint64 {
+	if x != nil {
+		return x.Token
+	}
+	return 0
+}
+
+type LockResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// increases every time the lock changes hands
+	Token         uint64 `protobuf:"varint,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockResponse) Reset() {
+	*x = LockResponse{}
+	mi := &file_core_manager_manager_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockResponse) ProtoMessage() {}
+
+func (x *LockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockResponse.ProtoReflect.Descriptor instead.
+func (*LockResponse) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LockResponse) GetToken() uint64 {
+	if x != nil {
+		return x.Token
+	}
+	return 0
+}
+
+type LockStatusResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// client id holding the lock, 0 if free
+	Holder   uint64                 `protobuf:"varint,1,opt,name=holder,proto3" json:"holder,omitempty"`
+	Token    uint64                 `protobuf:"varint,2,opt,name=token,proto3" json:"token,omitempty"`
+	Acquired *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=acquired,proto3" json:"acquired,omitempty"`
+	Expires  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires,proto3" json:"expires,omitempty"`
+	// client ids waiting for the lock, first in line first
+	Waiters       []uint64 `protobuf:"varint,5,rep,packed,name=waiters,proto3" json:"waiters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockStatusResponse) Reset() {
+	*x = LockStatusResponse{}
+	mi := &file_core_manager_manager_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockStatusResponse) ProtoMessage() {}
+
+func (x *LockStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockStatusResponse.ProtoReflect.Descriptor instead.
+func (*LockStatusResponse) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LockStatusResponse) GetHolder() uint64 {
+	if x != nil {
+		return x.Holder
+	}
+	return 0
+}
+
+func (x *LockStatusResponse) GetToken() uint64 {
+	if x != nil {
+		return x.Token
+	}
+	return 0
+}
+
+func (x *LockStatusResponse) GetAcquired() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Acquired
+	}
+	return nil
+}
+
+func (x *LockStatusResponse) GetExpires() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Expires
+	}
+	return nil
+}
+
+func (x *LockStatusResponse) GetWaiters() []uint64 {
+	if x != nil {
+		return x.Waiters
+	}
+	return nil
+}
+
+type MessageResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type     string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Content  string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Locked   bool                   `protobuf:"varint,4,opt,name=locked,proto3" json:"locked,omitempty"`
+	Instance string                 `protobuf:"bytes,5,opt,name=instance,proto3" json:"instance,omitempty"`
+	// per-instance sequence number shared by every subscriber
+	Seq uint64 `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`
+	// typed payload, type and content are still filled for older clients:
+	// log -> "stdout", state -> "StateChange", lock -> "LockChange",
+	// client -> "ClientEvent", hung -> "Hung", crash_report -> "CrashReport",
+	// history_gap -> "HistoryGap"
+	//
+	// Types that are valid to be assigned to Event:
+	//
+	//	*MessageResponse_Log
+	//	*MessageResponse_State
+	//	*MessageResponse_Lock
+	//	*MessageResponse_Client
+	//	*MessageResponse_Hung
+	//	*MessageResponse_CrashReport
+	//	*MessageResponse_HistoryGap
+	Event         isMessageResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageResponse) Reset() {
+	*x = MessageResponse{}
+	mi := &file_core_manager_manager_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageResponse) ProtoMessage() {}
+
+func (x *MessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageResponse.ProtoReflect.Descriptor instead.
+func (*MessageResponse) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MessageResponse) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MessageResponse) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *MessageResponse) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *MessageResponse) GetLocked() bool {
+	if x != nil {
+		return x.Locked
+	}
+	return false
+}
+
+func (x *MessageResponse) GetInstance() string {
+	if x != nil {
+		return x.Instance
+	}
+	return ""
+}
+
+func (x *MessageResponse) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageResponse) GetEvent() isMessageResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *MessageResponse) GetLog() *LogLine {
+	if x != nil {
+		if x, ok := x.Event.(*MessageResponse_Log); ok {
+			return x.Log
+		}
+	}
+	return nil
+}
+
+func (x *MessageResponse) GetState() *StateTransition {
+	if x != nil {
+		if x, ok := x.Event.(*MessageResponse_State); ok {
+			return x.State
+		}
+	}
+	return nil
+}
+
+func (x *MessageResponse) GetLock() *LockChange {
+	if x != nil {
+		if x, ok := x.Event.(*MessageResponse_Lock); ok {
+			return x.Lock
+		}
+	}
+	return nil
+}
+
+func (x *MessageResponse) GetClient() *ClientEvent {
+	if x != nil {
+		if x, ok := x.Event.(*MessageResponse_Client); ok {
+			return x.Client
+		}
+	}
+	return nil
+}
+
+func (x *MessageResponse) GetHung() *HungEvent {
+	if x != nil {
+		if x, ok := x.Event.(*MessageResponse_Hung); ok {
+			return x.Hung
+		}
+	}
+	return nil
+}
+
+func (x *MessageResponse) GetCrashReport() *CrashReport {
+	if x != nil {
+		if x, ok := x.Event.(*MessageResponse_CrashReport); ok {
+			return x.CrashReport
+		}
+	}
+	return nil
+}
+
+func (x *MessageResponse) GetHistoryGap() *HistoryGap {
+	if x != nil {
+		if x, ok := x.Event.(*MessageResponse_HistoryGap); ok {
+			return x.HistoryGap
+		}
+	}
+	return nil
+}
+
+type isMessageResponse_Event interface {
+	isMessageResponse_Event()
+}
+
+type MessageResponse_Log struct {
+	Log *LogLine `protobuf:"bytes,8,opt,name=log,proto3,oneof"`
+}
+
+type MessageResponse_State struct {
+	State *StateTransition `protobuf:"bytes,9,opt,name=state,proto3,oneof"`
+}
+
+type MessageResponse_Lock struct {
+	Lock *LockChange `protobuf:"bytes,10,opt,name=lock,proto3,oneof"`
+}
+
+type MessageResponse_Client struct {
+	Client *ClientEvent `protobuf:"bytes,11,opt,name=client,proto3,oneof"`
+}
+
+type MessageResponse_Hung struct {
+	Hung *HungEvent `protobuf:"bytes,12,opt,name=hung,proto3,oneof"`
+}
+
+type MessageResponse_CrashReport struct {
+	CrashReport *CrashReport `protobuf:"bytes,13,opt,name=crash_report,json=crashReport,proto3,oneof"`
+}
+
+type MessageResponse_HistoryGap struct {
+	HistoryGap *HistoryGap `protobuf:"bytes,14,opt,name=history_gap,json=historyGap,proto3,oneof"`
+}
+
+func (*MessageResponse_Log) isMessageResponse_Event() {}
+
+func (*MessageResponse_State) isMessageResponse_Event() {}
+
+func (*MessageResponse_Lock) isMessageResponse_Event() {}
+
+func (*MessageResponse_Client) isMessageResponse_Event() {}
+
+func (*MessageResponse_Hung) isMessageResponse_Event() {}
+
+func (*MessageResponse_CrashReport) isMessageResponse_Event() {}
+
+func (*MessageResponse_HistoryGap) isMessageResponse_Event() {}
+
+// sent before the replay when the history after MessageRequest.since is
+// partly gone
+type HistoryGap struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// how many messages fell out of the history buffer before they could be
+	// replayed, 0 if unknown
+	Missed        uint64 `protobuf:"varint,1,opt,name=missed,proto3" json:"missed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryGap) Reset() {
+	*x = HistoryGap{}
+	mi := &file_core_manager_manager_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryGap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryGap) ProtoMessage() {}
+
+func (x *HistoryGap) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryGap.ProtoReflect.Descriptor instead.
+func (*HistoryGap) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *HistoryGap) GetMissed() uint64 {
+	if x != nil {
+		return x.Missed
+	}
+	return 0
+}
+
+// id and instance have the numbers and types of Client, the request of
+// Message used to be a Client and older daemons still send one
+type MessageRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// target instance name, empty selects "default"
+	Instance string `protobuf:"bytes,2,opt,name=instance,proto3" json:"instance,omitempty"`
+	// last seq the client has received, history after it is replayed before
+	// live messages. 0 subscribes to live messages only.
+	Since         uint64 `protobuf:"varint,3,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageRequest) Reset() {
+	*x = MessageRequest{}
+	mi := &file_core_manager_manager_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageRequest) ProtoMessage() {}
+
+func (x *MessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageRequest.ProtoReflect.Descriptor instead.
+func (*MessageRequest) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MessageRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MessageRequest) GetInstance() string {
+	if x != nil {
+		return x.Instance
+	}
+	return ""
+}
+
+func (x *MessageRequest) GetSince() uint64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+// how to launch the server without a start script. Relative paths are
+// resolved against workdir.
+type LaunchProfile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// java binary, a bare name is looked up in PATH, defaults to "java"
+	Java string `protobuf:"bytes,1,opt,name=java,proto3" json:"java,omitempty"`
+	// JVM flags such as -Xms/-Xmx and GC options
+	JvmArgs []string `protobuf:"bytes,2,rep,name=jvm_args,json=jvmArgs,proto3" json:"jvm_args,omitempty"`
+	// server jar started with -jar, mutually exclusive with args_file
+	Jar string `protobuf:"bytes,3,opt,name=jar,proto3" json:"jar,omitempty"`
+	// java @argument file, e.g. Forge's libraries/.../unix_args.txt
+	ArgsFile string `protobuf:"bytes,4,opt,name=args_file,json=argsFile,proto3" json:"args_file,omitempty"`
+	// program arguments after the jar, e.g. nogui
+	Args []string `protobuf:"bytes,5,rep,name=args,proto3" json:"args,omitempty"`
+	// extra environment variables as KEY=VALUE
+	Env []string `protobuf:"bytes,6,rep,name=env,proto3" json:"env,omitempty"`
+	// working directory, defaults to the directory of the jar or args file
+	Workdir string `protobuf:"bytes,7,opt,name=workdir,proto3" json:"workdir,omitempty"`
+	// console command used by Stop, defaults to "stop"
+	StopCommand   string `protobuf:"bytes,8,opt,name=stop_command,json=stopCommand,proto3" json:"stop_command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LaunchProfile) Reset() {
+	*x = LaunchProfile{}
+	mi := &file_core_manager_manager_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LaunchProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LaunchProfile) ProtoMessage() {}
+
+func (x *LaunchProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LaunchProfile.ProtoReflect.Descriptor instead.
+func (*LaunchProfile) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LaunchProfile) GetJava() string {
+	if x != nil {
+		return x.Java
+	}
+	return ""
+}
+
+func (x *LaunchProfile) GetJvmArgs() []string {
+	if x != nil {
+		return x.JvmArgs
+	}
+	return nil
+}
+
+func (x *LaunchProfile) GetJar() string {
+	if x != nil {
+		return x.Jar
+	}
+	return ""
+}
+
+func (x *LaunchProfile) GetArgsFile() string {
+	if x != nil {
+		return x.ArgsFile
+	}
+	return ""
+}
+
+func (x *LaunchProfile) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *LaunchProfile) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *LaunchProfile) GetWorkdir() string {
+	if x != nil {
+		return x.Workdir
+	}
+	return ""
+}
+
+func (x *LaunchProfile) GetStopCommand() string {
+	if x != nil {
+		return x.StopCommand
+	}
+	return ""
+}
+
+type StartRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// start script, ignored when profile is set
+	Path          string         `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Client        *Client        `protobuf:"bytes,2,opt,name=client,proto3" json:"client,omitempty"`
+	Restart       RestartPolicy  `protobuf:"varint,3,opt,name=restart,proto3,enum=RestartPolicy" json:"restart,omitempty"`
+	Profile       *LaunchProfile `protobuf:"bytes,4,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRequest) Reset() {
+	*x = StartRequest{}
+	mi := &file_core_manager_manager_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRequest) ProtoMessage() {}
+
+func (x *StartRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRequest.ProtoReflect.Descriptor instead.
+func (*StartRequest) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StartRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *StartRequest) GetClient() *Client {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *StartRequest) GetRestart() RestartPolicy {
+	if x != nil {
+		return x.Restart
+	}
+	return RestartPolicy_restart_default
+}
+
+func (x *StartRequest) GetProfile() *LaunchProfile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+type Client struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// target instance name, empty selects "default". StartRequest and
+	// WriteRequest inherit it through their client field.
+	Instance      string `protobuf:"bytes,2,opt,name=instance,proto3" json:"instance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Client) Reset() {
+	*x = Client{}
+	mi := &file_core_manager_manager_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Client) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Client) ProtoMessage() {}
+
+func (x *Client) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Client.ProtoReflect.Descriptor instead.
+func (*Client) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Client) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Client) GetInstance() string {
+	if x != nil {
+		return x.Instance
+	}
+	return ""
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         MinecraftState         `protobuf:"varint,1,opt,name=state,proto3,enum=MinecraftState" json:"state,omitempty"`
+	Usedmemory    uint64                 `protobuf:"varint,2,opt,name=usedmemory,proto3" json:"usedmemory,omitempty"`
+	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_core_manager_manager_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StatusResponse) GetState() MinecraftState {
+	if x != nil {
+		return x.State
+	}
+	return MinecraftState_stopped
+}
+
+func (x *StatusResponse) GetUsedmemory() uint64 {
+	if x != nil {
+		return x.Usedmemory
+	}
+	return 0
+}
+
+type InstanceStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	State         MinecraftState         `protobuf:"varint,2,opt,name=state,proto3,enum=MinecraftState" json:"state,omitempty"`
+	Usedmemory    uint64                 `protobuf:"varint,3,opt,name=usedmemory,proto3" json:"usedmemory,omitempty"`
 	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstanceStatus) Reset() {
+	*x = InstanceStatus{}
+	mi := &file_core_manager_manager_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstanceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstanceStatus) ProtoMessage() {}
+
+func (x *InstanceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
 
-	Id      uint64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Content string  `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
-	Client  *Client `protobuf:"bytes,3,opt,name=client,proto3" json:"client,omitempty"`
+// Deprecated: Use InstanceStatus.ProtoReflect.Descriptor instead.
+func (*InstanceStatus) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *WriteRequest) Reset() {
-	*x = WriteRequest{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_core_manager_manager_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *InstanceStatus) GetName() string {
+	if x != nil {
+		return x.Name
 	}
+	return ""
 }
 
-func (x *WriteRequest) String() string {
+func (x *InstanceStatus) GetState() MinecraftState {
+	if x != nil {
+		return x.State
+	}
+	return MinecraftState_stopped
+}
+
+func (x *InstanceStatus) GetUsedmemory() uint64 {
+	if x != nil {
+		return x.Usedmemory
+	}
+	return 0
+}
+
+type InstanceList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instances     []*InstanceStatus      `protobuf:"bytes,1,rep,name=instances,proto3" json:"instances,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstanceList) Reset() {
+	*x = InstanceList{}
+	mi := &file_core_manager_manager_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstanceList) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WriteRequest) ProtoMessage() {}
+func (*InstanceList) ProtoMessage() {}
 
-func (x *WriteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_core_manager_manager_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+func (x *InstanceList) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[17]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -118,61 +1661,106 @@ func (x *WriteRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
-func (*WriteRequest) Descriptor() ([]byte, []int) {
-	return file_core_manager_manager_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use InstanceList.ProtoReflect.Descriptor instead.
+func (*InstanceList) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *WriteRequest) GetId() uint64 {
+func (x *InstanceList) GetInstances() []*InstanceStatus {
 	if x != nil {
-		return x.Id
+		return x.Instances
 	}
-	return 0
+	return nil
 }
 
-func (x *WriteRequest) GetContent() string {
+type MetricsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Client *Client                `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	// sampling interval, GameManager's default when unset
+	Interval      *durationpb.Duration `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricsRequest) Reset() {
+	*x = MetricsRequest{}
+	mi := &file_core_manager_manager_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsRequest) ProtoMessage() {}
+
+func (x *MetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[18]
 	if x != nil {
-		return x.Content
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *WriteRequest) GetClient() *Client {
+// Deprecated: Use MetricsRequest.ProtoReflect.Descriptor instead.
+func (*MetricsRequest) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *MetricsRequest) GetClient() *Client {
 	if x != nil {
 		return x.Client
 	}
 	return nil
 }
 
-type MessageResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *MetricsRequest) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
 
-	Id      uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Type    string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
-	Content string `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	Locked  bool   `protobuf:"varint,4,opt,name=locked,proto3" json:"locked,omitempty"`
+// resource usage of the server process and all of its children
+type ProcessMetrics struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	State MinecraftState         `protobuf:"varint,2,opt,name=state,proto3,enum=MinecraftState" json:"state,omitempty"`
+	Pid   int32                  `protobuf:"varint,3,opt,name=pid,proto3" json:"pid,omitempty"`
+	// percent of one core, may exceed 100 on multi-core hosts
+	CpuPercent    float64              `protobuf:"fixed64,4,opt,name=cpu_percent,json=cpuPercent,proto3" json:"cpu_percent,omitempty"`
+	Threads       int32                `protobuf:"varint,5,opt,name=threads,proto3" json:"threads,omitempty"`
+	OpenFiles     int32                `protobuf:"varint,6,opt,name=open_files,json=openFiles,proto3" json:"open_files,omitempty"`
+	ReadBytes     uint64               `protobuf:"varint,7,opt,name=read_bytes,json=readBytes,proto3" json:"read_bytes,omitempty"`
+	WriteBytes    uint64               `protobuf:"varint,8,opt,name=write_bytes,json=writeBytes,proto3" json:"write_bytes,omitempty"`
+	Rss           uint64               `protobuf:"varint,9,opt,name=rss,proto3" json:"rss,omitempty"`
+	Vms           uint64               `protobuf:"varint,10,opt,name=vms,proto3" json:"vms,omitempty"`
+	Uptime        *durationpb.Duration `protobuf:"bytes,11,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MessageResponse) Reset() {
-	*x = MessageResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_core_manager_manager_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+func (x *ProcessMetrics) Reset() {
+	*x = ProcessMetrics{}
+	mi := &file_core_manager_manager_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *MessageResponse) String() string {
+func (x *ProcessMetrics) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MessageResponse) ProtoMessage() {}
+func (*ProcessMetrics) ProtoMessage() {}
 
-func (x *MessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_core_manager_manager_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+func (x *ProcessMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[19]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -182,66 +1770,118 @@ func (x *MessageResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MessageResponse.ProtoReflect.Descriptor instead.
-func (*MessageResponse) Descriptor() ([]byte, []int) {
-	return file_core_manager_manager_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use ProcessMetrics.ProtoReflect.Descriptor instead.
+func (*ProcessMetrics) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *MessageResponse) GetId() uint64 {
+func (x *ProcessMetrics) GetTime() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Id
+		return x.Time
+	}
+	return nil
+}
+
+func (x *ProcessMetrics) GetState() MinecraftState {
+	if x != nil {
+		return x.State
+	}
+	return MinecraftState_stopped
+}
+
+func (x *ProcessMetrics) GetPid() int32 {
+	if x != nil {
+		return x.Pid
 	}
 	return 0
 }
 
-func (x *MessageResponse) GetType() string {
+func (x *ProcessMetrics) GetCpuPercent() float64 {
 	if x != nil {
-		return x.Type
+		return x.CpuPercent
 	}
-	return ""
+	return 0
 }
 
-func (x *MessageResponse) GetContent() string {
+func (x *ProcessMetrics) GetThreads() int32 {
 	if x != nil {
-		return x.Content
+		return x.Threads
 	}
-	return ""
+	return 0
 }
 
-func (x *MessageResponse) GetLocked() bool {
+func (x *ProcessMetrics) GetOpenFiles() int32 {
 	if x != nil {
-		return x.Locked
+		return x.OpenFiles
 	}
-	return false
+	return 0
 }
 
-type StartRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *ProcessMetrics) GetReadBytes() uint64 {
+	if x != nil {
+		return x.ReadBytes
+	}
+	return 0
+}
 
-	Path   string  `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Client *Client `protobuf:"bytes,2,opt,name=client,proto3" json:"client,omitempty"`
+func (x *ProcessMetrics) GetWriteBytes() uint64 {
+	if x != nil {
+		return x.WriteBytes
+	}
+	return 0
 }
 
-func (x *StartRequest) Reset() {
-	*x = StartRequest{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_core_manager_manager_proto_msgTypes[2]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *ProcessMetrics) GetRss() uint64 {
+	if x != nil {
+		return x.Rss
 	}
+	return 0
 }
 
-func (x *StartRequest) String() string {
+func (x *ProcessMetrics) GetVms() uint64 {
+	if x != nil {
+		return x.Vms
+	}
+	return 0
+}
+
+func (x *ProcessMetrics) GetUptime() *durationpb.Duration {
+	if x != nil {
+		return x.Uptime
+	}
+	return nil
+}
+
+type ReadLogRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Client *Client                `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	// lines received in [since, until), an unset until reads to the end
+	Since *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=since,proto3" json:"since,omitempty"`
+	Until *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=until,proto3" json:"until,omitempty"`
+	// page size, GameManager applies a default and an upper bound
+	Limit uint32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	// cursor of the previous page
+	Cursor        string `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadLogRequest) Reset() {
+	*x = ReadLogRequest{}
+	mi := &file_core_manager_manager_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadLogRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartRequest) ProtoMessage() {}
+func (*ReadLogRequest) ProtoMessage() {}
 
-func (x *StartRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_core_manager_manager_proto_msgTypes[2]
-	if protoimpl.UnsafeEnabled && x != nil {
+func (x *ReadLogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[20]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -251,51 +1891,71 @@ func (x *StartRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartRequest.ProtoReflect.Descriptor instead.
-func (*StartRequest) Descriptor() ([]byte, []int) {
-	return file_core_manager_manager_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use ReadLogRequest.ProtoReflect.Descriptor instead.
+func (*ReadLogRequest) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *StartRequest) GetPath() string {
+func (x *ReadLogRequest) GetClient() *Client {
 	if x != nil {
-		return x.Path
+		return x.Client
 	}
-	return ""
+	return nil
 }
 
-func (x *StartRequest) GetClient() *Client {
+func (x *ReadLogRequest) GetSince() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Client
+		return x.Since
 	}
 	return nil
 }
 
-type Client struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *ReadLogRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
 
-	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+func (x *ReadLogRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
 }
 
-func (x *Client) Reset() {
-	*x = Client{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_core_manager_manager_proto_msgTypes[3]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *ReadLogRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
 	}
+	return ""
 }
 
-func (x *Client) String() string {
+type ReadLogResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Lines []*LogLine             `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
+	// pass to the next ReadLog for more lines, empty when the range is exhausted
+	Cursor        string `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadLogResponse) Reset() {
+	*x = ReadLogResponse{}
+	mi := &file_core_manager_manager_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadLogResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Client) ProtoMessage() {}
+func (*ReadLogResponse) ProtoMessage() {}
 
-func (x *Client) ProtoReflect() protoreflect.Message {
-	mi := &file_core_manager_manager_proto_msgTypes[3]
-	if protoimpl.UnsafeEnabled && x != nil {
+func (x *ReadLogResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[21]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -305,45 +1965,113 @@ func (x *Client) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Client.ProtoReflect.Descriptor instead.
-func (*Client) Descriptor() ([]byte, []int) {
-	return file_core_manager_manager_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use ReadLogResponse.ProtoReflect.Descriptor instead.
+func (*ReadLogResponse) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *Client) GetId() uint64 {
+func (x *ReadLogResponse) GetLines() []*LogLine {
 	if x != nil {
-		return x.Id
+		return x.Lines
 	}
-	return 0
+	return nil
 }
 
-type StatusResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+func (x *ReadLogResponse) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type StopRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Client *Client                `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	// time to wait after the stop command before SIGTERM, default when unset
+	TermTimeout *durationpb.Duration `protobuf:"bytes,2,opt,name=term_timeout,json=termTimeout,proto3" json:"term_timeout,omitempty"`
+	// time to wait after SIGTERM before SIGKILL, default when unset
+	KillTimeout   *durationpb.Duration `protobuf:"bytes,3,opt,name=kill_timeout,json=killTimeout,proto3" json:"kill_timeout,omitempty"`
 	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-	State      MinecraftState `protobuf:"varint,1,opt,name=state,proto3,enum=MinecraftState" json:"state,omitempty"`
-	Usedmemory uint64         `protobuf:"varint,2,opt,name=usedmemory,proto3" json:"usedmemory,omitempty"`
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	mi := &file_core_manager_manager_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatusResponse) Reset() {
-	*x = StatusResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_core_manager_manager_proto_msgTypes[4]
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[22]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
+	return mi.MessageOf(x)
 }
 
-func (x *StatusResponse) String() string {
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StopRequest) GetClient() *Client {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *StopRequest) GetTermTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.TermTimeout
+	}
+	return nil
+}
+
+func (x *StopRequest) GetKillTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.KillTimeout
+	}
+	return nil
+}
+
+type StopResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Stage    StopStage              `protobuf:"varint,1,opt,name=stage,proto3,enum=StopStage" json:"stage,omitempty"`
+	ExitCode int32                  `protobuf:"varint,2,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// state after a Restart
+	State         MinecraftState `protobuf:"varint,3,opt,name=state,proto3,enum=MinecraftState" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopResponse) Reset() {
+	*x = StopResponse{}
+	mi := &file_core_manager_manager_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusResponse) ProtoMessage() {}
+func (*StopResponse) ProtoMessage() {}
 
-func (x *StatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_core_manager_manager_proto_msgTypes[4]
-	if protoimpl.UnsafeEnabled && x != nil {
+func (x *StopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_core_manager_manager_proto_msgTypes[23]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -353,135 +2081,353 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
-func (*StatusResponse) Descriptor() ([]byte, []int) {
-	return file_core_manager_manager_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
+func (*StopResponse) Descriptor() ([]byte, []int) {
+	return file_core_manager_manager_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *StatusResponse) GetState() MinecraftState {
+func (x *StopResponse) GetStage() StopStage {
 	if x != nil {
-		return x.State
+		return x.Stage
 	}
-	return MinecraftState_stopped
+	return StopStage_stop_stage_unknown
 }
 
-func (x *StatusResponse) GetUsedmemory() uint64 {
+func (x *StopResponse) GetExitCode() int32 {
 	if x != nil {
-		return x.Usedmemory
+		return x.ExitCode
 	}
 	return 0
 }
 
+func (x *StopResponse) GetState() MinecraftState {
+	if x != nil {
+		return x.State
+	}
+	return MinecraftState_stopped
+}
+
 var File_core_manager_manager_proto protoreflect.FileDescriptor
 
-var file_core_manager_manager_proto_rawDesc = []byte{
-	0x0a, 0x1a, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2f, 0x6d,
-	0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d,
-	0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x59, 0x0a, 0x0c, 0x57, 0x72, 0x69,
-	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e,
-	0x74, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74,
-	0x65, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x63, 0x6c,
-	0x69, 0x65, 0x6e, 0x74, 0x22, 0x67, 0x0a, 0x0f, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63,
-	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
-	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x22, 0x43, 0x0a,
-	0x0c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a,
-	0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74,
-	0x68, 0x12, 0x1f, 0x0a, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x07, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x63, 0x6c, 0x69, 0x65,
-	0x6e, 0x74, 0x22, 0x18, 0x0a, 0x06, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02,
-	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x22, 0x57, 0x0a, 0x0e,
-	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25,
-	0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e,
-	0x4d, 0x69, 0x6e, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05,
-	0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x75, 0x73, 0x65, 0x64, 0x6d, 0x65, 0x6d,
-	0x6f, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x75, 0x73, 0x65, 0x64, 0x6d,
-	0x65, 0x6d, 0x6f, 0x72, 0x79, 0x2a, 0x2a, 0x0a, 0x0e, 0x4d, 0x69, 0x6e, 0x65, 0x63, 0x72, 0x61,
-	0x66, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x73, 0x74, 0x6f, 0x70, 0x70,
-	0x65, 0x64, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x10,
-	0x01, 0x32, 0xe5, 0x02, 0x0a, 0x07, 0x4d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x12, 0x29, 0x0a,
-	0x04, 0x4c, 0x6f, 0x63, 0x6b, 0x12, 0x07, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x1a, 0x16,
-	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x2b, 0x0a, 0x06, 0x55, 0x6e, 0x6c, 0x6f,
-	0x63, 0x6b, 0x12, 0x07, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x30, 0x0a, 0x05, 0x57, 0x72, 0x69, 0x74, 0x65, 0x12, 0x0d,
-	0x2e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x28, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x12, 0x07, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x1a, 0x10, 0x2e, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30,
-	0x01, 0x12, 0x29, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x0d, 0x2e, 0x53, 0x74, 0x61,
-	0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x29, 0x0a, 0x04,
-	0x53, 0x74, 0x6f, 0x70, 0x12, 0x07, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x1a, 0x16, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x24, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75,
-	0x73, 0x12, 0x07, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x1a, 0x0f, 0x2e, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x2a, 0x0a,
-	0x05, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x07,
-	0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x42, 0x34, 0x5a, 0x32, 0x63, 0x67, 0x69,
-	0x74, 0x2e, 0x62, 0x62, 0x61, 0x61, 0x2e, 0x66, 0x75, 0x6e, 0x2f, 0x62, 0x62, 0x61, 0x61, 0x2f,
-	0x6d, 0x69, 0x6e, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2d, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
-	0x2d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
-}
+const file_core_manager_manager_proto_rawDesc = "" +
+	"\n" +
+	"\x1acore/manager/manager.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/duration.proto\"y\n" +
+	"\aLogLine\x12\"\n" +
+	"\x06stream\x18\x01 \x01(\x0e2\n" +
+	".LogStreamR\x06stream\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\tR\x04line\x126\n" +
+	"\breceived\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\breceived\"\xdb\x01\n" +
+	"\x0fStateTransition\x12$\n" +
+	"\x06reason\x18\x01 \x01(\x0e2\f.StateReasonR\x06reason\x12,\n" +
+	"\told_state\x18\x02 \x01(\x0e2\x0f.MinecraftStateR\boldState\x12,\n" +
+	"\tnew_state\x18\x03 \x01(\x0e2\x0f.MinecraftStateR\bnewState\x12\x1b\n" +
+	"\texit_code\x18\x04 \x01(\x05R\bexitCode\x12)\n" +
+	"\n" +
+	"stop_stage\x18\x05 \x01(\x0e2\n" +
+	".StopStageR\tstopStage\">\n" +
+	"\n" +
+	"LockChange\x12\x14\n" +
+	"\x05owner\x18\x01 \x01(\x04R\x05owner\x12\x1a\n" +
+	"\bprevious\x18\x02 \x01(\x04R\bprevious\"C\n" +
+	"\vClientEvent\x12\x16\n" +
+	"\x06client\x18\x01 \x01(\x04R\x06client\x12\x1c\n" +
+	"\tconnected\x18\x02 \x01(\bR\tconnected\"\x7f\n" +
+	"\tHungEvent\x121\n" +
+	"\x06silent\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x06silent\x12\x1f\n" +
+	"\vthread_dump\x18\x02 \x01(\tR\n" +
+	"threadDump\x12\x1e\n" +
+	"\n" +
+	"restarting\x18\x03 \x01(\bR\n" +
+	"restarting\"\xee\x01\n" +
+	"\vCrashReport\x12$\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x10.CrashReportKindR\x04kind\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x1c\n" +
+	"\texception\x18\x05 \x01(\tR\texception\x12\x18\n" +
+	"\asuspect\x18\x06 \x01(\tR\asuspect\x12\x1b\n" +
+	"\texit_code\x18\a \x01(\x05R\bexitCode\"o\n" +
+	"\fWriteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x1f\n" +
+	"\x06client\x18\x03 \x01(\v2\a.ClientR\x06client\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\x04R\x05token\"$\n" +
+	"\fLockResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\x04R\x05token\"\xca\x01\n" +
+	"\x12LockStatusResponse\x12\x16\n" +
+	"\x06holder\x18\x01 \x01(\x04R\x06holder\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\x04R\x05token\x126\n" +
+	"\bacquired\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bacquired\x124\n" +
+	"\aexpires\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\aexpires\x12\x18\n" +
+	"\awaiters\x18\x05 \x03(\x04R\awaiters\"\xc4\x03\n" +
+	"\x0fMessageResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x16\n" +
+	"\x06locked\x18\x04 \x01(\bR\x06locked\x12\x1a\n" +
+	"\binstance\x18\x05 \x01(\tR\binstance\x12\x10\n" +
+	"\x03seq\x18\x06 \x01(\x04R\x03seq\x12\x1c\n" +
+	"\x03log\x18\b \x01(\v2\b.LogLineH\x00R\x03log\x12(\n" +
+	"\x05state\x18\t \x01(\v2\x10.StateTransitionH\x00R\x05state\x12!\n" +
+	"\x04lock\x18\n" +
+	" \x01(\v2\v.LockChangeH\x00R\x04lock\x12&\n" +
+	"\x06client\x18\v \x01(\v2\f.ClientEventH\x00R\x06client\x12 \n" +
+	"\x04hung\x18\f \x01(\v2\n" +
+	".HungEventH\x00R\x04hung\x121\n" +
+	"\fcrash_report\x18\r \x01(\v2\f.CrashReportH\x00R\vcrashReport\x12.\n" +
+	"\vhistory_gap\x18\x0e \x01(\v2\v.HistoryGapH\x00R\n" +
+	"historyGapB\a\n" +
+	"\x05eventJ\x04\b\a\x10\bR\x06missed\"$\n" +
+	"\n" +
+	"HistoryGap\x12\x16\n" +
+	"\x06missed\x18\x01 \x01(\x04R\x06missed\"R\n" +
+	"\x0eMessageRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1a\n" +
+	"\binstance\x18\x02 \x01(\tR\binstance\x12\x14\n" +
+	"\x05since\x18\x03 \x01(\x04R\x05since\"\xd0\x01\n" +
+	"\rLaunchProfile\x12\x12\n" +
+	"\x04java\x18\x01 \x01(\tR\x04java\x12\x19\n" +
+	"\bjvm_args\x18\x02 \x03(\tR\ajvmArgs\x12\x10\n" +
+	"\x03jar\x18\x03 \x01(\tR\x03jar\x12\x1b\n" +
+	"\targs_file\x18\x04 \x01(\tR\bargsFile\x12\x12\n" +
+	"\x04args\x18\x05 \x03(\tR\x04args\x12\x10\n" +
+	"\x03env\x18\x06 \x03(\tR\x03env\x12\x18\n" +
+	"\aworkdir\x18\a \x01(\tR\aworkdir\x12!\n" +
+	"\fstop_command\x18\b \x01(\tR\vstopCommand\"\x97\x01\n" +
+	"\fStartRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1f\n" +
+	"\x06client\x18\x02 \x01(\v2\a.ClientR\x06client\x12(\n" +
+	"\arestart\x18\x03 \x01(\x0e2\x0e.RestartPolicyR\arestart\x12(\n" +
+	"\aprofile\x18\x04 \x01(\v2\x0e.LaunchProfileR\aprofile\"4\n" +
+	"\x06Client\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1a\n" +
+	"\binstance\x18\x02 \x01(\tR\binstance\"W\n" +
+	"\x0eStatusResponse\x12%\n" +
+	"\x05state\x18\x01 \x01(\x0e2\x0f.MinecraftStateR\x05state\x12\x1e\n" +
+	"\n" +
+	"usedmemory\x18\x02 \x01(\x04R\n" +
+	"usedmemory\"k\n" +
+	"\x0eInstanceStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12%\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x0f.MinecraftStateR\x05state\x12\x1e\n" +
+	"\n" +
+	"usedmemory\x18\x03 \x01(\x04R\n" +
+	"usedmemory\"=\n" +
+	"\fInstanceList\x12-\n" +
+	"\tinstances\x18\x01 \x03(\v2\x0f.InstanceStatusR\tinstances\"h\n" +
+	"\x0eMetricsRequest\x12\x1f\n" +
+	"\x06client\x18\x01 \x01(\v2\a.ClientR\x06client\x125\n" +
+	"\binterval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\binterval\"\xea\x02\n" +
+	"\x0eProcessMetrics\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12%\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x0f.MinecraftStateR\x05state\x12\x10\n" +
+	"\x03pid\x18\x03 \x01(\x05R\x03pid\x12\x1f\n" +
+	"\vcpu_percent\x18\x04 \x01(\x01R\n" +
+	"cpuPercent\x12\x18\n" +
+	"\athreads\x18\x05 \x01(\x05R\athreads\x12\x1d\n" +
+	"\n" +
+	"open_files\x18\x06 \x01(\x05R\topenFiles\x12\x1d\n" +
+	"\n" +
+	"read_bytes\x18\a \x01(\x04R\treadBytes\x12\x1f\n" +
+	"\vwrite_bytes\x18\b \x01(\x04R\n" +
+	"writeBytes\x12\x10\n" +
+	"\x03rss\x18\t \x01(\x04R\x03rss\x12\x10\n" +
+	"\x03vms\x18\n" +
+	" \x01(\x04R\x03vms\x121\n" +
+	"\x06uptime\x18\v \x01(\v2\x19.google.protobuf.DurationR\x06uptime\"\xc3\x01\n" +
+	"\x0eReadLogRequest\x12\x1f\n" +
+	"\x06client\x18\x01 \x01(\v2\a.ClientR\x06client\x120\n" +
+	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\rR\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x05 \x01(\tR\x06cursor\"I\n" +
+	"\x0fReadLogResponse\x12\x1e\n" +
+	"\x05lines\x18\x01 \x03(\v2\b.LogLineR\x05lines\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\"\xaa\x01\n" +
+	"\vStopRequest\x12\x1f\n" +
+	"\x06client\x18\x01 \x01(\v2\a.ClientR\x06client\x12<\n" +
+	"\fterm_timeout\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\vtermTimeout\x12<\n" +
+	"\fkill_timeout\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\vkillTimeout\"t\n" +
+	"\fStopResponse\x12 \n" +
+	"\x05stage\x18\x01 \x01(\x0e2\n" +
+	".StopStageR\x05stage\x12\x1b\n" +
+	"\texit_code\x18\x02 \x01(\x05R\bexitCode\x12%\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x0f.MinecraftStateR\x05state**\n" +
+	"\x0eMinecraftState\x12\v\n" +
+	"\astopped\x10\x00\x12\v\n" +
+	"\arunning\x10\x01*K\n" +
+	"\rRestartPolicy\x12\x13\n" +
+	"\x0frestart_default\x10\x00\x12\t\n" +
+	"\x05never\x10\x01\x12\x0e\n" +
+	"\n" +
+	"on_failure\x10\x02\x12\n" +
+	"\n" +
+	"\x06always\x10\x03*#\n" +
+	"\tLogStream\x12\n" +
+	"\n" +
+	"\x06stdout\x10\x00\x12\n" +
+	"\n" +
+	"\x06stderr\x10\x01*\xa2\x01\n" +
+	"\vStateReason\x12\x11\n" +
+	"\rstate_unknown\x10\x00\x12\x12\n" +
+	"\x0eserver_started\x10\x01\x12\x12\n" +
+	"\x0eserver_stopped\x10\x02\x12\x12\n" +
+	"\x0eserver_crashed\x10\x03\x12\x15\n" +
+	"\x11server_restarting\x10\x04\x12\x19\n" +
+	"\x15restart_limit_reached\x10\x05\x12\x12\n" +
+	"\x0erestart_failed\x10\x06*`\n" +
+	"\tStopStage\x12\x16\n" +
+	"\x12stop_stage_unknown\x10\x00\x12\x10\n" +
+	"\fstop_command\x10\x01\x12\v\n" +
+	"\asigterm\x10\x02\x12\v\n" +
+	"\asigkill\x10\x03\x12\x0f\n" +
+	"\vnot_running\x10\x04*N\n" +
+	"\x0fCrashReportKind\x12\x11\n" +
+	"\rcrash_unknown\x10\x00\x12\x13\n" +
+	"\x0fminecraft_crash\x10\x01\x12\x13\n" +
+	"\x0fjvm_fatal_error\x10\x022\x95\x05\n" +
+	"\aManager\x12 \n" +
+	"\x04Lock\x12\a.Client\x1a\r.LockResponse\"\x00\x12+\n" +
+	"\x06Unlock\x12\a.Client\x1a\x16.google.protobuf.Empty\"\x00\x120\n" +
+	"\x05Write\x12\r.WriteRequest\x1a\x16.google.protobuf.Empty\"\x00\x120\n" +
+	"\aMessage\x12\x0f.MessageRequest\x1a\x10.MessageResponse\"\x000\x01\x12)\n" +
+	"\x05Start\x12\r.StartRequest\x1a\x0f.StatusResponse\"\x00\x12)\n" +
+	"\x04Stop\x12\a.Client\x1a\x16.google.protobuf.Empty\"\x00\x12)\n" +
+	"\bShutdown\x12\f.StopRequest\x1a\r.StopResponse\"\x00\x12 \n" +
+	"\x04Kill\x12\a.Client\x1a\r.StopResponse\"\x00\x12(\n" +
+	"\aRestart\x12\f.StopRequest\x1a\r.StopResponse\"\x00\x12$\n" +
+	"\x06Status\x12\a.Client\x1a\x0f.StatusResponse\"\x00\x12*\n" +
+	"\x05Login\x12\x16.google.protobuf.Empty\x1a\a.Client\"\x00\x12)\n" +
+	"\rListInstances\x12\a.Client\x1a\r.InstanceList\"\x00\x12,\n" +
+	"\n" +
+	"LockStatus\x12\a.Client\x1a\x13.LockStatusResponse\"\x00\x12/\n" +
+	"\aMetrics\x12\x0f.MetricsRequest\x1a\x0f.ProcessMetrics\"\x000\x01\x12.\n" +
+	"\aReadLog\x12\x0f.ReadLogRequest\x1a\x10.ReadLogResponse\"\x00B3Z1git.bbaa.fun/bbaa/minecraft-plugin-daemon/managerb\x06proto3"
 
 var (
 	file_core_manager_manager_proto_rawDescOnce sync.Once
-	file_core_manager_manager_proto_rawDescData = file_core_manager_manager_proto_rawDesc
+	file_core_manager_manager_proto_rawDescData []byte
 )
 
 func file_core_manager_manager_proto_rawDescGZIP() []byte {
 	file_core_manager_manager_proto_rawDescOnce.Do(func() {
-		file_core_manager_manager_proto_rawDescData = protoimpl.X.CompressGZIP(file_core_manager_manager_proto_rawDescData)
+		file_core_manager_manager_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_core_manager_manager_proto_rawDesc), len(file_core_manager_manager_proto_rawDesc)))
 	})
 	return file_core_manager_manager_proto_rawDescData
 }
 
-var file_core_manager_manager_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_core_manager_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
-var file_core_manager_manager_proto_goTypes = []interface{}{
-	(MinecraftState)(0),     // 0: MinecraftState
-	(*WriteRequest)(nil),    // 1: WriteRequest
-	(*MessageResponse)(nil), // 2: MessageResponse
-	(*StartRequest)(nil),    // 3: StartRequest
-	(*Client)(nil),          // 4: Client
-	(*StatusResponse)(nil),  // 5: StatusResponse
-	(*emptypb.Empty)(nil),   // 6: google.protobuf.Empty
+var file_core_manager_manager_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_core_manager_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_core_manager_manager_proto_goTypes = []any{
+	(MinecraftState)(0),           // 0: MinecraftState
+	(RestartPolicy)(0),            // 1: RestartPolicy
+	(LogStream)(0),                // 2: LogStream
+	(StateReason)(0),              // 3: StateReason
+	(StopStage)(0),                // 4: StopStage
+	(CrashReportKind)(0),          // 5: CrashReportKind
+	(*LogLine)(nil),               // 6: LogLine
+	(*StateTransition)(nil),       // 7: StateTransition
+	(*LockChange)(nil),            // 8: LockChange
+	(*ClientEvent)(nil),           // 9: ClientEvent
+	(*HungEvent)(nil),             // 10: HungEvent
+	(*CrashReport)(nil),           // 11: CrashReport
+	(*WriteRequest)(nil),          // 12: WriteRequest
+	(*LockResponse)(nil),          // 13: LockResponse
+	(*LockStatusResponse)(nil),    // 14: LockStatusResponse
+	(*MessageResponse)(nil),       // 15: MessageResponse
+	(*HistoryGap)(nil),            // 16: HistoryGap
+	(*MessageRequest)(nil),        // 17: MessageRequest
+	(*LaunchProfile)(nil),         // 18: LaunchProfile
+	(*StartRequest)(nil),          // 19: StartRequest
+	(*Client)(nil),                // 20: Client
+	(*StatusResponse)(nil),        // 21: StatusResponse
+	(*InstanceStatus)(nil),        // 22: InstanceStatus
+	(*InstanceList)(nil),          // 23: InstanceList
+	(*MetricsRequest)(nil),        // 24: MetricsRequest
+	(*ProcessMetrics)(nil),        // 25: ProcessMetrics
+	(*ReadLogRequest)(nil),        // 26: ReadLogRequest
+	(*ReadLogResponse)(nil),       // 27: ReadLogResponse
+	(*StopRequest)(nil),           // 28: StopRequest
+	(*StopResponse)(nil),          // 29: StopResponse
+	(*timestamppb.Timestamp)(nil), // 30: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 31: google.protobuf.Duration
+	(*emptypb.Empty)(nil),         // 32: google.protobuf.Empty
 }
 var file_core_manager_manager_proto_depIdxs = []int32{
-	4,  // 0: WriteRequest.client:type_name -> Client
-	4,  // 1: StartRequest.client:type_name -> Client
-	0,  // 2: StatusResponse.state:type_name -> MinecraftState
-	4,  // 3: Manager.Lock:input_type -> Client
-	4,  // 4: Manager.Unlock:input_type -> Client
-	1,  // 5: Manager.Write:input_type -> WriteRequest
-	4,  // 6: Manager.Message:input_type -> Client
-	3,  // 7: Manager.Start:input_type -> StartRequest
-	4,  // 8: Manager.Stop:input_type -> Client
-	4,  // 9: Manager.Status:input_type -> Client
-	6,  // 10: Manager.Login:input_type -> google.protobuf.Empty
-	6,  // 11: Manager.Lock:output_type -> google.protobuf.Empty
-	6,  // 12: Manager.Unlock:output_type -> google.protobuf.Empty
-	6,  // 13: Manager.Write:output_type -> google.protobuf.Empty
-	2,  // 14: Manager.Message:output_type -> MessageResponse
-	5,  // 15: Manager.Start:output_type -> StatusResponse
-	6,  // 16: Manager.Stop:output_type -> google.protobuf.Empty
-	5,  // 17: Manager.Status:output_type -> StatusResponse
-	4,  // 18: Manager.Login:output_type -> Client
-	11, // [11:19] is the sub-list for method output_type
-	3,  // [3:11] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	2,  // 0: LogLine.stream:type_name -> LogStream
+	30, // 1: LogLine.received:type_name -> google.protobuf.Timestamp
+	3,  // 2: StateTransition.reason:type_name -> StateReason
+	0,  // 3: StateTransition.old_state:type_name -> MinecraftState
+	0,  // 4: StateTransition.new_state:type_name -> MinecraftState
+	4,  // 5: StateTransition.stop_stage:type_name -> StopStage
+	31, // 6: HungEvent.silent:type_name -> google.protobuf.Duration
+	5,  // 7: CrashReport.kind:type_name -> CrashReportKind
+	30, // 8: CrashReport.time:type_name -> google.protobuf.Timestamp
+	20, // 9: WriteRequest.client:type_name -> Client
+	30, // 10: LockStatusResponse.acquired:type_name -> google.protobuf.Timestamp
+	30, // 11: LockStatusResponse.expires:type_name -> google.protobuf.Timestamp
+	6,  // 12: MessageResponse.log:type_name -> LogLine
+	7,  // 13: MessageResponse.state:type_name -> StateTransition
+	8,  // 14: MessageResponse.lock:type_name -> LockChange
+	9,  // 15: MessageResponse.client:type_name -> ClientEvent
+	10, // 16: MessageResponse.hung:type_name -> HungEvent
+	11, // 17: MessageResponse.crash_report:type_name -> CrashReport
+	16, // 18: MessageResponse.history_gap:type_name -> HistoryGap
+	20, // 19: StartRequest.client:type_name -> Client
+	1,  // 20: StartRequest.restart:type_name -> RestartPolicy
+	18, // 21: StartRequest.profile:type_name -> LaunchProfile
+	0,  // 22: StatusResponse.state:type_name -> MinecraftState
+	0,  // 23: InstanceStatus.state:type_name -> MinecraftState
+	22, // 24: InstanceList.instances:type_name -> InstanceStatus
+	20, // 25: MetricsRequest.client:type_name -> Client
+	31, // 26: MetricsRequest.interval:type_name -> google.protobuf.Duration
+	30, // 27: ProcessMetrics.time:type_name -> google.protobuf.Timestamp
+	0,  // 28: ProcessMetrics.state:type_name -> MinecraftState
+	31, // 29: ProcessMetrics.uptime:type_name -> google.protobuf.Duration
+	20, // 30: ReadLogRequest.client:type_name -> Client
+	30, // 31: ReadLogRequest.since:type_name -> google.protobuf.Timestamp
+	30, // 32: ReadLogRequest.until:type_name -> google.protobuf.Timestamp
+	6,  // 33: ReadLogResponse.lines:type_name -> LogLine
+	20, // 34: StopRequest.client:type_name -> Client
+	31, // 35: StopRequest.term_timeout:type_name -> google.protobuf.Duration
+	31, // 36: StopRequest.kill_timeout:type_name -> google.protobuf.Duration
+	4,  // 37: StopResponse.stage:type_name -> StopStage
+	0,  // 38: StopResponse.state:type_name -> MinecraftState
+	20, // 39: Manager.Lock:input_type -> Client
+	20, // 40: Manager.Unlock:input_type -> Client
+	12, // 41: Manager.Write:input_type -> WriteRequest
+	17, // 42: Manager.Message:input_type -> MessageRequest
+	19, // 43: Manager.Start:input_type -> StartRequest
+	20, // 44: Manager.Stop:input_type -> Client
+	28, // 45: Manager.Shutdown:input_type -> StopRequest
+	20, // 46: Manager.Kill:input_type -> Client
+	28, // 47: Manager.Restart:input_type -> StopRequest
+	20, // 48: Manager.Status:input_type -> Client
+	32, // 49: Manager.Login:input_type -> google.protobuf.Empty
+	20, // 50: Manager.ListInstances:input_type -> Client
+	20, // 51: Manager.LockStatus:input_type -> Client
+	24, // 52: Manager.Metrics:input_type -> MetricsRequest
+	26, // 53: Manager.ReadLog:input_type -> ReadLogRequest
+	13, // 54: Manager.Lock:output_type -> LockResponse
+	32, // 55: Manager.Unlock:output_type -> google.protobuf.Empty
+	32, // 56: Manager.Write:output_type -> google.protobuf.Empty
+	15, // 57: Manager.Message:output_type -> MessageResponse
+	21, // 58: Manager.Start:output_type -> StatusResponse
+	32, // 59: Manager.Stop:output_type -> google.protobuf.Empty
+	29, // 60: Manager.Shutdown:output_type -> StopResponse
+	29, // 61: Manager.Kill:output_type -> StopResponse
+	29, // 62: Manager.Restart:output_type -> StopResponse
+	21, // 63: Manager.Status:output_type -> StatusResponse
+	20, // 64: Manager.Login:output_type -> Client
+	23, // 65: Manager.ListInstances:output_type -> InstanceList
+	14, // 66: Manager.LockStatus:output_type -> LockStatusResponse
+	25, // 67: Manager.Metrics:output_type -> ProcessMetrics
+	27, // 68: Manager.ReadLog:output_type -> ReadLogResponse
+	54, // [54:69] is the sub-list for method output_type
+	39, // [39:54] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
 }
 
 func init() { file_core_manager_manager_proto_init() }
@@ -489,75 +2435,22 @@ func file_core_manager_manager_proto_init() {
 	if File_core_manager_manager_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_core_manager_manager_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WriteRequest); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_core_manager_manager_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MessageResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_core_manager_manager_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StartRequest); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_core_manager_manager_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Client); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_core_manager_manager_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StatusResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
+	file_core_manager_manager_proto_msgTypes[9].OneofWrappers = []any{
+		(*MessageResponse_Log)(nil),
+		(*MessageResponse_State)(nil),
+		(*MessageResponse_Lock)(nil),
+		(*MessageResponse_Client)(nil),
+		(*MessageResponse_Hung)(nil),
+		(*MessageResponse_CrashReport)(nil),
+		(*MessageResponse_HistoryGap)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_core_manager_manager_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   5,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_core_manager_manager_proto_rawDesc), len(file_core_manager_manager_proto_rawDesc)),
+			NumEnums:      6,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -567,7 +2460,6 @@ func file_core_manager_manager_proto_init() {
 		MessageInfos:      file_core_manager_manager_proto_msgTypes,
 	}.Build()
 	File_core_manager_manager_proto = out.File
-	file_core_manager_manager_proto_rawDesc = nil
 	file_core_manager_manager_proto_goTypes = nil
 	file_core_manager_manager_proto_depIdxs = nil
 }
@@ -23,14 +23,27 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ManagerClient interface {
-	Lock(ctx context.Context, in *Client, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// blocks until the lock is granted in request order, calling it again while
+	// holding the lock renews the lease
+	Lock(ctx context.Context, in *Client, opts ...grpc.CallOption) (*LockResponse, error)
 	Unlock(ctx context.Context, in *Client, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
-	Message(ctx context.Context, in *Client, opts ...grpc.CallOption) (Manager_MessageClient, error)
+	Message(ctx context.Context, in *MessageRequest, opts ...grpc.CallOption) (Manager_MessageClient, error)
 	Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	// Shutdown with the default timeouts
 	Stop(ctx context.Context, in *Client, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// stop command, then SIGTERM, then SIGKILL to the process group
+	Shutdown(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error)
+	// SIGKILL to the process group right away
+	Kill(ctx context.Context, in *Client, opts ...grpc.CallOption) (*StopResponse, error)
+	// Shutdown followed by a Start with the last launch settings
+	Restart(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error)
 	Status(ctx context.Context, in *Client, opts ...grpc.CallOption) (*StatusResponse, error)
 	Login(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Client, error)
+	ListInstances(ctx context.Context, in *Client, opts ...grpc.CallOption) (*InstanceList, error)
+	LockStatus(ctx context.Context, in *Client, opts ...grpc.CallOption) (*LockStatusResponse, error)
+	Metrics(ctx context.Context, in *MetricsRequest, opts ...grpc.CallOption) (Manager_MetricsClient, error)
+	ReadLog(ctx context.Context, in *ReadLogRequest, opts ...grpc.CallOption) (*ReadLogResponse, error)
 }
 
 type managerClient struct {
@@ -41,8 +54,8 @@ func NewManagerClient(cc grpc.ClientConnInterface) ManagerClient {
 	return &managerClient{cc}
 }
 
-func (c *managerClient) Lock(ctx context.Context, in *Client, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	out := new(emptypb.Empty)
+func (c *managerClient) Lock(ctx context.Context, in *Client, opts ...grpc.CallOption) (*LockResponse, error) {
+	out := new(LockResponse)
 	err := c.cc.Invoke(ctx, "/Manager/Lock", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -68,7 +81,7 @@ func (c *managerClient) Write(ctx context.Context, in *WriteRequest, opts ...grp
 	return out, nil
 }
 
-func (c *managerClient) Message(ctx context.Context, in *Client, opts ...grpc.CallOption) (Manager_MessageClient, error) {
+func (c *managerClient) Message(ctx context.Context, in *MessageRequest, opts ...grpc.CallOption) (Manager_MessageClient, error) {
 	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[0], "/Manager/Message", opts...)
 	if err != nil {
 		return nil, err
@@ -118,6 +131,33 @@ func (c *managerClient) Stop(ctx context.Context, in *Client, opts ...grpc.CallO
 	return out, nil
 }
 
+func (c *managerClient) Shutdown(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error) {
+	out := new(StopResponse)
+	err := c.cc.Invoke(ctx, "/Manager/Shutdown", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) Kill(ctx context.Context, in *Client, opts ...grpc.CallOption) (*StopResponse, error) {
+	out := new(StopResponse)
+	err := c.cc.Invoke(ctx, "/Manager/Kill", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) Restart(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopResponse, error) {
+	out := new(StopResponse)
+	err := c.cc.Invoke(ctx, "/Manager/Restart", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) Status(ctx context.Context, in *Client, opts ...grpc.CallOption) (*StatusResponse, error) {
 	out := new(StatusResponse)
 	err := c.cc.Invoke(ctx, "/Manager/Status", in, out, opts...)
@@ -136,18 +176,90 @@ func (c *managerClient) Login(ctx context.Context, in *emptypb.Empty, opts ...gr
 	return out, nil
 }
 
+func (c *managerClient) ListInstances(ctx context.Context, in *Client, opts ...grpc.CallOption) (*InstanceList, error) {
+	out := new(InstanceList)
+	err := c.cc.Invoke(ctx, "/Manager/ListInstances", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) LockStatus(ctx context.Context, in *Client, opts ...grpc.CallOption) (*LockStatusResponse, error) {
+	out := new(LockStatusResponse)
+	err := c.cc.Invoke(ctx, "/Manager/LockStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) Metrics(ctx context.Context, in *MetricsRequest, opts ...grpc.CallOption) (Manager_MetricsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[1], "/Manager/Metrics", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerMetricsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_MetricsClient interface {
+	Recv() (*ProcessMetrics, error)
+	grpc.ClientStream
+}
+
+type managerMetricsClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerMetricsClient) Recv() (*ProcessMetrics, error) {
+	m := new(ProcessMetrics)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) ReadLog(ctx context.Context, in *ReadLogRequest, opts ...grpc.CallOption) (*ReadLogResponse, error) {
+	out := new(ReadLogResponse)
+	err := c.cc.Invoke(ctx, "/Manager/ReadLog", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ManagerServer is the server API for Manager service.
 // All implementations must embed UnimplementedManagerServer
 // for forward compatibility
 type ManagerServer interface {
-	Lock(context.Context, *Client) (*emptypb.Empty, error)
+	// blocks until the lock is granted in request order, calling it again while
+	// holding the lock renews the lease
+	Lock(context.Context, *Client) (*LockResponse, error)
 	Unlock(context.Context, *Client) (*emptypb.Empty, error)
 	Write(context.Context, *WriteRequest) (*emptypb.Empty, error)
-	Message(*Client, Manager_MessageServer) error
+	Message(*MessageRequest, Manager_MessageServer) error
 	Start(context.Context, *StartRequest) (*StatusResponse, error)
+	// Shutdown with the default timeouts
 	Stop(context.Context, *Client) (*emptypb.Empty, error)
+	// stop command, then SIGTERM, then SIGKILL to the process group
+	Shutdown(context.Context, *StopRequest) (*StopResponse, error)
+	// SIGKILL to the process group right away
+	Kill(context.Context, *Client) (*StopResponse, error)
+	// Shutdown followed by a Start with the last launch settings
+	Restart(context.Context, *StopRequest) (*StopResponse, error)
 	Status(context.Context, *Client) (*StatusResponse, error)
 	Login(context.Context, *emptypb.Empty) (*Client, error)
+	ListInstances(context.Context, *Client) (*InstanceList, error)
+	LockStatus(context.Context, *Client) (*LockStatusResponse, error)
+	Metrics(*MetricsRequest, Manager_MetricsServer) error
+	ReadLog(context.Context, *ReadLogRequest) (*ReadLogResponse, error)
 	mustEmbedUnimplementedManagerServer()
 }
 
@@ -155,7 +267,7 @@ type ManagerServer interface {
 type UnimplementedManagerServer struct {
 }
 
-func (UnimplementedManagerServer) Lock(context.Context, *Client) (*emptypb.Empty, error) {
+func (UnimplementedManagerServer) Lock(context.Context, *Client) (*LockResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Lock not implemented")
 }
 func (UnimplementedManagerServer) Unlock(context.Context, *Client) (*emptypb.Empty, error) {
@@ -164,7 +276,7 @@ func (UnimplementedManagerServer) Unlock(context.Context, *Client) (*emptypb.Emp
 func (UnimplementedManagerServer) Write(context.Context, *WriteRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Write not implemented")
 }
-func (UnimplementedManagerServer) Message(*Client, Manager_MessageServer) error {
+func (UnimplementedManagerServer) Message(*MessageRequest, Manager_MessageServer) error {
 	return status.Errorf(codes.Unimplemented, "method Message not implemented")
 }
 func (UnimplementedManagerServer) Start(context.Context, *StartRequest) (*StatusResponse, error) {
@@ -173,12 +285,33 @@ func (UnimplementedManagerServer) Start(context.Context, *StartRequest) (*Status
 func (UnimplementedManagerServer) Stop(context.Context, *Client) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
 }
+func (UnimplementedManagerServer) Shutdown(context.Context, *StopRequest) (*StopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Shutdown not implemented")
+}
+func (UnimplementedManagerServer) Kill(context.Context, *Client) (*StopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Kill not implemented")
+}
+func (UnimplementedManagerServer) Restart(context.Context, *StopRequest) (*StopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restart not implemented")
+}
 func (UnimplementedManagerServer) Status(context.Context, *Client) (*StatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
 }
 func (UnimplementedManagerServer) Login(context.Context, *emptypb.Empty) (*Client, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
 }
+func (UnimplementedManagerServer) ListInstances(context.Context, *Client) (*InstanceList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInstances not implemented")
+}
+func (UnimplementedManagerServer) LockStatus(context.Context, *Client) (*LockStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockStatus not implemented")
+}
+func (UnimplementedManagerServer) Metrics(*MetricsRequest, Manager_MetricsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Metrics not implemented")
+}
+func (UnimplementedManagerServer) ReadLog(context.Context, *ReadLogRequest) (*ReadLogResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadLog not implemented")
+}
 func (UnimplementedManagerServer) mustEmbedUnimplementedManagerServer() {}
 
 // UnsafeManagerServer may be embedded to opt out of forward compatibility for this service.
@@ -247,7 +380,7 @@ func _Manager_Write_Handler(srv interface{}, ctx context.Context, dec func(inter
 }
 
 func _Manager_Message_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(Client)
+	m := new(MessageRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
@@ -303,6 +436,60 @@ func _Manager_Stop_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_Shutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).Shutdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Manager/Shutdown",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).Shutdown(ctx, req.(*StopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_Kill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Client)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).Kill(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Manager/Kill",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).Kill(ctx, req.(*Client))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_Restart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).Restart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Manager/Restart",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).Restart(ctx, req.(*StopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Client)
 	if err := dec(in); err != nil {
@@ -339,6 +526,81 @@ func _Manager_Login_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_ListInstances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Client)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ListInstances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Manager/ListInstances",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ListInstances(ctx, req.(*Client))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_LockStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Client)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).LockStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Manager/LockStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).LockStatus(ctx, req.(*Client))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_Metrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MetricsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).Metrics(m, &managerMetricsServer{stream})
+}
+
+type Manager_MetricsServer interface {
+	Send(*ProcessMetrics) error
+	grpc.ServerStream
+}
+
+type managerMetricsServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerMetricsServer) Send(m *ProcessMetrics) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Manager_ReadLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ReadLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Manager/ReadLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ReadLog(ctx, req.(*ReadLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Manager_ServiceDesc is the grpc.ServiceDesc for Manager service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -366,6 +628,18 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Stop",
 			Handler:    _Manager_Stop_Handler,
 		},
+		{
+			MethodName: "Shutdown",
+			Handler:    _Manager_Shutdown_Handler,
+		},
+		{
+			MethodName: "Kill",
+			Handler:    _Manager_Kill_Handler,
+		},
+		{
+			MethodName: "Restart",
+			Handler:    _Manager_Restart_Handler,
+		},
 		{
 			MethodName: "Status",
 			Handler:    _Manager_Status_Handler,
@@ -374,6 +648,18 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Login",
 			Handler:    _Manager_Login_Handler,
 		},
+		{
+			MethodName: "ListInstances",
+			Handler:    _Manager_ListInstances_Handler,
+		},
+		{
+			MethodName: "LockStatus",
+			Handler:    _Manager_LockStatus_Handler,
+		},
+		{
+			MethodName: "ReadLog",
+			Handler:    _Manager_ReadLog_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -381,6 +667,11 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Manager_Message_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Metrics",
+			Handler:       _Manager_Metrics_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "core/manager/manager.proto",
 }
@@ -0,0 +1,204 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const TokenMetadataKey = "x-manager-token"
+
+const DefaultAddress = "localhost:12345"
+
+// TransportConfig describes how the Manager service is exposed and reached.
+//
+// Address is either "host:port" (optionally prefixed with "tcp://") or a unix
+// domain socket written as "unix:/path" or "unix:///path". When TLSCert and
+// TLSKey are set the connection is TLS, and TLSCA additionally turns on mutual
+// authentication (server side) or replaces the system roots (client side).
+type TransportConfig struct {
+	Address       string
+	SocketMode    fs.FileMode // unix socket permission, 0 keeps 0600
+	Token         string
+	TLSCert       string
+	TLSKey        string
+	TLSCA         string
+	TLSServerName string
+}
+
+var ErrInvalidToken = status.Error(codes.Unauthenticated, "invalid manager token")
+
+func (tc *TransportConfig) isUnix() bool {
+	return strings.HasPrefix(tc.Address, "unix:")
+}
+
+func (tc *TransportConfig) unixPath() string {
+	path := strings.TrimPrefix(tc.Address, "unix:")
+	if strings.HasPrefix(path, "//") {
+		path = strings.TrimPrefix(path, "//")
+	}
+	return path
+}
+
+func (tc *TransportConfig) tcpAddress() string {
+	if tc.Address == "" {
+		return DefaultAddress
+	}
+	return strings.TrimPrefix(tc.Address, "tcp://")
+}
+
+// Target returns the address in the form accepted by grpc.NewClient.
+func (tc *TransportConfig) Target() string {
+	if tc.isUnix() {
+		return "unix://" + tc.unixPath()
+	}
+	return tc.tcpAddress()
+}
+
+func (tc *TransportConfig) Listen() (net.Listener, error) {
+	if !tc.isUnix() {
+		return net.Listen("tcp", tc.tcpAddress())
+	}
+	path := tc.unixPath()
+	if stat, err := os.Stat(path); err == nil {
+		if stat.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	mode := tc.SocketMode
+	if mode == 0 {
+		mode = 0600
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func (tc *TransportConfig) loadCA() (*x509.CertPool, error) {
+	caPem, err := os.ReadFile(tc.TLSCA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPem) {
+		return nil, fmt.Errorf("no certificate found in %s", tc.TLSCA)
+	}
+	return pool, nil
+}
+
+func (tc *TransportConfig) ServerOptions() (opts []grpc.ServerOption, err error) {
+	if tc.TLSCert != "" || tc.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(tc.TLSCert, tc.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		if tc.TLSCA != "" {
+			tlsConfig.ClientCAs, err = tc.loadCA()
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if tc.Token != "" {
+		opts = append(opts, grpc.ChainUnaryInterceptor(tc.unaryServerInterceptor), grpc.ChainStreamInterceptor(tc.streamServerInterceptor))
+	}
+	return opts, nil
+}
+
+func (tc *TransportConfig) DialOptions() (opts []grpc.DialOption, err error) {
+	if tc.TLSCert != "" || tc.TLSCA != "" {
+		tlsConfig := &tls.Config{ServerName: tc.TLSServerName, MinVersion: tls.VersionTLS12}
+		if tc.TLSCert != "" {
+			cert, err := tls.LoadX509KeyPair(tc.TLSCert, tc.TLSKey)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		if tc.TLSCA != "" {
+			tlsConfig.RootCAs, err = tc.loadCA()
+			if err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if tc.Token != "" {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(tc.unaryClientInterceptor), grpc.WithChainStreamInterceptor(tc.streamClientInterceptor))
+	}
+	return opts, nil
+}
+
+func (tc *TransportConfig) checkToken(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ErrInvalidToken
+	}
+	for _, token := range md.Get(TokenMetadataKey) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(tc.Token)) == 1 {
+			return nil
+		}
+	}
+	return ErrInvalidToken
+}
+
+func (tc *TransportConfig) unaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := tc.checkToken(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (tc *TransportConfig) streamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := tc.checkToken(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (tc *TransportConfig) unaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, TokenMetadataKey, tc.Token), method, req, reply, cc, opts...)
+}
+
+func (tc *TransportConfig) streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(metadata.AppendToOutgoingContext(ctx, TokenMetadataKey, tc.Token), desc, cc, method, opts...)
+}
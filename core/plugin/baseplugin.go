@@ -15,6 +15,7 @@
 package plugin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -32,17 +33,27 @@ type BasePlugin struct {
 	simpleCommand  *SimpleCommand
 	scoreboardCore *ScoreboardCore
 	tellrawManager *TellrawManager
+	priority       pluginabi.CommandPriority
 }
 
 // downstream plugins should implement this interface like
 //
 //	func (p *Plugin) Depends() []string {
-//	 return append(p.BasePlugin.Depends(), []string{"PluginName","AAA"}...)
+//	 return append(p.BasePlugin.Depends(), []string{"PluginName", "AAA >=1.2", "?Optional"}...)
 //	}
+//
+// see pluginabi.Dependency for the syntax. The core plugins listed here
+// override it with the ones they use themselves.
 func (bp *BasePlugin) Depends() []string {
 	return []string{"PlayerInfo", "ScoreboardCore", "TellrawManager", "TeleportCore", "SimpleCommand"}
 }
 
+// Version is reported to the plugins depending on this one, override it to
+// let them put a constraint on it.
+func (bp *BasePlugin) Version() string {
+	return "1.0.0"
+}
+
 func (bp *BasePlugin) Println(a ...any) (int, error) {
 	return bp.pm.Println(color.BlueString(bp.p.DisplayName()), a...)
 }
@@ -155,6 +166,32 @@ func (bp *BasePlugin) RegisterLogProcesser(processer func(logmsg string, iscomma
 	return bp.pm.RegisterLogProcesser(bp.p, processer)
 }
 
+// TrackResource calls release when the plugin is unloaded, for what the
+// plugin set up itself.
+func (bp *BasePlugin) TrackResource(kind string, name string, release func()) {
+	if bp.pm == nil {
+		return
+	}
+	bp.pm.TrackResource(bp.p, kind, name, release)
+}
+
+// Context is cancelled when the plugin is unloaded.
+func (bp *BasePlugin) Context() context.Context {
+	if bp.pm == nil {
+		return context.Background()
+	}
+	return bp.pm.PluginContext(bp.p)
+}
+
+// Go runs worker in the background, it has to return once ctx is done.
+func (bp *BasePlugin) Go(worker func(ctx context.Context)) {
+	if bp.pm == nil {
+		go worker(context.Background())
+		return
+	}
+	bp.pm.Go(bp.p, worker)
+}
+
 func (bp *BasePlugin) GetPlayerInfo_Position(player string) (*MinecraftPlayerInfo, error) {
 	if bp.playerInfo == nil {
 		return nil, fmt.Errorf("no playerInfo instance")
@@ -177,7 +214,38 @@ func (bp *BasePlugin) GetPlayerList() []string {
 }
 
 func (bp *BasePlugin) RunCommand(command string) string {
-	return bp.pm.RunCommand(command)
+	response, _ := bp.RunCommandContext(context.Background(), command)
+	return response
+}
+
+// RunCommandContext runs command in the plugin's lane unless ctx carries a
+// priority of its own.
+func (bp *BasePlugin) RunCommandContext(ctx context.Context, command string) (string, error) {
+	return bp.pm.RunCommandContext(bp.commandContext(ctx), command)
+}
+
+func (bp *BasePlugin) RunCommands(commands []string) []pluginabi.CommandResult {
+	return bp.RunCommandsContext(context.Background(), commands)
+}
+
+func (bp *BasePlugin) RunCommandsContext(ctx context.Context, commands []string) []pluginabi.CommandResult {
+	return bp.pm.RunCommandsContext(bp.commandContext(ctx), commands)
+}
+
+func (bp *BasePlugin) commandContext(ctx context.Context) context.Context {
+	if pluginabi.CommandSourceFrom(ctx) == "" {
+		ctx = pluginabi.WithCommandSource(ctx, bp.p.Name())
+	}
+	if _, ok := pluginabi.CommandPriorityFrom(ctx); !ok {
+		ctx = pluginabi.WithCommandPriority(ctx, bp.priority)
+	}
+	return ctx
+}
+
+// SetCommandPriority sets the lane of the plugin's commands, normal by
+// default.
+func (bp *BasePlugin) SetCommandPriority(priority pluginabi.CommandPriority) {
+	bp.priority = priority
 }
 
 func (bp *BasePlugin) Tellraw(Target string, msg []tellraw.Message) {
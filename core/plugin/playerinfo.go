@@ -22,7 +22,6 @@ import (
 	"io"
 	"os"
 	"reflect"
-	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -160,15 +159,14 @@ type PlayerInfo struct {
 	data           *PlayerInfo_Storage
 }
 
-var PlayerEnterLeaveMessage = regexp.MustCompile(`(left|joined) the game`)
-
 func (pi *PlayerInfo) Init(pm pluginabi.PluginManager) (err error) {
 	err = pi.BasePlugin.Init(pm, pi)
 	if err != nil {
 		return err
 	}
 	pi.data = &PlayerInfo_Storage{PlayerInfo: map[string]*MinecraftPlayerInfo{}, UUIDMap: map[string]string{}}
-	pi.RegisterLogProcesser(pi.playerJoinLeaveEvent)
+	pluginabi.Subscribe(pm, pi, pi.playerJoined)
+	pluginabi.Subscribe(pm, pi, pi.playerLeft)
 	err = pi.Load()
 	if err != nil {
 		pi.Println(color.RedString("加载存储的玩家数据失败"))
@@ -176,12 +174,22 @@ func (pi *PlayerInfo) Init(pm pluginabi.PluginManager) (err error) {
 	return nil
 }
 
-func (pi *PlayerInfo) playerJoinLeaveEvent(log string, _ bool) {
-	if PlayerEnterLeaveMessage.MatchString(log) {
-		pi.updatePlayerList()
+func (pi *PlayerInfo) playerJoined(event pluginabi.PlayerJoined) {
+	pi.playerListLock.Lock()
+	defer pi.playerListLock.Unlock()
+	if !slices.Contains(pi.playerList, event.Player) {
+		pi.playerList = append(pi.playerList, event.Player)
 	}
 }
 
+func (pi *PlayerInfo) playerLeft(event pluginabi.PlayerLeft) {
+	pi.playerListLock.Lock()
+	defer pi.playerListLock.Unlock()
+	pi.playerList = slices.DeleteFunc(pi.playerList, func(player string) bool {
+		return player == event.Player
+	})
+}
+
 func (pi *PlayerInfo) convertUUID(rawData []int32) (uuid string, err error) {
 	if len(rawData) != 4 {
 		return "", fmt.Errorf("parse UUID 失败")
@@ -408,6 +416,10 @@ func (pi *PlayerInfo) Start() {
 func (pi *PlayerInfo) Pause() {
 }
 
+func (pi *PlayerInfo) Depends() []string {
+	return nil
+}
+
 func (pi *PlayerInfo) Name() string {
 	return "PlayerInfo"
 }
@@ -0,0 +1,205 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginabi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidVersion    = fmt.Errorf("invalid version")
+	ErrInvalidDependency = fmt.Errorf("invalid dependency")
+)
+
+// Dependency is one entry of Plugin.Depends, written as
+//
+//	"PlayerInfo"              required, any version
+//	"PlayerInfo >=1.2, <2"    required, with a version constraint
+//	"?StatusPlugin >=1.0"     optional, only orders the init when it is there
+type Dependency struct {
+	Name       string
+	Optional   bool
+	Constraint VersionConstraint // empty matches every version
+}
+
+func ParseDependency(spec string) (dep Dependency, err error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "?") {
+		dep.Optional = true
+		spec = strings.TrimSpace(spec[1:])
+	}
+	name, constraint, _ := strings.Cut(spec, " ")
+	if name == "" {
+		return dep, fmt.Errorf("%w: %q", ErrInvalidDependency, spec)
+	}
+	dep.Name = name
+	dep.Constraint, err = ParseVersionConstraint(constraint)
+	if err != nil {
+		return dep, fmt.Errorf("%w: %s: %w", ErrInvalidDependency, name, err)
+	}
+	return dep, nil
+}
+
+func (d Dependency) String() string {
+	s := d.Name
+	if d.Optional {
+		s = "?" + s
+	}
+	if len(d.Constraint) > 0 {
+		s += " " + d.Constraint.String()
+	}
+	return s
+}
+
+// Version is a dotted version like 1.2.0, a -suffix marks a pre-release that
+// sorts before the release and a +suffix is ignored.
+type Version struct {
+	Parts      []int
+	Prerelease string
+}
+
+func ParseVersion(s string) (v Version, err error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, v.Prerelease, _ = strings.Cut(s, "-")
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+		}
+		v.Parts = append(v.Parts, n)
+	}
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1, missing parts count as 0.
+func (v Version) Compare(o Version) int {
+	for i := 0; i < max(len(v.Parts), len(o.Parts)); i++ {
+		a, b := 0, 0
+		if i < len(v.Parts) {
+			a = v.Parts[i]
+		}
+		if i < len(o.Parts) {
+			b = o.Parts[i]
+		}
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+	return strings.Compare(v.Prerelease, o.Prerelease)
+}
+
+func (v Version) String() string {
+	parts := make([]string, len(v.Parts))
+	for i, n := range v.Parts {
+		parts[i] = strconv.Itoa(n)
+	}
+	s := strings.Join(parts, ".")
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+type versionCondition struct {
+	op      string
+	version Version
+}
+
+// VersionConstraint is a comma separated list of conditions that all have to
+// hold, each one of >=, >, <=, <, = or != and a version. A bare version means =.
+type VersionConstraint []versionCondition
+
+var versionOperators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+func ParseVersionConstraint(s string) (c VersionConstraint, err error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	for _, condition := range strings.Split(s, ",") {
+		condition = strings.TrimSpace(condition)
+		op := "="
+		for _, candidate := range versionOperators {
+			if strings.HasPrefix(condition, candidate) {
+				op, condition = candidate, condition[len(candidate):]
+				break
+			}
+		}
+		if op == "==" {
+			op = "="
+		}
+		version, err := ParseVersion(condition)
+		if err != nil {
+			return nil, err
+		}
+		c = append(c, versionCondition{op: op, version: version})
+	}
+	return c, nil
+}
+
+// Check reports whether version satisfies every condition, a version that
+// does not parse satisfies none.
+func (c VersionConstraint) Check(version string) bool {
+	if len(c) == 0 {
+		return true
+	}
+	v, err := ParseVersion(version)
+	if err != nil {
+		return false
+	}
+	for _, condition := range c {
+		cmp := v.Compare(condition.version)
+		var ok bool
+		switch condition.op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c VersionConstraint) String() string {
+	conditions := make([]string, len(c))
+	for i, condition := range c {
+		conditions[i] = condition.op + condition.version.String()
+	}
+	return strings.Join(conditions, ", ")
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginabi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVersionCompare(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.2", "1.2.0", 0},
+		{"v1.2.0", "1.2.0", 0},
+		{"1.2.0+build.5", "1.2.0", 0},
+		{"1.10", "1.9", 1},
+		{"1.2.0-beta", "1.2.0", -1},
+		{"1.2.0-alpha", "1.2.0-beta", -1},
+		{"2", "1.99.99", 1},
+	} {
+		a, err := ParseVersion(test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseVersion(test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Compare(b); got != test.want {
+			t.Errorf("%s vs %s = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+	for _, invalid := range []string{"", "1..2", "1.x", "-1", "1.-2"} {
+		if _, err := ParseVersion(invalid); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("ParseVersion(%q) = %v, want %v", invalid, err, ErrInvalidVersion)
+		}
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	for _, test := range []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"", "anything", true},
+		{"1.2", "1.2.0", true},
+		{"==1.2", "1.2.1", false},
+		{">=1.2, <2", "1.9.9", true},
+		{">=1.2, <2", "2.0.0", false},
+		{">=1.2, <2", "1.1", false},
+		{">1.2", "1.2.0", false},
+		{"<=1.2", "1.2.0-rc1", true},
+		{"!=1.3", "1.3.0", false},
+		{">=1.0", "broken", false},
+	} {
+		c, err := ParseVersionConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("ParseVersionConstraint(%q): %v", test.constraint, err)
+		}
+		if got := c.Check(test.version); got != test.want {
+			t.Errorf("%q.Check(%q) = %v, want %v", test.constraint, test.version, got, test.want)
+		}
+	}
+}
+
+func TestParseDependency(t *testing.T) {
+	for _, test := range []struct {
+		spec string
+		want string
+		err  error
+	}{
+		{"PlayerInfo", "PlayerInfo", nil},
+		{" ?StatusPlugin >=1.0 ", "?StatusPlugin >=1.0", nil},
+		{"PlayerInfo >=1.2,<2", "PlayerInfo >=1.2, <2", nil},
+		{"PlayerInfo ==1.2", "PlayerInfo =1.2", nil},
+		{"?", "", ErrInvalidDependency},
+		{"PlayerInfo >=x", "", ErrInvalidDependency},
+	} {
+		dep, err := ParseDependency(test.spec)
+		if !errors.Is(err, test.err) {
+			t.Errorf("ParseDependency(%q) = %v, want %v", test.spec, err, test.err)
+			continue
+		}
+		if err == nil && dep.String() != test.want {
+			t.Errorf("ParseDependency(%q) = %q, want %q", test.spec, dep.String(), test.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginabi
+
+import "time"
+
+// GameEvent is something that happened in the game. The plugin manager parses
+// every server log line once and passes the events to the subscribed handlers.
+type GameEvent interface {
+	EventName() string
+}
+
+// EventInfo is embedded in every event.
+type EventInfo struct {
+	Time time.Time
+	Line string // the raw log line
+}
+
+type PlayerJoined struct {
+	EventInfo
+	Player string
+}
+
+type PlayerLeft struct {
+	EventInfo
+	Player string
+}
+
+type ChatMessage struct {
+	EventInfo
+	Player  string
+	Message string
+}
+
+type PlayerDied struct {
+	EventInfo
+	Player  string
+	Message string // the death message without the player name
+	Key     string // translation key of the death message, e.g. death.attack.mob
+	Killer  string // empty if the message names none
+	Item    string
+}
+
+// AdvancementMade is any of the advancement broadcasts, Kind is task,
+// challenge or goal.
+type AdvancementMade struct {
+	EventInfo
+	Player      string
+	Advancement string
+	Kind        string
+}
+
+// TriggerFired is a player running /trigger, Value is the amount added or,
+// when Set is true, the new value.
+type TriggerFired struct {
+	EventInfo
+	Player    string
+	Objective string
+	Value     int
+	Set       bool
+}
+
+type ServerStarted struct {
+	EventInfo
+	Took time.Duration
+}
+
+type ServerStopping struct {
+	EventInfo
+}
+
+// CommandIssued is a player command logged by the server, only Bukkit based
+// servers log them.
+type CommandIssued struct {
+	EventInfo
+	Player  string
+	Command string // without the leading slash
+}
+
+func (PlayerJoined) EventName() string    { return "PlayerJoined" }
+func (PlayerLeft) EventName() string      { return "PlayerLeft" }
+func (ChatMessage) EventName() string     { return "ChatMessage" }
+func (PlayerDied) EventName() string      { return "PlayerDied" }
+func (AdvancementMade) EventName() string { return "AdvancementMade" }
+func (TriggerFired) EventName() string    { return "TriggerFired" }
+func (ServerStarted) EventName() string   { return "ServerStarted" }
+func (ServerStopping) EventName() string  { return "ServerStopping" }
+func (CommandIssued) EventName() string   { return "CommandIssued" }
+
+// Subscribe registers a typed handler on pm, like
+//
+//	pluginabi.Subscribe(pm, p, func(e pluginabi.PlayerDied) { ... })
+//
+// Each handler gets its events in order on a goroutine of its own.
+func Subscribe[E GameEvent](pm PluginManager, context PluginName, handler func(E)) (unsubscribe func()) {
+	var event E
+	return pm.RegisterEventHandler(context, event.EventName(), func(e GameEvent) {
+		handler(e.(E))
+	})
+}
@@ -15,6 +15,10 @@
 package pluginabi
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -25,7 +29,25 @@ type Plugin interface {
 	Init(PluginManager) error
 	Start()
 	Pause()
-	Depends() []string // only for plugin init, not for runtime, cyclic dependency is allowed
+	// Depends returns Dependency specs, the plugin is initialised after the
+	// plugins they name. A cycle of required dependencies blocks every plugin
+	// in it, a cycle through an optional one is broken there.
+	//
+	//	spec       = ["?"] name [" " constraint]   "?" marks it optional
+	//	constraint = condition {"," condition}     all have to hold
+	//	condition  = [op] version                  no op means "="
+	//	op         = ">=" | ">" | "<=" | "<" | "=" | "==" | "!="
+	Depends() []string
+	// Version is checked against the constraints of the plugins that depend
+	// on this one, see Version.
+	//
+	//	version = ["v"] number {"." number} ["-" prerelease] ["+" build]
+	//
+	// Missing parts count as 0, so 1.2 equals 1.2.0. A pre-release sorts
+	// before its release and against another one by plain string order, the
+	// build is ignored. A version that does not parse satisfies no
+	// constraint.
+	Version() string
 }
 
 type PluginName interface {
@@ -49,18 +71,97 @@ func (p *PluginNameWrapper) DisplayName() string {
 	return p.PluginDisplayName
 }
 
+// errors returned by RunCommandContext, a timeout wraps the context error too
+var (
+	ErrServerStopped  = fmt.Errorf("minecraft server is not running")
+	ErrLockFailed     = fmt.Errorf("failed to lock the console")
+	ErrCommandTimeout = fmt.Errorf("command timed out")
+	ErrUnknownCommand = fmt.Errorf("unknown or incomplete command")
+	ErrCommandFailed  = fmt.Errorf("command failed")
+)
+
+// CommandResult is the outcome of one command of a RunCommands batch.
+type CommandResult struct {
+	Command string
+	Output  string
+	Err     error
+}
+
 type PluginManager interface {
 	Printf(scope string, format string, a ...any) (n int, err error)
 	Println(scope string, a ...any) (n int, err error)
 	RegisterLogProcesser(context PluginName, process func(logmsg string, iscommandrespone bool)) (channel chan *manager.MessageResponse)
+	RegisterEventHandler(context PluginName, event string, handler func(GameEvent)) (unsubscribe func())
 	RegisterManagerMessageChannel() (channel chan *manager.MessageResponse)
 	RegisterPlugin(plugin Plugin) (p Plugin, err error)
+	RegisterPlugins(plugins ...Plugin) error
+	UnregisterPlugin(pluginName string) error
+	ReloadPlugin(pluginName string) error
+	DisablePlugin(pluginName string) error
+	EnablePlugin(pluginName string) error
 	GetPlugin(pluginName string) Plugin
+	// TrackResource records something registered for a plugin, release is
+	// called when the plugin is unloaded
+	TrackResource(context PluginName, kind string, name string, release func())
+	// PluginContext is cancelled when the plugin is unloaded, Go runs a worker
+	// with it that unloading waits for
+	PluginContext(owner PluginName) context.Context
+	Go(owner PluginName, worker func(ctx context.Context))
 	UnRegisterManagerMessageChannel(channel chan *manager.MessageResponse)
 
 	RunCommand(cmd string) string
+	RunCommandContext(ctx context.Context, cmd string) (string, error)
+	RunCommands(cmds []string) []CommandResult
+	RunCommandsContext(ctx context.Context, cmds []string) []CommandResult
 
 	Status(opts ...grpc.CallOption) (*manager.StatusResponse, error)
+	Metrics(ctx context.Context, interval time.Duration, opts ...grpc.CallOption) (manager.Manager_MetricsClient, error)
 	Stop(opts ...grpc.CallOption) (*emptypb.Empty, error)
 	StartMinecraft() (err error)
 }
+
+// CommandPriority picks the lane of the command queue, interactive commands
+// run before normal ones and background commands only when nothing else waits.
+type CommandPriority int
+
+const (
+	PriorityNormal CommandPriority = iota
+	PriorityInteractive
+	PriorityBackground
+)
+
+func (p CommandPriority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBackground:
+		return "background"
+	}
+	return "normal"
+}
+
+type commandPriorityKey struct{}
+type commandSourceKey struct{}
+
+// WithCommandPriority sets the lane of the commands run with ctx.
+func WithCommandPriority(ctx context.Context, priority CommandPriority) context.Context {
+	return context.WithValue(ctx, commandPriorityKey{}, priority)
+}
+
+// CommandPriorityFrom returns the priority set on ctx, ok is false when there
+// is none.
+func CommandPriorityFrom(ctx context.Context) (priority CommandPriority, ok bool) {
+	priority, ok = ctx.Value(commandPriorityKey{}).(CommandPriority)
+	return priority, ok
+}
+
+// WithCommandSource names who runs the commands, usually the plugin name.
+// Sources of a lane take turns.
+func WithCommandSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, commandSourceKey{}, source)
+}
+
+func CommandSourceFrom(ctx context.Context) string {
+	source, _ := ctx.Value(commandSourceKey{}).(string)
+	return source
+}
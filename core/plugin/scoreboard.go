@@ -15,6 +15,7 @@
 package plugin
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/binary"
 	"fmt"
@@ -44,20 +45,18 @@ const MaxTriggerCount = 1024
 
 type ScoreboardCore struct {
 	BasePlugin
-	score       map[string]map[string]int64
-	scorelist   []string
-	trigger     map[string]MinecraftTrigger
-	triggerInfo *regexp.Regexp
-	lock        sync.RWMutex
-	debounce    *time.Timer
+	score     map[string]map[string]int64
+	scorelist []string
+	trigger   map[string]MinecraftTrigger
+	lock      sync.RWMutex
+	debounce  *time.Timer
 }
 
 func (sc *ScoreboardCore) Init(pm pluginabi.PluginManager) error {
 	sc.BasePlugin.Init(pm, sc)
 	sc.score = make(map[string]map[string]int64)
 	sc.trigger = make(map[string]MinecraftTrigger)
-	sc.triggerInfo = regexp.MustCompile(`.*?\]:(?: \[[^\]]+\])? ?\[(\w+): ?Triggered ?\[(.*?)\] ?(?:\(set value to (\d+)\)|\(added (\d+) to value\))?\]`)
-	sc.RegisterLogProcesser(sc.processTrigger)
+	pluginabi.Subscribe(pm, sc, sc.processTrigger)
 	return nil
 }
 
@@ -88,25 +87,21 @@ func (sc *ScoreboardCore) cleanExpiredTrigger() {
 	}
 	sc.lock.Unlock()
 	if len(cleanupTransaction) > 0 {
-		sc.RunCommand(strings.Join(cleanupTransaction, "\n"))
+		sc.runTransaction(cleanupTransaction)
 	}
 }
 
-func (sc *ScoreboardCore) processTrigger(logText string, _ bool) {
-	triggerInfo := sc.triggerInfo.FindStringSubmatch(logText)
-	if len(triggerInfo) < 5 {
-		return
-	}
-	value := 0
-	player := strings.TrimSpace(triggerInfo[1])
-	trigger := strings.TrimSpace(triggerInfo[2])
-	if triggerInfo[3] != "" {
-		parsedvalue, _ := strconv.ParseInt(triggerInfo[3], 10, 0)
-		value = int(parsedvalue)
-	} else if triggerInfo[4] != "" {
-		parsedvalue, _ := strconv.ParseInt(triggerInfo[4], 10, 0)
-		value = int(parsedvalue)
+// runTransaction runs commands as one batch and reports the ones that failed.
+func (sc *ScoreboardCore) runTransaction(commands []string) {
+	for _, result := range sc.RunCommands(commands) {
+		if result.Err != nil {
+			sc.Println(color.RedString("命令执行失败: "), color.YellowString(result.Command), color.RedString(" (%v)", result.Err))
+		}
 	}
+}
+
+func (sc *ScoreboardCore) processTrigger(event pluginabi.TriggerFired) {
+	player, trigger, value := event.Player, event.Objective, event.Value
 	sc.lock.RLock()
 	triggerEntry, ok := sc.trigger[trigger]
 	sc.lock.RUnlock()
@@ -152,6 +147,10 @@ func (sc *ScoreboardCore) getNamespace(context pluginabi.PluginName) string {
 	return base64.RawURLEncoding.EncodeToString(bhash[4:])[:5]
 }
 
+func (sc *ScoreboardCore) Depends() []string {
+	return nil
+}
+
 func (sc *ScoreboardCore) Name() string {
 	return "ScoreboardCore"
 }
@@ -167,7 +166,9 @@ func (sc *ScoreboardCore) requestSync() {
 	if sc.debounce != nil {
 		sc.debounce.Reset(1 * time.Second)
 	}
-	sc.debounce = time.AfterFunc(1*time.Second, sc.syncScore)
+	sc.debounce = time.AfterFunc(1*time.Second, func() {
+		sc.syncScore(pluginabi.WithCommandPriority(context.Background(), pluginabi.PriorityBackground))
+	})
 }
 
 func (sc *ScoreboardCore) displayScoreboard(context pluginabi.PluginName, name string, slot string) {
@@ -206,7 +207,7 @@ func (sc *ScoreboardCore) getOneScore(context pluginabi.PluginName, player strin
 
 func (sc *ScoreboardCore) getAllScore() (scores map[string]map[string]int64) {
 	scores = map[string]map[string]int64{}
-	sc.syncScore()
+	sc.syncScore(context.Background())
 	sc.lock.RLock()
 	maps.Copy(scores, sc.score)
 	sc.lock.RUnlock()
@@ -270,10 +271,29 @@ func (sc *ScoreboardCore) registerTrigger(context pluginabi.PluginName, trigger
 		color.YellowString(" 注册了%d个 (Autogenerated)", len(trigger)),
 		color.YellowString("触发器"),
 	)
-	sc.RunCommand(strings.Join(commandTransaction, "\n"))
+	sc.runTransaction(commandTransaction)
+	sc.pm.TrackResource(context, "trigger", strings.Join(name, ","), func() {
+		sc.removeTrigger(name)
+	})
 	return name
 }
 
+// removeTrigger drops the triggers that have not expired yet.
+func (sc *ScoreboardCore) removeTrigger(names []string) {
+	commandTransaction := []string{}
+	sc.lock.Lock()
+	for _, name := range names {
+		if _, ok := sc.trigger[name]; ok {
+			delete(sc.trigger, name)
+			commandTransaction = append(commandTransaction, fmt.Sprintf("scoreboard objectives remove %s", name))
+		}
+	}
+	sc.lock.Unlock()
+	if len(commandTransaction) > 0 {
+		sc.runTransaction(commandTransaction)
+	}
+}
+
 func (sc *ScoreboardCore) clearTrigger() {
 	triggerListStr := sc.RunCommand("scoreboard objectives list")
 	triggerStrList := strings.Split(triggerListStr, ":")
@@ -292,12 +312,12 @@ func (sc *ScoreboardCore) clearTrigger() {
 	if len(commandList) == 0 {
 		return
 	}
-	sc.RunCommand(strings.Join(commandList, "\n"))
+	sc.runTransaction(commandList)
 
 }
 
-func (sc *ScoreboardCore) syncScore() {
-	trackedPlayersStr := sc.RunCommand("scoreboard players list")
+func (sc *ScoreboardCore) syncScore(ctx context.Context) {
+	trackedPlayersStr, _ := sc.RunCommandContext(ctx, "scoreboard players list")
 	if ScoreboardTrackedPlayer.MatchString(trackedPlayersStr) {
 		trackedPlayers := lo.Map(strings.Split(ScoreboardTrackedPlayer.FindStringSubmatch(trackedPlayersStr)[1], ","), func(item string, index int) string {
 			return strings.TrimSpace(item)
@@ -309,7 +329,7 @@ func (sc *ScoreboardCore) syncScore() {
 				sc.score[player] = make(map[string]int64)
 			}
 			for _, score := range sc.scorelist {
-				scoreResult := sc.RunCommand(fmt.Sprintf(`scoreboard players get %s %s`, player, score))
+				scoreResult, _ := sc.RunCommandContext(ctx, fmt.Sprintf(`scoreboard players get %s %s`, player, score))
 				scoreMatch := ScoreboardTrackedPlayerScore.FindStringSubmatch(scoreResult)
 				if len(scoreMatch) == 2 {
 					scoreValue, err := strconv.ParseInt(scoreMatch[1], 10, 64)
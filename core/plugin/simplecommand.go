@@ -16,7 +16,6 @@ package plugin
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 	"sync"
 
@@ -26,7 +25,6 @@ import (
 
 type SimpleCommand struct {
 	BasePlugin
-	playerCommand    *regexp.Regexp
 	registerCommands map[string]func(string, ...string)
 	lock             sync.RWMutex
 }
@@ -36,9 +34,8 @@ func (sp *SimpleCommand) Init(pm pluginabi.PluginManager) (err error) {
 	if err != nil {
 		return err
 	}
-	sp.RegisterLogProcesser(sp.processCommand)
-	sp.playerCommand = regexp.MustCompile(`.*?\]:(?: \[[^\]]+\])? <(.*?)>.*?!!(.*)`)
 	sp.registerCommands = make(map[string]func(string, ...string))
+	pluginabi.Subscribe(pm, sp, sp.processCommand)
 	return nil
 }
 
@@ -48,6 +45,9 @@ func (sp *SimpleCommand) RegisterCommand(context pluginabi.PluginName, command s
 	if _, ok := sp.registerCommands[command]; !ok {
 		sp.Println(color.YellowString("插件 "), color.BlueString(context.DisplayName()), color.YellowString(" 注册了一条新命令: "), color.GreenString(command))
 		sp.registerCommands[command] = commandFunc
+		sp.pm.TrackResource(context, "command", command, func() {
+			sp.unregisterCommand(context, command)
+		})
 	} else {
 		sp.Println(color.YellowString("插件 "), color.BlueString(context.DisplayName()), color.RedString(" 尝试注册已注册的命令: "), color.GreenString(command))
 		return fmt.Errorf("command exist")
@@ -55,13 +55,22 @@ func (sp *SimpleCommand) RegisterCommand(context pluginabi.PluginName, command s
 	return nil
 }
 
-func (sp *SimpleCommand) processCommand(logText string, _ bool) {
-	cmdInfo := sp.playerCommand.FindStringSubmatch(logText)
-	if len(cmdInfo) < 3 {
+func (sp *SimpleCommand) unregisterCommand(context pluginabi.PluginName, command string) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	if _, ok := sp.registerCommands[command]; ok {
+		sp.Println(color.YellowString("插件 "), color.BlueString(context.DisplayName()), color.YellowString(" 注销了命令: "), color.GreenString(command))
+		delete(sp.registerCommands, command)
+	}
+}
+
+func (sp *SimpleCommand) processCommand(event pluginabi.ChatMessage) {
+	_, rawCommand, ok := strings.Cut(event.Message, "!!")
+	if !ok {
 		return
 	}
-	player := strings.TrimSpace(cmdInfo[1])
-	rawCommand := strings.TrimSpace(cmdInfo[2])
+	player := strings.TrimSpace(event.Player)
+	rawCommand = strings.TrimSpace(rawCommand)
 	commandPart := strings.Split(rawCommand, " ")
 	command := commandPart[0]
 	sp.lock.RLock()
@@ -72,6 +81,10 @@ func (sp *SimpleCommand) processCommand(logText string, _ bool) {
 	}
 }
 
+func (sp *SimpleCommand) Depends() []string {
+	return nil
+}
+
 func (sp *SimpleCommand) Name() string {
 	return "SimpleCommand"
 }
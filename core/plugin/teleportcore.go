@@ -36,6 +36,8 @@ func (tc *TeleportCore) Init(pm pluginabi.PluginManager) (err error) {
 	if err != nil {
 		return err
 	}
+	// teleports are requested by a player waiting in game
+	tc.SetCommandPriority(pluginabi.PriorityInteractive)
 	return nil
 }
 
@@ -80,6 +82,10 @@ func (tc *TeleportCore) Teleport(src string, dst any) error {
 	return nil
 }
 
+func (tc *TeleportCore) Depends() []string {
+	return []string{"PlayerInfo"}
+}
+
 func (tc *TeleportCore) Name() string {
 	return "TeleportCore"
 }
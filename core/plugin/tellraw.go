@@ -30,6 +30,10 @@ func (tm *TellrawManager) DisplayName() string {
 	return "命令回显"
 }
 
+func (tm *TellrawManager) Depends() []string {
+	return []string{"ScoreboardCore"}
+}
+
 func (tm *TellrawManager) Name() string {
 	return "TellrawManager"
 }
@@ -0,0 +1,125 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/tellraw"
+)
+
+// MinOperatorLevel is the ops.json level needed for the in-game !!plugin.
+const MinOperatorLevel = 3
+
+var pluginStateNames = map[string]string{
+	"running":  "运行中",
+	"loaded":   "已加载",
+	"waiting":  "等待依赖",
+	"disabled": "已停用",
+}
+
+// PluginAdminPlugin gives server operators "!!plugin" in the game chat, the
+// same command the REPL has.
+type PluginAdminPlugin struct {
+	plugin.BasePlugin
+	pm *MinecraftPluginManager
+}
+
+func (pa *PluginAdminPlugin) Name() string {
+	return "PluginAdmin"
+}
+
+func (pa *PluginAdminPlugin) DisplayName() string {
+	return "插件管理"
+}
+
+func (pa *PluginAdminPlugin) Init(pm pluginabi.PluginManager) (err error) {
+	pa.pm = pm.(*MinecraftPluginManager)
+	err = pa.BasePlugin.Init(pm, pa)
+	if err != nil {
+		return err
+	}
+	return pa.RegisterCommand("plugin", pa.command)
+}
+
+func (pa *PluginAdminPlugin) command(player string, args ...string) {
+	if !pa.pm.isOperator(player) {
+		pa.Tellraw(player, []tellraw.Message{{Text: "权限不足", Color: tellraw.Red}})
+		return
+	}
+	if len(args) == 0 || args[0] == "list" {
+		for _, state := range pa.pm.PluginStates() {
+			message := []tellraw.Message{
+				{Text: state.Name, Color: tellraw.Aqua},
+				{Text: fmt.Sprintf("(%s) ", state.DisplayName), Color: tellraw.Yellow},
+				{Text: pluginStateNames[state.State], Color: pluginStateColor(state.State)},
+			}
+			if state.Blocked != nil {
+				message = append(message, tellraw.Message{Text: " 阻塞: " + state.Blocked.Error(), Color: tellraw.Red})
+			}
+			pa.Tellraw(player, message)
+		}
+		return
+	}
+	if len(args) != 2 {
+		pa.Tellraw(player, []tellraw.Message{{Text: pluginCommandUsage, Color: tellraw.Yellow}})
+		return
+	}
+	if err := pa.pm.pluginAction(args[0], args[1]); err != nil {
+		pa.Tellraw(player, []tellraw.Message{{Text: "操作失败: ", Color: tellraw.Red}, {Text: err.Error(), Color: tellraw.Yellow}})
+		return
+	}
+	pa.Tellraw(player, []tellraw.Message{{Text: "操作成功", Color: tellraw.Green}})
+}
+
+func pluginStateColor(state string) tellraw.Color {
+	switch state {
+	case "running":
+		return tellraw.Green
+	case "loaded":
+		return tellraw.Aqua
+	case "waiting":
+		return tellraw.Yellow
+	}
+	return tellraw.Red
+}
+
+// isOperator reports whether player is in the server's ops.json with at least
+// MinOperatorLevel.
+func (mpm *MinecraftPluginManager) isOperator(player string) bool {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(mpm.serverProperties()), "ops.json"))
+	if err != nil {
+		return false
+	}
+	var ops []struct {
+		Name  string `json:"name"`
+		Level int    `json:"level"`
+	}
+	if json.Unmarshal(data, &ops) != nil {
+		return false
+	}
+	for _, op := range ops {
+		if strings.EqualFold(op.Name, player) && op.Level >= MinOperatorLevel {
+			return true
+		}
+	}
+	return false
+}
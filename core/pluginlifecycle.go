@@ -0,0 +1,335 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"github.com/fatih/color"
+)
+
+// PluginStopTimeout bounds how long unloading a plugin waits for its workers.
+const PluginStopTimeout = 10 * time.Second
+
+var (
+	ErrPluginNotFound      = fmt.Errorf("plugin not registered")
+	ErrPluginProtected     = fmt.Errorf("plugin can not be unloaded")
+	ErrPluginDisabled      = fmt.Errorf("plugin is disabled")
+	ErrUnknownPluginAction = fmt.Errorf("unknown plugin action")
+)
+
+// pluginResource is something a plugin registered, released when the plugin
+// is unloaded.
+type pluginResource struct {
+	kind    string
+	name    string
+	release func()
+}
+
+// PluginState is one line of "!!plugin list".
+type PluginState struct {
+	Name        string
+	DisplayName string
+	State       string   // running, loaded, waiting or disabled
+	Resources   []string // "kind name" of what the plugin registered
+	Blocked     error    // why a waiting plugin is not loaded
+}
+
+// TrackResource records something registered on behalf of a plugin, release
+// is called when the plugin is unloaded. Registrations of plugins that are not
+// registered with the manager are not tracked.
+func (mpm *MinecraftPluginManager) TrackResource(context pluginabi.PluginName, kind string, name string, release func()) {
+	pm := mpm.trackedPlugin(context)
+	if pm == nil {
+		return
+	}
+	pm.resourceLock.Lock()
+	pm.resources = append(pm.resources, pluginResource{kind: kind, name: name, release: release})
+	pm.resourceLock.Unlock()
+}
+
+// releaseResources undoes the registrations of the plugin, the latest first.
+func (pm *PluginManager) releaseResources() int {
+	pm.resourceLock.Lock()
+	resources := pm.resources
+	pm.resources = nil
+	pm.resourceLock.Unlock()
+	for i := len(resources) - 1; i >= 0; i-- {
+		resources[i].release()
+	}
+	return len(resources)
+}
+
+// PluginContext returns the context of the current load of the plugin, it is
+// cancelled when the plugin is unloaded. Plugins that are not registered get
+// one that is never cancelled.
+func (mpm *MinecraftPluginManager) PluginContext(owner pluginabi.PluginName) context.Context {
+	if pm := mpm.trackedPlugin(owner); pm != nil {
+		pm.resourceLock.Lock()
+		defer pm.resourceLock.Unlock()
+		if pm.ctx != nil {
+			return pm.ctx
+		}
+	}
+	return context.Background()
+}
+
+// Go runs worker for the plugin with its PluginContext, unloading the plugin
+// waits up to PluginStopTimeout for the worker to return.
+func (mpm *MinecraftPluginManager) Go(owner pluginabi.PluginName, worker func(ctx context.Context)) {
+	ctx := mpm.PluginContext(owner)
+	pm := mpm.trackedPlugin(owner)
+	if pm == nil {
+		go worker(ctx)
+		return
+	}
+	pm.workers.Add(1)
+	go func() {
+		defer pm.workers.Done()
+		worker(ctx)
+	}()
+}
+
+func (mpm *MinecraftPluginManager) trackedPlugin(owner pluginabi.PluginName) *PluginManager {
+	if owner == nil {
+		return nil
+	}
+	mpm.pluginLock.RLock()
+	defer mpm.pluginLock.RUnlock()
+	return mpm.plugins[owner.Name()]
+}
+
+// stop cancels the context of the plugin, releases its resources and waits
+// for its workers.
+func (pm *PluginManager) stop(mpm *MinecraftPluginManager) (released int) {
+	pm.resourceLock.Lock()
+	cancel := pm.cancel
+	pm.resourceLock.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	released = pm.releaseResources()
+	done := make(chan struct{})
+	go func() {
+		pm.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(PluginStopTimeout):
+		mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pm.plugin.DisplayName()), color.RedString(" 的后台任务在 %s 内未退出", PluginStopTimeout))
+	}
+	return released
+}
+
+// unload pauses the plugin and stops what it registered or started. It stays
+// registered and can be initialised again.
+func (mpm *MinecraftPluginManager) unload(pm *PluginManager) {
+	pm.Pause()
+	released := pm.stop(mpm)
+	if pm.inited {
+		mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pm.plugin.DisplayName()), color.YellowString(" 已卸载, 释放资源: "), color.GreenString("%d", released))
+	}
+	pm.inited = false
+}
+
+// dependents returns the loaded plugins that depend on name directly or
+// through other plugins, the ones depending on the others first.
+func (mpm *MinecraftPluginManager) dependents(name string) (list []*PluginManager) {
+	mpm.pluginLock.RLock()
+	defer mpm.pluginLock.RUnlock()
+	names := slices.Sorted(maps.Keys(mpm.plugins))
+	seen := map[string]bool{name: true}
+	var visit func(name string)
+	visit = func(name string) {
+		for _, dependent := range names {
+			pm := mpm.plugins[dependent]
+			if seen[dependent] || !pm.inited || !slices.Contains(dependencyNames(pm.plugin), name) {
+				continue
+			}
+			seen[dependent] = true
+			visit(dependent)
+			list = append(list, pm)
+		}
+	}
+	visit(name)
+	return list
+}
+
+// suspendDependents unloads the plugins that depend on pm, they are
+// initialised again once it is back, or right away by loadPlugins if they only
+// depend on it optionally.
+func (mpm *MinecraftPluginManager) suspendDependents(pm *PluginManager) {
+	for _, dependent := range mpm.dependents(pm.plugin.Name()) {
+		mpm.kPrintln(color.YellowString("插件 "), color.BlueString(dependent.plugin.DisplayName()), color.YellowString(" 依赖的 "), color.BlueString(pm.plugin.DisplayName()), color.YellowString(" 不可用, 暂停插件"))
+		mpm.unload(dependent)
+	}
+}
+
+// lookupPlugin finds a plugin by name, case-insensitively if there is no
+// exact match.
+func (mpm *MinecraftPluginManager) lookupPlugin(name string) (*PluginManager, error) {
+	mpm.pluginLock.RLock()
+	defer mpm.pluginLock.RUnlock()
+	pm, ok := mpm.plugins[name]
+	if !ok {
+		for pluginName, candidate := range mpm.plugins {
+			if strings.EqualFold(pluginName, name) {
+				pm, ok = candidate, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPluginNotFound, name)
+	}
+	switch pm.plugin.(type) {
+	case *MinecraftCommandProcessor, *REPLPlugin:
+		return nil, fmt.Errorf("%w: %s", ErrPluginProtected, pm.plugin.Name())
+	}
+	return pm, nil
+}
+
+// UnregisterPlugin unloads the plugin and forgets it, the plugins depending
+// on it are paused until it is registered again.
+func (mpm *MinecraftPluginManager) UnregisterPlugin(name string) error {
+	mpm.lifecycleLock.Lock()
+	defer mpm.lifecycleLock.Unlock()
+	pm, err := mpm.lookupPlugin(name)
+	if err != nil {
+		return err
+	}
+	mpm.suspendDependents(pm)
+	mpm.unload(pm)
+	mpm.pluginLock.Lock()
+	delete(mpm.plugins, pm.plugin.Name())
+	mpm.pluginLock.Unlock()
+	mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pm.plugin.DisplayName()), color.YellowString(" 已注销"))
+	mpm.reportPlugins(mpm.loadPlugins())
+	return nil
+}
+
+// DisablePlugin unloads the plugin but keeps it registered, EnablePlugin
+// loads it again.
+func (mpm *MinecraftPluginManager) DisablePlugin(name string) error {
+	mpm.lifecycleLock.Lock()
+	defer mpm.lifecycleLock.Unlock()
+	pm, err := mpm.lookupPlugin(name)
+	if err != nil {
+		return err
+	}
+	if pm.disabled {
+		return nil
+	}
+	mpm.suspendDependents(pm)
+	mpm.unload(pm)
+	pm.disabled = true
+	mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pm.plugin.DisplayName()), color.YellowString(" 已停用"))
+	mpm.reportPlugins(mpm.loadPlugins())
+	return nil
+}
+
+func (mpm *MinecraftPluginManager) EnablePlugin(name string) error {
+	mpm.lifecycleLock.Lock()
+	defer mpm.lifecycleLock.Unlock()
+	pm, err := mpm.lookupPlugin(name)
+	if err != nil {
+		return err
+	}
+	if !pm.disabled {
+		return nil
+	}
+	pm.disabled = false
+	mpm.kPrintln(color.YellowString("插件 "), color.BlueString(pm.plugin.DisplayName()), color.YellowString(" 已启用"))
+	res := mpm.loadPlugins()
+	mpm.reportPlugins(res)
+	return res.err(pm.plugin.Name())
+}
+
+// ReloadPlugin unloads the plugin and runs its Init again, the plugins
+// depending on it are reloaded after it.
+func (mpm *MinecraftPluginManager) ReloadPlugin(name string) error {
+	mpm.lifecycleLock.Lock()
+	defer mpm.lifecycleLock.Unlock()
+	pm, err := mpm.lookupPlugin(name)
+	if err != nil {
+		return err
+	}
+	if pm.disabled {
+		return fmt.Errorf("%w: %s", ErrPluginDisabled, pm.plugin.Name())
+	}
+	mpm.kPrintln(color.YellowString("正在重载插件 "), color.BlueString(pm.plugin.DisplayName()))
+	mpm.suspendDependents(pm)
+	mpm.unload(pm)
+	res := mpm.loadPlugins()
+	mpm.reportPlugins(res)
+	return res.err(pm.plugin.Name())
+}
+
+// PluginStates lists the registered plugins by name.
+func (mpm *MinecraftPluginManager) PluginStates() (states []PluginState) {
+	mpm.lifecycleLock.Lock()
+	defer mpm.lifecycleLock.Unlock()
+	res := mpm.resolvePlugins()
+	mpm.pluginLock.RLock()
+	defer mpm.pluginLock.RUnlock()
+	for _, name := range slices.Sorted(maps.Keys(mpm.plugins)) {
+		pm := mpm.plugins[name]
+		state := PluginState{Name: name, DisplayName: pm.plugin.DisplayName()}
+		switch {
+		case pm.disabled:
+			state.State = "disabled"
+		case pm.started:
+			state.State = "running"
+		case pm.inited:
+			state.State = "loaded"
+		default:
+			state.State = "waiting"
+		}
+		if !pm.inited && !pm.disabled {
+			state.Blocked = res.blocked[name]
+		}
+		pm.resourceLock.Lock()
+		for _, resource := range pm.resources {
+			state.Resources = append(state.Resources, resource.kind+" "+resource.name)
+		}
+		pm.resourceLock.Unlock()
+		states = append(states, state)
+	}
+	return states
+}
+
+// pluginAction runs "!!plugin <action> <name>" of the admin commands.
+func (mpm *MinecraftPluginManager) pluginAction(action string, name string) error {
+	switch action {
+	case "enable":
+		return mpm.EnablePlugin(name)
+	case "disable":
+		return mpm.DisablePlugin(name)
+	case "reload":
+		return mpm.ReloadPlugin(name)
+	case "unload", "unregister":
+		return mpm.UnregisterPlugin(name)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownPluginAction, action)
+}
+
+const pluginCommandUsage = "!!plugin list | !!plugin enable|disable|reload|unload <插件名>"
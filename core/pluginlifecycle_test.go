@@ -0,0 +1,174 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/rcon"
+)
+
+// workerPlugin starts a worker and tracks a resource on every load.
+type workerPlugin struct {
+	loads    atomic.Int32
+	running  atomic.Int32
+	released atomic.Int32
+	ctx      context.Context
+}
+
+func (p *workerPlugin) Name() string        { return "Worker" }
+func (p *workerPlugin) DisplayName() string { return "Worker" }
+func (p *workerPlugin) Depends() []string   { return nil }
+func (p *workerPlugin) Version() string     { return "1.0.0" }
+func (p *workerPlugin) Start()              {}
+func (p *workerPlugin) Pause()              {}
+
+func (p *workerPlugin) Init(pm pluginabi.PluginManager) error {
+	p.loads.Add(1)
+	p.ctx = pm.PluginContext(p)
+	started := make(chan struct{})
+	pm.Go(p, func(ctx context.Context) {
+		p.running.Add(1)
+		defer p.running.Add(-1)
+		close(started)
+		<-ctx.Done()
+	})
+	<-started
+	pm.TrackResource(p, "test", "resource", func() { p.released.Add(1) })
+	return nil
+}
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestPluginUnloadReload(t *testing.T) {
+	mpm := NewPluginManager()
+	worker := &workerPlugin{}
+	address := freeAddress(t)
+	rconServer := &RconServerPlugin{Address: address, Password: "secret", AuditLog: filepath.Join(t.TempDir(), "audit.log")}
+	if err := mpm.RegisterPlugins(worker, rconServer); err != nil {
+		t.Fatal(err)
+	}
+	first := worker.ctx
+	if worker.loads.Load() != 1 || worker.running.Load() != 1 {
+		t.Fatalf("after load: %d loads, %d workers", worker.loads.Load(), worker.running.Load())
+	}
+
+	if err := mpm.ReloadPlugin("Worker"); err != nil {
+		t.Fatal(err)
+	}
+	if first.Err() == nil {
+		t.Error("context of the first load is not cancelled")
+	}
+	if worker.ctx.Err() != nil {
+		t.Error("context of the second load is cancelled")
+	}
+	if worker.loads.Load() != 2 || worker.running.Load() != 1 || worker.released.Load() != 1 {
+		t.Fatalf("after reload: %d loads, %d workers, %d released", worker.loads.Load(), worker.running.Load(), worker.released.Load())
+	}
+
+	// the old listener has to be gone before the new one binds the address
+	for range 2 {
+		if err := mpm.ReloadPlugin("RconServer"); err != nil {
+			t.Fatalf("reload RconServer: %v", err)
+		}
+		conn, err := rcon.Dial(address, "secret", time.Second)
+		if err != nil {
+			t.Fatalf("dial reloaded RconServer: %v", err)
+		}
+		conn.Close()
+	}
+
+	if err := mpm.UnregisterPlugin("Worker"); err != nil {
+		t.Fatal(err)
+	}
+	if worker.running.Load() != 0 || worker.released.Load() != 2 {
+		t.Fatalf("after unregister: %d workers, %d released", worker.running.Load(), worker.released.Load())
+	}
+	if err := mpm.DisablePlugin("RconServer"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rcon.Dial(address, "secret", time.Second); err == nil {
+		t.Fatal("RconServer still listening after disable")
+	}
+	if err := mpm.ReloadPlugin("RconServer"); !errors.Is(err, ErrPluginDisabled) {
+		t.Fatalf("reload disabled plugin: %v, want %v", err, ErrPluginDisabled)
+	}
+}
+
+// startPlugin counts how often it is started without a pause in between.
+type startPlugin struct {
+	running atomic.Int32
+	twice   atomic.Bool
+}
+
+func (p *startPlugin) Name() string                          { return "Starter" }
+func (p *startPlugin) DisplayName() string                   { return "Starter" }
+func (p *startPlugin) Depends() []string                     { return nil }
+func (p *startPlugin) Version() string                       { return "1.0.0" }
+func (p *startPlugin) Init(pm pluginabi.PluginManager) error { return nil }
+func (p *startPlugin) Pause()                                { p.running.Add(-1) }
+
+func (p *startPlugin) Start() {
+	if p.running.Add(1) > 1 {
+		p.twice.Store(true)
+	}
+}
+
+func TestPluginReloadWhileStarting(t *testing.T) {
+	mpm := NewPluginManager()
+	starter := &startPlugin{}
+	if err := mpm.RegisterPlugins(starter); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				mpm.pluginStart()
+			} else {
+				mpm.pluginPause(true)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			mpm.ReloadPlugin("Starter")
+		}()
+	}
+	wg.Wait()
+	if starter.twice.Load() {
+		t.Fatal("Start was called twice without a Pause")
+	}
+	if running := starter.running.Load(); running != 0 && running != 1 {
+		t.Fatalf("%d starts without a pause", running)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"github.com/fatih/color"
+)
+
+// why a plugin is blocked, wrapped with the dependency
+var (
+	ErrDependencyMissing  = fmt.Errorf("dependency not registered")
+	ErrDependencyDisabled = fmt.Errorf("dependency disabled")
+	ErrDependencyVersion  = fmt.Errorf("dependency version not satisfied")
+	ErrDependencyCycle    = fmt.Errorf("cyclic dependency")
+	ErrDependencyBlocked  = fmt.Errorf("dependency not loaded")
+)
+
+type pluginEdge struct {
+	to       string
+	optional bool
+}
+
+// pluginResolution is the init order of the enabled plugins.
+type pluginResolution struct {
+	plugins  map[string]*PluginManager
+	edges    map[string][]pluginEdge
+	order    []*PluginManager // dependencies first, registration order breaks ties
+	blocked  map[string]error // plugins that can not be initialised and why
+	failed   map[string]error // plugins whose Init returned an error
+	loaded   []string         // plugins initialised by loadPlugins
+	warnings []string
+}
+
+// err returns why the plugin is not loaded, nil if it is.
+func (res *pluginResolution) err(name string) error {
+	if err, ok := res.failed[name]; ok {
+		return err
+	}
+	return res.blocked[name]
+}
+
+// dependencyNames returns the plugins plugin depends on, optional or not.
+func dependencyNames(plugin pluginabi.Plugin) (names []string) {
+	for _, spec := range plugin.Depends() {
+		if dep, err := pluginabi.ParseDependency(spec); err == nil {
+			names = append(names, dep.Name)
+		}
+	}
+	return names
+}
+
+// resolvePlugins checks the dependencies of every enabled plugin and orders
+// the ones that can be initialised. A missing, disabled or mismatched
+// required dependency blocks a plugin, an optional one is ignored. Cycles
+// through optional dependencies are broken, the other cycles are blocked.
+func (mpm *MinecraftPluginManager) resolvePlugins() (res pluginResolution) {
+	mpm.pluginLock.RLock()
+	all := maps.Clone(mpm.plugins)
+	mpm.pluginLock.RUnlock()
+	res.plugins = all
+	res.edges = make(map[string][]pluginEdge)
+	res.blocked = make(map[string]error)
+	res.failed = make(map[string]error)
+
+	var names []string
+	for name, pm := range all {
+		if !pm.disabled {
+			names = append(names, name)
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Compare(all[a].seq, all[b].seq)
+	})
+
+	for _, name := range names {
+		for _, spec := range all[name].plugin.Depends() {
+			dep, err := pluginabi.ParseDependency(spec)
+			if err != nil {
+				res.blocked[name] = err
+				break
+			}
+			target, ok := all[dep.Name]
+			switch {
+			case !ok && dep.Optional, ok && target.disabled && dep.Optional:
+				continue
+			case !ok:
+				err = fmt.Errorf("%w: %s", ErrDependencyMissing, dep.Name)
+			case target.disabled:
+				err = fmt.Errorf("%w: %s", ErrDependencyDisabled, dep.Name)
+			case !dep.Constraint.Check(target.plugin.Version()):
+				err = fmt.Errorf("%w: %s %s, found %s", ErrDependencyVersion, dep.Name, dep.Constraint, target.plugin.Version())
+				if dep.Optional {
+					res.warnings = append(res.warnings, fmt.Sprintf("%s: %v", name, err))
+					continue
+				}
+			}
+			if err != nil {
+				res.blocked[name] = err
+				break
+			}
+			res.edges[name] = append(res.edges[name], pluginEdge{to: dep.Name, optional: dep.Optional})
+		}
+	}
+
+	for cycle := findPluginCycle(names, res.edges, res.blocked); cycle != nil; cycle = findPluginCycle(names, res.edges, res.blocked) {
+		path := strings.Join(cycle, " -> ")
+		dropped := false
+		for i := 0; i < len(cycle)-1 && !dropped; i++ {
+			from, to := cycle[i], cycle[i+1]
+			idx := slices.IndexFunc(res.edges[from], func(e pluginEdge) bool { return e.to == to && e.optional })
+			if idx >= 0 {
+				res.edges[from] = slices.Delete(res.edges[from], idx, idx+1)
+				res.warnings = append(res.warnings, fmt.Sprintf("optional dependency cycle %s, %s is initialised without waiting for %s", path, from, to))
+				dropped = true
+			}
+		}
+		if !dropped {
+			for _, name := range cycle[:len(cycle)-1] {
+				res.blocked[name] = fmt.Errorf("%w: %s", ErrDependencyCycle, path)
+			}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, name := range names {
+			if _, ok := res.blocked[name]; ok {
+				continue
+			}
+			for _, edge := range res.edges[name] {
+				if _, ok := res.blocked[edge.to]; ok && !edge.optional {
+					res.blocked[name] = fmt.Errorf("%w: %s", ErrDependencyBlocked, edge.to)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	placed := make(map[string]bool)
+	for progress := true; progress; {
+		progress = false
+		for _, name := range names {
+			if _, ok := res.blocked[name]; ok || placed[name] {
+				continue
+			}
+			ready := !slices.ContainsFunc(res.edges[name], func(e pluginEdge) bool {
+				_, blocked := res.blocked[e.to]
+				return !placed[e.to] && !(e.optional && blocked)
+			})
+			if ready {
+				placed[name] = true
+				res.order = append(res.order, all[name])
+				progress = true
+				break
+			}
+		}
+	}
+	return res
+}
+
+// findPluginCycle returns a dependency cycle as the path a -> ... -> a, nil if
+// there is none between the plugins that are not blocked.
+func findPluginCycle(names []string, edges map[string][]pluginEdge, blocked map[string]error) []string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var stack []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, edge := range edges[name] {
+			if _, ok := blocked[edge.to]; ok {
+				continue
+			}
+			switch state[edge.to] {
+			case visiting:
+				return append(slices.Clone(stack[slices.Index(stack, edge.to):]), edge.to)
+			case 0:
+				if cycle := visit(edge.to); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		return nil
+	}
+	for _, name := range names {
+		if _, ok := blocked[name]; !ok && state[name] == 0 {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// loadPlugins initialises the plugins that are ready, dependencies first.
+func (mpm *MinecraftPluginManager) loadPlugins() (res pluginResolution) {
+	res = mpm.resolvePlugins()
+	for _, pm := range res.order {
+		name := pm.plugin.Name()
+		if pm.inited {
+			continue
+		}
+		for _, edge := range res.edges[name] {
+			if dependency := res.plugins[edge.to]; !edge.optional && !dependency.inited {
+				res.blocked[name] = fmt.Errorf("%w: %s", ErrDependencyBlocked, edge.to)
+				break
+			}
+		}
+		if _, ok := res.blocked[name]; ok {
+			continue
+		}
+		if err := pm.Init(mpm); err != nil {
+			res.failed[name] = err
+			continue
+		}
+		res.loaded = append(res.loaded, name)
+	}
+	return res
+}
+
+// reportPlugins prints what loadPlugins did and the plugins left waiting.
+func (mpm *MinecraftPluginManager) reportPlugins(res pluginResolution) {
+	for _, warning := range res.warnings {
+		mpm.kPrintln(color.YellowString("依赖警告: "), color.MagentaString(warning))
+	}
+	if len(res.loaded) > 0 {
+		mpm.kPrintln(color.YellowString("插件加载顺序: "), color.GreenString(strings.Join(res.loaded, " -> ")))
+	}
+	waiting := 0
+	for _, name := range slices.Sorted(maps.Keys(res.blocked)) {
+		if res.plugins[name].inited {
+			continue
+		}
+		waiting++
+		mpm.kPrintln(color.YellowString("插件 "), color.BlueString(res.plugins[name].plugin.DisplayName()), color.RedString(" 被阻塞: "), color.MagentaString(res.blocked[name].Error()))
+	}
+	mpm.kPrintln(color.YellowString("依赖解析完成, 加载: "), color.GreenString("%d", len(res.loaded)), color.YellowString(" 失败: "), color.RedString("%d", len(res.failed)), color.YellowString(" 阻塞: "), color.RedString("%d", waiting))
+}
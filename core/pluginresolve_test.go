@@ -0,0 +1,157 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+)
+
+// depPlugin appends its name to inits when it is initialised.
+type depPlugin struct {
+	name    string
+	version string
+	depends []string
+	inits   *[]string
+}
+
+func (p *depPlugin) Name() string        { return p.name }
+func (p *depPlugin) DisplayName() string { return p.name }
+func (p *depPlugin) Depends() []string   { return p.depends }
+func (p *depPlugin) Start()              {}
+func (p *depPlugin) Pause()              {}
+
+func (p *depPlugin) Version() string {
+	if p.version == "" {
+		return "1.0.0"
+	}
+	return p.version
+}
+
+func (p *depPlugin) Init(pluginabi.PluginManager) error {
+	*p.inits = append(*p.inits, p.name)
+	return nil
+}
+
+// registerDeps registers plugins given as name and dependency specs in one
+// batch and returns the order they were initialised in.
+func registerDeps(t *testing.T, mpm *MinecraftPluginManager, plugins ...*depPlugin) []string {
+	t.Helper()
+	var inits []string
+	batch := make([]pluginabi.Plugin, len(plugins))
+	for i, plugin := range plugins {
+		plugin.inits = &inits
+		batch[i] = plugin
+	}
+	if err := mpm.RegisterPlugins(batch...); err != nil {
+		t.Fatal(err)
+	}
+	return inits
+}
+
+func expectBlocked(t *testing.T, res pluginResolution, name string, want error) {
+	t.Helper()
+	if err := res.err(name); !errors.Is(err, want) {
+		t.Errorf("%s blocked by %v, want %v", name, err, want)
+	}
+}
+
+func TestResolveOrder(t *testing.T) {
+	mpm := NewPluginManager()
+	inits := registerDeps(t, mpm,
+		&depPlugin{name: "C", depends: []string{"B"}},
+		&depPlugin{name: "B", depends: []string{"A >=1.0"}},
+		&depPlugin{name: "D"},
+		&depPlugin{name: "A"},
+		&depPlugin{name: "E", depends: []string{"?Optional", "?D"}},
+	)
+	// dependencies first, registration order between the ready ones
+	if want := []string{"D", "A", "B", "C", "E"}; !slices.Equal(inits, want) {
+		t.Fatalf("init order %v, want %v", inits, want)
+	}
+}
+
+func TestResolveLateDependency(t *testing.T) {
+	mpm := NewPluginManager()
+	if inits := registerDeps(t, mpm, &depPlugin{name: "B", depends: []string{"A"}}); len(inits) != 0 {
+		t.Fatalf("B initialised without A: %v", inits)
+	}
+	expectBlocked(t, mpm.resolvePlugins(), "B", ErrDependencyMissing)
+	var inits []string
+	mpm.plugins["B"].plugin.(*depPlugin).inits = &inits
+	a := &depPlugin{name: "A", inits: &inits}
+	if err := mpm.RegisterPlugins(a); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "B"}; !slices.Equal(inits, want) {
+		t.Fatalf("init order %v, want %v", inits, want)
+	}
+}
+
+func TestResolveCycle(t *testing.T) {
+	mpm := NewPluginManager()
+	inits := registerDeps(t, mpm,
+		&depPlugin{name: "X", depends: []string{"Y"}},
+		&depPlugin{name: "Y", depends: []string{"X"}},
+		&depPlugin{name: "Z", depends: []string{"X"}},
+		&depPlugin{name: "P", depends: []string{"?Q"}},
+		&depPlugin{name: "Q", depends: []string{"P"}},
+	)
+	// the optional edge P -> Q is dropped, Q still waits for P
+	if want := []string{"P", "Q"}; !slices.Equal(inits, want) {
+		t.Fatalf("init order %v, want %v", inits, want)
+	}
+	res := mpm.resolvePlugins()
+	expectBlocked(t, res, "X", ErrDependencyCycle)
+	expectBlocked(t, res, "Y", ErrDependencyCycle)
+	expectBlocked(t, res, "Z", ErrDependencyBlocked)
+	if len(res.warnings) != 1 {
+		t.Errorf("warnings %q, want the dropped optional edge", res.warnings)
+	}
+}
+
+func TestResolveConstraints(t *testing.T) {
+	mpm := NewPluginManager()
+	inits := registerDeps(t, mpm,
+		&depPlugin{name: "Lib", version: "v1.4.2-beta+git"},
+		&depPlugin{name: "Fits", depends: []string{"Lib >=1.4.2-alpha, <2"}},
+		&depPlugin{name: "TooNew", depends: []string{"Lib >=1.4.2"}},
+		&depPlugin{name: "Excluded", depends: []string{"Lib !=1.4.2-beta"}},
+		&depPlugin{name: "OptionalTooNew", depends: []string{"?Lib >=2"}},
+		&depPlugin{name: "Missing", depends: []string{"Nope"}},
+		&depPlugin{name: "Invalid", depends: []string{"Lib >=one"}},
+		&depPlugin{name: "Chained", depends: []string{"TooNew"}},
+	)
+	if want := []string{"Lib", "Fits", "OptionalTooNew"}; !slices.Equal(inits, want) {
+		t.Fatalf("init order %v, want %v", inits, want)
+	}
+	res := mpm.resolvePlugins()
+	expectBlocked(t, res, "TooNew", ErrDependencyVersion)
+	expectBlocked(t, res, "Excluded", ErrDependencyVersion)
+	expectBlocked(t, res, "Missing", ErrDependencyMissing)
+	expectBlocked(t, res, "Invalid", pluginabi.ErrInvalidDependency)
+	expectBlocked(t, res, "Chained", ErrDependencyBlocked)
+	if len(res.warnings) != 1 {
+		t.Errorf("warnings %q, want the optional version mismatch", res.warnings)
+	}
+
+	if err := mpm.DisablePlugin("Lib"); err != nil {
+		t.Fatal(err)
+	}
+	expectBlocked(t, mpm.resolvePlugins(), "Fits", ErrDependencyDisabled)
+}
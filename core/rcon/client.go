@@ -0,0 +1,186 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rcon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrAuthFailed      = fmt.Errorf("rcon authentication failed")
+	ErrCommandTooLong  = fmt.Errorf("rcon command too long")
+	ErrConnectionStale = fmt.Errorf("rcon connection closed by the server")
+	ErrCommandLost     = fmt.Errorf("rcon connection lost after the command was sent")
+)
+
+// how long Exec looks for a close of the server before sending a command
+const probeTimeout = time.Millisecond
+
+// Conn is one authenticated RCON connection, it is not safe for concurrent
+// use.
+type Conn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	nextId  int32
+	Timeout time.Duration // per Exec, 0 for none
+}
+
+func Dial(address string, password string, timeout time.Duration) (*Conn, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{conn: conn, reader: bufio.NewReader(conn), nextId: 1, Timeout: timeout}
+	if err := c.auth(password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Conn) deadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if c.Timeout > 0 && (!ok || time.Now().Add(c.Timeout).Before(deadline)) {
+		deadline, ok = time.Now().Add(c.Timeout), true
+	}
+	if !ok {
+		deadline = time.Time{}
+	}
+	c.conn.SetDeadline(deadline)
+}
+
+func (c *Conn) id() int32 {
+	id := c.nextId
+	c.nextId++
+	if c.nextId <= 0 {
+		c.nextId = 1
+	}
+	return id
+}
+
+func (c *Conn) auth(password string) error {
+	c.deadline(context.Background())
+	id := c.id()
+	if err := WritePacket(c.conn, Packet{Id: id, Type: TypeAuth, Body: password}); err != nil {
+		return err
+	}
+	for {
+		p, err := ReadPacket(c.reader)
+		if err != nil {
+			return err
+		}
+		// some servers send an empty response first
+		if p.Type != TypeAuthResponse {
+			continue
+		}
+		if p.Id != id {
+			return ErrAuthFailed
+		}
+		return nil
+	}
+}
+
+// Exec runs command and returns its output. The server splits long output
+// into several packets without marking the last one, so an invalid request
+// follows the command, the server's answer to it ends the output.
+//
+// ErrConnectionStale means the server closed the connection before the
+// command was sent, it is safe to run it again on a new connection.
+// ErrCommandLost means the connection broke after that, the server may have
+// run the command.
+func (c *Conn) Exec(command string) (string, error) {
+	return c.ExecContext(context.Background(), command)
+}
+
+// ExecContext is Exec bounded by ctx, the connection is unusable once ctx
+// ended in the middle of a command.
+func (c *Conn) ExecContext(ctx context.Context, command string) (string, error) {
+	if len(command) > MaxRequestBody {
+		return "", ErrCommandTooLong
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := c.probe(); err != nil {
+		return "", err
+	}
+	c.deadline(ctx)
+	stop := context.AfterFunc(ctx, func() {
+		c.conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+	id, endId := c.id(), c.id()
+	var request bytes.Buffer
+	WritePacket(&request, Packet{Id: id, Type: TypeCommand, Body: command})
+	WritePacket(&request, Packet{Id: endId, Type: TypeResponse})
+	if n, err := c.conn.Write(request.Bytes()); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if n == 0 {
+			return "", stale(err)
+		}
+		return "", fmt.Errorf("%w: %w", ErrCommandLost, err)
+	}
+	var output strings.Builder
+	for {
+		p, err := ReadPacket(c.reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", fmt.Errorf("%w: %w", ErrCommandLost, err)
+		}
+		switch p.Id {
+		case id:
+			output.WriteString(p.Body)
+		case endId:
+			return output.String(), nil
+		}
+	}
+}
+
+// probe reports a close of the server that is already waiting on the idle
+// connection, e.g. across a restart. Once the command is written the close
+// can no longer be told apart from one after the server ran it.
+func (c *Conn) probe() error {
+	c.conn.SetReadDeadline(time.Now().Add(probeTimeout))
+	_, err := c.reader.Peek(1)
+	if err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+		return nil
+	}
+	return stale(err)
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func stale(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return fmt.Errorf("%w: %w", ErrConnectionStale, err)
+	}
+	return err
+}
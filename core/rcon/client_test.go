@@ -0,0 +1,253 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testPassword = "secret"
+
+// serve runs server as a stub on address, 127.0.0.1:0 picks a free port.
+func serve(t *testing.T, address string, server *Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Password = testPassword
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+func echo(session *Session, command string) string {
+	return "echo " + command
+}
+
+func TestDialAuthFailed(t *testing.T) {
+	address := serve(t, "127.0.0.1:0", &Server{Handler: echo})
+	if _, err := Dial(address, "wrong", time.Second); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("Dial with a wrong password: %v, want %v", err, ErrAuthFailed)
+	}
+	c, err := Dial(address, testPassword, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if output, err := c.Exec("list"); err != nil || output != "echo list" {
+		t.Fatalf("Exec = %q, %v", output, err)
+	}
+}
+
+func TestServerAuthFailureDelay(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	address := serve(t, "127.0.0.1:0", &Server{Handler: echo, AuthFailureDelay: delay})
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	start := time.Now()
+	if err := WritePacket(conn, Packet{Id: 1, Type: TypeAuth, Body: "wrong"}); err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	p, err := ReadPacket(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Id != -1 {
+		t.Fatalf("answer to a wrong password has id %d, want -1", p.Id)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Fatalf("wrong password answered after %s, want at least %s", elapsed, delay)
+	}
+	// no second guess on the same connection
+	WritePacket(conn, Packet{Id: 2, Type: TypeAuth, Body: testPassword})
+	if _, err := ReadPacket(reader); err == nil {
+		t.Fatal("connection still open after a wrong password")
+	}
+}
+
+func TestServerSessionContext(t *testing.T) {
+	var sessions sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	started, returned := make(chan struct{}), make(chan struct{})
+	server := &Server{
+		Handler: func(session *Session, command string) string {
+			close(started)
+			<-session.Context().Done()
+			close(returned)
+			return "cancelled"
+		},
+		Go: func(serve func(ctx context.Context)) {
+			sessions.Add(1)
+			go func() {
+				defer sessions.Done()
+				serve(ctx)
+			}()
+		},
+	}
+	address := serve(t, "127.0.0.1:0", server)
+	c, err := Dial(address, testPassword, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	go c.Exec("block")
+	<-started
+	// the context passed to Go ends the running command
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command still running after the context was cancelled")
+	}
+	server.Close()
+	sessions.Wait()
+}
+
+func TestExecSplitResponse(t *testing.T) {
+	long := strings.Repeat("0123456789", MaxResponseBody/4)
+	address := serve(t, "127.0.0.1:0", &Server{Handler: func(session *Session, command string) string {
+		if command == "long" {
+			return long
+		}
+		return "short"
+	}})
+	c, err := Dial(address, testPassword, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for range 3 {
+		output, err := c.Exec("long")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output != long {
+			t.Fatalf("got %d bytes, want %d", len(output), len(long))
+		}
+		// the next command must not see the rest of the previous one
+		if output, err := c.Exec("next"); err != nil || output != "short" {
+			t.Fatalf("Exec after split response = %q, %v", output, err)
+		}
+	}
+}
+
+func TestPoolReplacesStaleConnection(t *testing.T) {
+	first := &Server{Handler: echo}
+	address := serve(t, "127.0.0.1:0", first)
+	pool := NewPool(address, testPassword)
+	pool.Timeout = time.Second
+	defer pool.Close()
+	if _, err := pool.Exec("before"); err != nil {
+		t.Fatal(err)
+	}
+	if len(pool.idle) != 1 {
+		t.Fatalf("%d idle connections, want 1", len(pool.idle))
+	}
+
+	// the server restarts, the pooled connection is closed by the peer
+	first.Close()
+	var connects atomic.Int32
+	serve(t, address, &Server{
+		Handler:   func(session *Session, command string) string { return "restarted " + command },
+		OnConnect: func(session *Session) { connects.Add(1) },
+	})
+
+	stale := pool.idle[0]
+	if _, err := stale.Exec("probe"); !errors.Is(err, ErrConnectionStale) {
+		t.Fatalf("Exec on a closed connection: %v, want %v", err, ErrConnectionStale)
+	}
+	output, err := pool.Exec("after")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "restarted after" {
+		t.Fatalf("Exec = %q", output)
+	}
+	if connects.Load() != 1 {
+		t.Fatalf("%d connections to the restarted server, want 1", connects.Load())
+	}
+}
+
+func TestPoolCommandLost(t *testing.T) {
+	var runs atomic.Int32
+	server := &Server{}
+	server.Handler = func(session *Session, command string) string {
+		if command != "stop" {
+			return "echo " + command
+		}
+		// the server runs the command and goes away before answering
+		runs.Add(1)
+		server.Close()
+		return "stopping"
+	}
+	address := serve(t, "127.0.0.1:0", server)
+	pool := NewPool(address, testPassword)
+	pool.Timeout = time.Second
+	defer pool.Close()
+	if _, err := pool.Exec("before"); err != nil {
+		t.Fatal(err)
+	}
+	// the pooled connection must not be replaced and the command sent again
+	if _, err := pool.Exec("stop"); !errors.Is(err, ErrCommandLost) {
+		t.Fatalf("Exec dropped after the command ran: %v, want %v", err, ErrCommandLost)
+	}
+	if runs.Load() != 1 {
+		t.Fatalf("command ran %d times, want 1", runs.Load())
+	}
+}
+
+func TestPoolRetryInterval(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	pool := NewPool(address, testPassword)
+	pool.Timeout = time.Second
+	pool.RetryInterval = 200 * time.Millisecond
+	defer pool.Close()
+	if _, err := pool.Exec("list"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Exec without a server: %v, want %v", err, ErrUnavailable)
+	}
+
+	var connects atomic.Int32
+	serve(t, address, &Server{Handler: echo, OnConnect: func(session *Session) { connects.Add(1) }})
+	if _, err := pool.Exec("list"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Exec within RetryInterval: %v, want %v", err, ErrUnavailable)
+	}
+	if connects.Load() != 0 {
+		t.Fatal("pool dialed within RetryInterval")
+	}
+
+	time.Sleep(pool.RetryInterval)
+	if output, err := pool.Exec("list"); err != nil || output != "echo list" {
+		t.Fatalf("Exec after RetryInterval = %q, %v", output, err)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rcon implements the Source RCON protocol spoken by Minecraft
+// servers: a pooled client, a server and the server.properties settings.
+package rcon
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	TypeResponse     int32 = 0
+	TypeCommand      int32 = 2
+	TypeAuthResponse int32 = 2
+	TypeAuth         int32 = 3
+)
+
+const (
+	// MaxResponseBody is the size the server splits responses at.
+	MaxResponseBody = 4096
+	// MaxRequestBody is the longest command Minecraft accepts.
+	MaxRequestBody = 1446
+	// id, type and the two terminating NULs
+	headerSize    = 10
+	maxPacketSize = headerSize + MaxResponseBody*4
+)
+
+var ErrBadPacket = fmt.Errorf("malformed rcon packet")
+
+type Packet struct {
+	Id   int32
+	Type int32
+	Body string
+}
+
+func ReadPacket(r *bufio.Reader) (p Packet, err error) {
+	var size int32
+	if err = binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return p, err
+	}
+	if size < headerSize || size > maxPacketSize {
+		return p, fmt.Errorf("%w: size %d", ErrBadPacket, size)
+	}
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return p, err
+	}
+	p.Id = int32(binary.LittleEndian.Uint32(buf[0:4]))
+	p.Type = int32(binary.LittleEndian.Uint32(buf[4:8]))
+	if buf[size-2] != 0 || buf[size-1] != 0 {
+		return p, fmt.Errorf("%w: missing terminator", ErrBadPacket)
+	}
+	p.Body = string(buf[8 : size-2])
+	return p, nil
+}
+
+func WritePacket(w io.Writer, p Packet) error {
+	buf := make([]byte, 4+headerSize+len(p.Body))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(headerSize+len(p.Body)))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(p.Id))
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(p.Type))
+	copy(buf[12:], p.Body)
+	_, err := w.Write(buf)
+	return err
+}
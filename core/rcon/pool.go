@@ -0,0 +1,146 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPoolSize      = 2
+	DefaultTimeout       = 10 * time.Second
+	DefaultRetryInterval = 5 * time.Second
+)
+
+var (
+	ErrUnavailable = fmt.Errorf("rcon unavailable")
+	ErrPoolClosed  = fmt.Errorf("rcon pool closed")
+)
+
+// Pool keeps up to Size idle connections to one server. Broken connections
+// are dropped and replaced on the next Exec, after a failed dial the server
+// counts as unavailable for RetryInterval so callers can fall back quickly.
+type Pool struct {
+	Address       string
+	Password      string
+	Size          int
+	Timeout       time.Duration
+	RetryInterval time.Duration
+
+	lock    sync.Mutex
+	idle    []*Conn
+	retryAt time.Time
+	lastErr error
+	closed  bool
+}
+
+func NewPool(address string, password string) *Pool {
+	return &Pool{
+		Address:       address,
+		Password:      password,
+		Size:          DefaultPoolSize,
+		Timeout:       DefaultTimeout,
+		RetryInterval: DefaultRetryInterval,
+	}
+}
+
+func (p *Pool) get() (c *Conn, pooled bool, err error) {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil, false, ErrPoolClosed
+	}
+	if n := len(p.idle); n > 0 {
+		c = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.lock.Unlock()
+		return c, true, nil
+	}
+	if time.Now().Before(p.retryAt) {
+		err = p.lastErr
+		p.lock.Unlock()
+		return nil, false, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	p.lock.Unlock()
+
+	c, err = Dial(p.Address, p.Password, p.Timeout)
+	if err != nil {
+		p.lock.Lock()
+		p.retryAt = time.Now().Add(p.RetryInterval)
+		p.lastErr = err
+		p.lock.Unlock()
+		return nil, false, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return c, false, nil
+}
+
+func (p *Pool) put(c *Conn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed || len(p.idle) >= p.Size {
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+}
+
+// Exec runs command on a pooled connection. A pooled connection the server
+// has closed in the meantime, e.g. across a restart, is replaced before the
+// command is sent. ErrCommandLost is returned as is, the command is not sent
+// again since the server may have run it already.
+func (p *Pool) Exec(command string) (string, error) {
+	return p.ExecContext(context.Background(), command)
+}
+
+func (p *Pool) ExecContext(ctx context.Context, command string) (string, error) {
+	for {
+		c, pooled, err := p.get()
+		if err != nil {
+			return "", err
+		}
+		output, err := c.ExecContext(ctx, command)
+		if err != nil {
+			c.Close()
+			if pooled && errors.Is(err, ErrConnectionStale) {
+				continue
+			}
+			return "", err
+		}
+		p.put(c)
+		return output, nil
+	}
+}
+
+// Reset drops the idle connections and forgets a failed dial.
+func (p *Pool) Reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, c := range p.idle {
+		c.Close()
+	}
+	p.idle = nil
+	p.retryAt = time.Time{}
+}
+
+func (p *Pool) Close() {
+	p.Reset()
+	p.lock.Lock()
+	p.closed = true
+	p.lock.Unlock()
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rcon
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const DefaultPort = 25575
+
+// Config is the RCON part of server.properties.
+type Config struct {
+	Enabled  bool
+	Address  string // host:port to connect to
+	Port     int
+	Password string
+}
+
+// LoadConfig reads the RCON settings from a server.properties file.
+func LoadConfig(path string) (Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer file.Close()
+	properties, err := ReadProperties(file)
+	if err != nil {
+		return Config{}, err
+	}
+	config := Config{
+		Enabled:  properties["enable-rcon"] == "true",
+		Port:     DefaultPort,
+		Password: properties["rcon.password"],
+	}
+	if port, err := strconv.Atoi(properties["rcon.port"]); err == nil && port > 0 {
+		config.Port = port
+	}
+	host := properties["server-ip"]
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	config.Address = net.JoinHostPort(host, strconv.Itoa(config.Port))
+	return config, nil
+}
+
+// ReadProperties parses a java .properties file as written by the server:
+// one key=value per line, # and ! start comments, backslash escapes.
+func ReadProperties(r io.Reader) (map[string]string, error) {
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	logical := ""
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical == "" && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+		// an odd number of trailing backslashes continues the line
+		trailing := len(line) - len(strings.TrimRight(line, `\`))
+		if trailing%2 == 1 {
+			logical += line[:len(line)-1]
+			continue
+		}
+		logical += line
+		key, value := splitProperty(logical)
+		properties[unescapeProperty(key)] = unescapeProperty(value)
+		logical = ""
+	}
+	return properties, scanner.Err()
+}
+
+func splitProperty(line string) (key string, value string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':', ' ', '\t', '\f':
+			value = strings.TrimLeft(line[i:], " \t\f")
+			if value != "" && (value[0] == '=' || value[0] == ':') {
+				value = value[1:]
+			}
+			return line[:i], strings.TrimLeft(value, " \t\f")
+		}
+	}
+	return line, ""
+}
+
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					b.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rcon
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestReadProperties(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{
+			name:  "equals",
+			input: "enable-rcon=true\nrcon.port = 25576\n",
+			want:  map[string]string{"enable-rcon": "true", "rcon.port": "25576"},
+		},
+		{
+			name:  "colon separator",
+			input: "rcon.password:secret\nmotd : A Server\n",
+			want:  map[string]string{"rcon.password": "secret", "motd": "A Server"},
+		},
+		{
+			name:  "space separator",
+			input: "rcon.password secret\nlevel-name\tworld\n",
+			want:  map[string]string{"rcon.password": "secret", "level-name": "world"},
+		},
+		{
+			name:  "empty value",
+			input: "server-ip=\nresource-pack\n",
+			want:  map[string]string{"server-ip": "", "resource-pack": ""},
+		},
+		{
+			name:  "comments",
+			input: "#Minecraft server properties\n! comment\n  # indented\n\nlevel-seed=1\n",
+			want:  map[string]string{"level-seed": "1"},
+		},
+		{
+			name:  "escapes",
+			input: `motd=A\u00A7bMinecraft\tServer\nline2` + "\n" + `key\=with\:sep=x\\y` + "\n" + `rcon.password=p\ w` + "\n",
+			want:  map[string]string{"motd": "A\u00a7bMinecraft\tServer\nline2", "key=with:sep": `x\y`, "rcon.password": "p w"},
+		},
+		{
+			name:  "continuation",
+			input: "motd=first \\\n    second \\\n\tthird\nnext=1\n",
+			want:  map[string]string{"motd": "first second third", "next": "1"},
+		},
+		{
+			name:  "escaped backslash is no continuation",
+			input: "path=C:\\\\\nnext=1\n",
+			want:  map[string]string{"path": `C:\`, "next": "1"},
+		},
+		{
+			name:  "continuation keeps a comment character",
+			input: "motd=a \\\n#b\n",
+			want:  map[string]string{"motd": "a #b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadProperties(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("ReadProperties = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Server answers RCON clients the way the Minecraft server does, commands are
+// passed to Handler once the client authenticated with Password. The On
+// callbacks are optional.
+type Server struct {
+	Password  string
+	Handler   func(session *Session, command string) string
+	OnConnect func(session *Session)
+	OnAuth    func(session *Session, ok bool)
+	OnClose   func(session *Session)
+	// Go starts serving a connection, the context of the session is derived
+	// from ctx. A plain goroutine with context.Background by default.
+	Go func(serve func(ctx context.Context))
+	// AuthFailureDelay holds back the answer to a wrong password and closes
+	// the connection after it, to slow down guessing. 0 answers at once and
+	// keeps the connection like the Minecraft server.
+	AuthFailureDelay time.Duration
+
+	lock      sync.Mutex
+	listeners []net.Listener
+	conns     map[net.Conn]context.CancelFunc
+	sessionId uint64
+	closed    bool
+}
+
+const DefaultAuthFailureDelay = 2 * time.Second
+
+// Session is one client connection.
+type Session struct {
+	Id            uint64
+	RemoteAddr    net.Addr
+	Connected     time.Time
+	Authenticated bool
+	Commands      int
+
+	ctx context.Context
+}
+
+// Context is cancelled once the connection is closed.
+func (session *Session) Context() context.Context {
+	return session.ctx
+}
+
+var ErrServerClosed = fmt.Errorf("rcon server closed")
+
+func (s *Server) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listeners = append(s.listeners, listener)
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]context.CancelFunc)
+	}
+	s.lock.Unlock()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.lock.Lock()
+			closed := s.closed
+			s.lock.Unlock()
+			if closed || errors.Is(err, net.ErrClosed) {
+				return ErrServerClosed
+			}
+			return err
+		}
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.sessionId++
+		session := &Session{Id: s.sessionId, RemoteAddr: conn.RemoteAddr(), Connected: time.Now()}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.conns[conn] = cancel
+		s.lock.Unlock()
+		if s.Go == nil {
+			go s.serveConn(ctx, conn, session)
+			continue
+		}
+		s.Go(func(base context.Context) {
+			// the context of the session ends with the connection or base
+			stop := context.AfterFunc(base, cancel)
+			defer stop()
+			s.serveConn(ctx, conn, session)
+		})
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn, session *Session) {
+	session.ctx = ctx
+	if s.OnConnect != nil {
+		s.OnConnect(session)
+	}
+	defer func() {
+		conn.Close()
+		s.lock.Lock()
+		cancel := s.conns[conn]
+		delete(s.conns, conn)
+		s.lock.Unlock()
+		cancel()
+		if s.OnClose != nil {
+			s.OnClose(session)
+		}
+	}()
+	reader := bufio.NewReader(conn)
+	for {
+		p, err := ReadPacket(reader)
+		if err != nil {
+			return
+		}
+		switch {
+		case p.Type == TypeAuth:
+			session.Authenticated = s.Password != "" && subtle.ConstantTimeCompare([]byte(p.Body), []byte(s.Password)) == 1
+			if s.OnAuth != nil {
+				s.OnAuth(session, session.Authenticated)
+			}
+			if session.Authenticated {
+				err = WritePacket(conn, Packet{Id: p.Id, Type: TypeAuthResponse})
+			} else if s.AuthFailureDelay > 0 {
+				select {
+				case <-time.After(s.AuthFailureDelay):
+					WritePacket(conn, Packet{Id: -1, Type: TypeAuthResponse})
+				case <-ctx.Done():
+				}
+				return
+			} else {
+				err = WritePacket(conn, Packet{Id: -1, Type: TypeAuthResponse})
+			}
+		case !session.Authenticated:
+			err = WritePacket(conn, Packet{Id: -1, Type: TypeAuthResponse})
+		case p.Type == TypeCommand:
+			session.Commands++
+			err = s.respond(conn, p.Id, s.Handler(session, p.Body))
+		default:
+			err = WritePacket(conn, Packet{Id: p.Id, Type: TypeResponse, Body: fmt.Sprintf("Unknown request %x", p.Type)})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// respond splits output into packets of at most MaxResponseBody bytes.
+func (s *Server) respond(conn net.Conn, id int32, output string) error {
+	for {
+		chunk := output
+		if len(chunk) > MaxResponseBody {
+			chunk = chunk[:MaxResponseBody]
+		}
+		output = output[len(chunk):]
+		if err := WritePacket(conn, Packet{Id: id, Type: TypeResponse, Body: chunk}); err != nil {
+			return err
+		}
+		if output == "" {
+			return nil
+		}
+	}
+}
+
+// Close stops the listeners and drops every connection, cancelling the
+// contexts of their sessions.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	for _, listener := range s.listeners {
+		listener.Close()
+	}
+	for conn, cancel := range s.conns {
+		conn.Close()
+		cancel()
+	}
+	return nil
+}
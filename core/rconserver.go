@@ -0,0 +1,186 @@
+// Copyright 2024 bbaa
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/rcon"
+	"github.com/fatih/color"
+)
+
+var ErrRconPasswordRequired = fmt.Errorf("rcon server needs a password")
+
+// RconServerPlugin lets RCON clients such as web panels or bots run console
+// commands through the command queue, so they are serialized with the plugins'
+// commands and get the captured output back.
+type RconServerPlugin struct {
+	Address  string
+	Password string
+	AuditLog string // JSON lines file recording every session, empty to only print
+
+	pm        *MinecraftPluginManager
+	server    *rcon.Server
+	auditLock sync.Mutex
+	audit     *os.File
+}
+
+// RconAuditEntry is one line of the audit log.
+type RconAuditEntry struct {
+	Time     time.Time `json:"time"`
+	Session  uint64    `json:"session"`
+	Remote   string    `json:"remote"`
+	Event    string    `json:"event"` // connect, auth, auth_failed, command or close
+	Command  string    `json:"command,omitempty"`
+	Response string    `json:"response,omitempty"`
+	Duration string    `json:"duration,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func (rs *RconServerPlugin) Depends() []string {
+	return nil
+}
+
+func (rs *RconServerPlugin) Version() string {
+	return "1.0.0"
+}
+
+func (rs *RconServerPlugin) Name() string {
+	return "RconServer"
+}
+
+func (rs *RconServerPlugin) DisplayName() string {
+	return "RCON 服务"
+}
+
+func (rs *RconServerPlugin) Println(a ...any) (int, error) {
+	return rs.pm.Println(color.MagentaString(rs.DisplayName()), a...)
+}
+
+func (rs *RconServerPlugin) Init(pm pluginabi.PluginManager) (err error) {
+	rs.pm = pm.(*MinecraftPluginManager)
+	if rs.Password == "" {
+		return ErrRconPasswordRequired
+	}
+	if rs.AuditLog != "" {
+		if err = os.MkdirAll(filepath.Dir(rs.AuditLog), 0755); err != nil {
+			return err
+		}
+		audit, err := os.OpenFile(rs.AuditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		// sessions of the previous load may still be closing
+		rs.auditLock.Lock()
+		rs.audit = audit
+		rs.auditLock.Unlock()
+		rs.pm.TrackResource(rs, "audit log", rs.AuditLog, rs.closeAudit)
+	}
+	listener, err := net.Listen("tcp", rs.Address)
+	if err != nil {
+		return err
+	}
+	server := &rcon.Server{
+		Password:  rs.Password,
+		Handler:   rs.command,
+		OnConnect: rs.connect,
+		OnAuth:    rs.auth,
+		OnClose:   rs.close,
+		// unloading waits for the sessions, their commands end with the plugin
+		Go:               func(serve func(ctx context.Context)) { rs.pm.Go(rs, serve) },
+		AuthFailureDelay: rcon.DefaultAuthFailureDelay,
+	}
+	rs.server = server
+	rs.pm.TrackResource(rs, "listener", listener.Addr().String(), func() { server.Close() })
+	rs.pm.Go(rs, func(context.Context) { server.Serve(listener) })
+	rs.Println(color.YellowString("RCON 服务监听于 "), color.GreenString(listener.Addr().String()))
+	return nil
+}
+
+func (rs *RconServerPlugin) Start() {
+}
+
+func (rs *RconServerPlugin) Pause() {
+}
+
+func (rs *RconServerPlugin) record(session *rcon.Session, entry RconAuditEntry) {
+	entry.Time = time.Now()
+	entry.Session = session.Id
+	entry.Remote = session.RemoteAddr.String()
+	line, _ := json.Marshal(entry)
+	rs.auditLock.Lock()
+	defer rs.auditLock.Unlock()
+	if rs.audit == nil {
+		return
+	}
+	rs.audit.Write(append(line, '\n'))
+}
+
+// closeAudit closes the audit log, the sessions closed after it are not
+// recorded.
+func (rs *RconServerPlugin) closeAudit() {
+	rs.auditLock.Lock()
+	defer rs.auditLock.Unlock()
+	rs.audit.Close()
+	rs.audit = nil
+}
+
+func (rs *RconServerPlugin) sessionName(session *rcon.Session) string {
+	return fmt.Sprintf("[%d %s]", session.Id, session.RemoteAddr)
+}
+
+func (rs *RconServerPlugin) connect(session *rcon.Session) {
+	rs.Println(color.YellowString("RCON 连接 "), color.GreenString(rs.sessionName(session)))
+	rs.record(session, RconAuditEntry{Event: "connect"})
+}
+
+func (rs *RconServerPlugin) auth(session *rcon.Session, ok bool) {
+	if ok {
+		rs.Println(color.YellowString("RCON 连接 "), color.GreenString(rs.sessionName(session)), color.GreenString(" 认证成功"))
+		rs.record(session, RconAuditEntry{Event: "auth"})
+		return
+	}
+	rs.Println(color.YellowString("RCON 连接 "), color.GreenString(rs.sessionName(session)), color.RedString(" 密码错误"))
+	rs.record(session, RconAuditEntry{Event: "auth_failed"})
+}
+
+func (rs *RconServerPlugin) command(session *rcon.Session, command string) string {
+	rs.Println(color.YellowString("RCON 连接 "), color.GreenString(rs.sessionName(session)), color.YellowString(" 执行命令: "), color.RedString(command))
+	start := time.Now()
+	ctx := pluginabi.WithCommandPriority(pluginabi.WithCommandSource(session.Context(), rs.Name()), pluginabi.PriorityInteractive)
+	response, err := rs.pm.RunCommandContext(ctx, command)
+	entry := RconAuditEntry{Event: "command", Command: command, Response: response, Duration: time.Since(start).String()}
+	if err != nil {
+		entry.Error = err.Error()
+		if response == "" {
+			response = err.Error()
+		}
+	}
+	rs.record(session, entry)
+	return response
+}
+
+func (rs *RconServerPlugin) close(session *rcon.Session) {
+	rs.Println(color.YellowString("RCON 连接 "), color.GreenString(rs.sessionName(session)), color.YellowString(" 断开, 共执行 %d 条命令", session.Commands))
+	rs.record(session, RconAuditEntry{Event: "close", Duration: time.Since(session.Connected).String()})
+}
@@ -15,11 +15,15 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
+	"github.com/fatih/color"
 	"golang.org/x/term"
 )
 
@@ -33,6 +37,10 @@ func (rp *REPLPlugin) Depends() []string {
 	return nil
 }
 
+func (rp *REPLPlugin) Version() string {
+	return "1.0.0"
+}
+
 func (rp *REPLPlugin) DisplayName() string {
 	return "终端命令"
 }
@@ -42,7 +50,63 @@ func (rp *REPLPlugin) Name() string {
 }
 
 func (rp *REPLPlugin) RunCommand(cmd string) string {
-	return rp.pm.RunCommand(cmd)
+	ctx := pluginabi.WithCommandPriority(pluginabi.WithCommandSource(context.Background(), rp.Name()), pluginabi.PriorityInteractive)
+	response, _ := rp.pm.RunCommandContext(ctx, cmd)
+	return response
+}
+
+// printQueueStats shows the command queue per source for "!!queue".
+func (rp *REPLPlugin) printQueueStats() {
+	show := func(a ...any) {
+		rp.pm.Println(color.MagentaString(rp.DisplayName()), a...)
+	}
+	stats := rp.pm.CommandQueueStats()
+	if len(stats) == 0 {
+		show(color.YellowString("命令队列为空"))
+		return
+	}
+	for _, entry := range stats {
+		show(
+			color.BlueString(entry.Source),
+			color.YellowString(" 排队(交互/普通/后台): "),
+			color.RedString("%d/%d/%d", entry.Queued[pluginabi.PriorityInteractive], entry.Queued[pluginabi.PriorityNormal], entry.Queued[pluginabi.PriorityBackground]),
+			color.YellowString(" 已执行: "), color.GreenString("%d", entry.Executed),
+			color.YellowString(" 平均等待: "), color.GreenString("%s", entry.AvgWait.Round(time.Millisecond)),
+			color.YellowString(" 最长等待: "), color.GreenString("%s", entry.MaxWait.Round(time.Millisecond)),
+		)
+	}
+}
+
+// pluginCommand lists, enables, disables, reloads or unloads plugins for
+// "!!plugin".
+func (rp *REPLPlugin) pluginCommand(args []string) {
+	show := func(a ...any) {
+		rp.pm.Println(color.MagentaString(rp.DisplayName()), a...)
+	}
+	if len(args) == 0 || args[0] == "list" {
+		for _, state := range rp.pm.PluginStates() {
+			line := []any{
+				color.BlueString(state.Name), color.YellowString("(%s) ", state.DisplayName),
+				color.GreenString(pluginStateNames[state.State]),
+				color.YellowString(" 资源: "), color.GreenString("%d", len(state.Resources)),
+			}
+			if state.Blocked != nil {
+				line = append(line, color.RedString(" 阻塞: %v", state.Blocked))
+			}
+			if len(state.Resources) > 0 {
+				line = append(line, color.CyanString(" (%s)", strings.Join(state.Resources, ", ")))
+			}
+			show(line...)
+		}
+		return
+	}
+	if len(args) != 2 {
+		show(color.YellowString(pluginCommandUsage))
+		return
+	}
+	if err := rp.pm.pluginAction(args[0], args[1]); err != nil {
+		show(color.RedString("操作失败: "), color.MagentaString(err.Error()))
+	}
 }
 
 func (rp *REPLPlugin) initTerminal() (t *term.Terminal, err error) {
@@ -64,7 +128,12 @@ func (rp *REPLPlugin) Init(pm pluginabi.PluginManager) (err error) {
 	if !ok {
 		return fmt.Errorf("can not get terminal")
 	}
-	rp.terminal, _ = rp.initTerminal()
+	rp.terminal, err = rp.initTerminal()
+	if err != nil {
+		// headless, e.g. under go test or a service manager
+		rp.pm.kPrintln(color.YellowString("标准输入不是终端, REPL 已禁用"))
+		return nil
+	}
 	go rp.worker()
 	return nil
 }
@@ -74,11 +143,19 @@ func (rp *REPLPlugin) worker() {
 		line, err := rp.terminal.ReadLine()
 		if err != nil {
 			if err == io.EOF {
-				os.Exit(0)
+				rp.pm.exit()
 			}
 		}
 		if line == "exit" {
-			os.Exit(0)
+			rp.pm.exit()
+		}
+		if line == "!!queue" {
+			rp.printQueueStats()
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == "!!plugin" {
+			rp.pluginCommand(fields[1:])
+			continue
 		}
 		if len(line) > 0 {
 			rp.RunCommand(line)
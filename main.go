@@ -16,19 +16,62 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/gamemanager"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/plugins"
+	"github.com/fatih/color"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 var StartScript = flag.String("script", "/home/bbaa/Minecraft/BountyHunter/run.sh", "start")
+var LaunchProfile = flag.String("launch", "", "JSON launch profile used instead of -script")
+var Instance = flag.String("instance", "", "GameManager instance name")
+var ManagerAddress = flag.String("manager", "127.0.0.1:12345", "GameManager address, host:port or unix:/path/to/socket")
+var ManagerToken = flag.String("token", "", "GameManager shared token (default $GAMEMANAGER_TOKEN)")
+var TLSCert = flag.String("tls-cert", "", "client certificate for mutual TLS")
+var TLSKey = flag.String("tls-key", "", "client private key for mutual TLS")
+var TLSCA = flag.String("tls-ca", "", "CA used to verify GameManager, enables TLS")
+var TLSServerName = flag.String("tls-server-name", "", "override the TLS server name")
+var ServerProperties = flag.String("properties", "", "server.properties with the RCON settings (default server.properties in the server directory)")
+var DisableRcon = flag.Bool("no-rcon", false, "send commands through the console even when the server enables RCON")
+var RconListen = flag.String("rcon-listen", "", "serve RCON clients on this address, commands go through the daemon's command queue")
+var RconPassword = flag.String("rcon-password", "", "password of -rcon-listen (default $DAEMON_RCON_PASSWORD)")
+var RconAudit = flag.String("rcon-audit", "rconaudit.log", "audit log of -rcon-listen sessions, empty to disable")
+var LogProfileName = flag.String("log-profile", "auto", "server log format: auto, vanilla, paper, purpur, forge, neoforge or fabric")
+var Embedded = flag.Bool("embedded", false, "run GameManager inside the daemon instead of connecting to -manager, the Minecraft server then stops with the daemon")
+var managerConfig = gamemanager.ConfigFlags(flag.CommandLine)
+var logProfile *core.LogProfile
 
 func main() {
 	flag.Parse()
+	var err error
+	logProfile, err = core.FindLogProfile(*LogProfileName)
+	if err != nil {
+		fmt.Println(color.RedString("%v", err))
+		os.Exit(1)
+	}
+	var embedded *gamemanager.Embedded
+	if *Embedded {
+		config, err := managerConfig()
+		if err != nil {
+			fmt.Println(color.RedString("%v", err))
+			os.Exit(1)
+		}
+		embedded = gamemanager.NewEmbedded(config)
+		go stopOnSignal(embedded)
+	}
 	go func() {
 		for {
-			err := createGameManager()
+			err := createGameManager(embedded)
 			if err != nil {
 				time.Sleep(5 * time.Second)
 				continue
@@ -39,16 +82,66 @@ func main() {
 	select {}
 }
 
-func createGameManager() error {
-	minecraftManagerClient := &core.MinecraftPluginManager{StartScript: *StartScript}
-	err := minecraftManagerClient.Dial("127.0.0.1:12345")
+func stopOnSignal(embedded *gamemanager.Embedded) {
+	sysSignals := make(chan os.Signal, 1)
+	signal.Notify(sysSignals, syscall.SIGINT, syscall.SIGTERM)
+	<-sysSignals
+	embedded.Close()
+	os.Exit(0)
+}
+
+func createGameManager(embedded *gamemanager.Embedded) error {
+	transport := &manager.TransportConfig{
+		Token:         *ManagerToken,
+		TLSCert:       *TLSCert,
+		TLSKey:        *TLSKey,
+		TLSCA:         *TLSCA,
+		TLSServerName: *TLSServerName,
+	}
+	if transport.Token == "" {
+		transport.Token = os.Getenv("GAMEMANAGER_TOKEN")
+	}
+	minecraftManagerClient := &core.MinecraftPluginManager{StartScript: *StartScript, Instance: *Instance, Transport: transport, ServerProperties: *ServerProperties, DisableRcon: *DisableRcon, LogProfile: logProfile}
+	if *LaunchProfile != "" {
+		profileJson, err := os.ReadFile(*LaunchProfile)
+		if err == nil {
+			minecraftManagerClient.LaunchProfile = &manager.LaunchProfile{}
+			err = protojson.Unmarshal(profileJson, minecraftManagerClient.LaunchProfile)
+		}
+		if err != nil {
+			fmt.Println(color.RedString("无法读取启动配置 %s: %v", *LaunchProfile, err))
+			return err
+		}
+	}
+	var err error
+	if embedded != nil {
+		gamemanager.Output = minecraftManagerClient.Output()
+		minecraftManagerClient.OnExit = embedded.Close
+		var conn *grpc.ClientConn
+		conn, err = embedded.Dial()
+		if err == nil {
+			err = minecraftManagerClient.Connect(conn)
+		}
+	} else {
+		err = minecraftManagerClient.Dial(*ManagerAddress)
+	}
 	if err != nil {
 		return err
 	}
-	minecraftManagerClient.RegisterPlugin(&plugins.TeleportPlugin{})
-	minecraftManagerClient.RegisterPlugin(&plugins.HomePlugin{})
-	minecraftManagerClient.RegisterPlugin(&plugins.BackPlugin{})
-	minecraftManagerClient.RegisterPlugin(&plugins.BackupPlugin{Source: "/home/bbaa/Minecraft/BountyHunter/world", Dest: "/home/bbaa/Minecraft/Backup/"})
-	minecraftManagerClient.RegisterPlugin(&plugins.StatusPlugin{MaxSentBandwidth: 50, MaxRecvBandwidth: 800})
+	pluginList := []pluginabi.Plugin{
+		&plugins.TeleportPlugin{},
+		&plugins.HomePlugin{},
+		&plugins.BackPlugin{},
+		&plugins.BackupPlugin{Source: "/home/bbaa/Minecraft/BountyHunter/world", Dest: "/home/bbaa/Minecraft/Backup/"},
+		&plugins.StatusPlugin{MaxSentBandwidth: 50, MaxRecvBandwidth: 800},
+	}
+	if *RconListen != "" {
+		password := *RconPassword
+		if password == "" {
+			password = os.Getenv("DAEMON_RCON_PASSWORD")
+		}
+		pluginList = append([]pluginabi.Plugin{&core.RconServerPlugin{Address: *RconListen, Password: password, AuditLog: *RconAudit}}, pluginList...)
+	}
+	minecraftManagerClient.RegisterPlugins(pluginList...)
 	return nil
 }
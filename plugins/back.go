@@ -16,24 +16,17 @@ package plugins
 
 import (
 	"fmt"
-	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
-	"unicode"
 
-	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core"
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin"
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/tellraw"
 	"github.com/fatih/color"
 )
 
-var MinecraftMessage = regexp.MustCompile(`\[.*?MinecraftServer.?\]: (.*)`)
-
-var DeathEventBlackList = []*regexp.Regexp{regexp.MustCompile(" has the following entity data"), regexp.MustCompile("trigger"), regexp.MustCompile("players online"), core.PlayerJoinLeaveMessage}
-
 type BackPlugin struct {
 	plugin.BasePlugin
 }
@@ -121,32 +114,12 @@ func (bp *BackPlugin) checkDeath(player string) {
 	}
 }
 
-func (bp *BackPlugin) deathEvent(logmsg string, iscmdrsp bool) {
-	if contentMatcher := MinecraftMessage.FindStringSubmatch(logmsg); len(contentMatcher) == 2 {
-		for _, black := range DeathEventBlackList {
-			if black.MatchString(logmsg) {
-				return
-			}
-		}
-		if iscmdrsp {
-			return
-		}
-		playerList := bp.GetPlayerList()
-		playerSelect := strings.Join(playerList, "|")
-		playerRegex, _ := regexp.Compile(fmt.Sprintf(`\b(%s)\b`, playerSelect))
-		playerMatcher := playerRegex.FindAllStringSubmatch(contentMatcher[1], -1)
-		time.Sleep(20 * time.Millisecond)
-		for _, matchPlayer := range playerMatcher {
-			player := matchPlayer[1]
-			matchIndex := strings.Index(logmsg, player)
-			if matchIndex >= 0 {
-				if !unicode.IsSpace([]rune(logmsg)[max(0, matchIndex-1)]) && !unicode.IsSpace([]rune(logmsg)[min(len(logmsg)-1, matchIndex+len(player))]) {
-					continue
-				}
-			}
-			bp.checkDeath(player)
-		}
-	}
+// deathEvent needs no guard against command responses any more, PlayerDied
+// only matches the death messages themselves and a death logged while a
+// command runs is still a death.
+func (bp *BackPlugin) deathEvent(event pluginabi.PlayerDied) {
+	time.Sleep(20 * time.Millisecond)
+	bp.checkDeath(event.Player)
 }
 
 func (bp *BackPlugin) Init(pm pluginabi.PluginManager) (err error) {
@@ -154,7 +127,7 @@ func (bp *BackPlugin) Init(pm pluginabi.PluginManager) (err error) {
 	if err != nil {
 		return err
 	}
-	bp.RegisterLogProcesser(bp.deathEvent)
+	pluginabi.Subscribe(pm, bp, bp.deathEvent)
 	bp.RegisterCommand("back", bp.back)
 	return nil
 }
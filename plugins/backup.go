@@ -15,6 +15,7 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
@@ -504,6 +505,10 @@ func (bp *BackupPlugin) Init(pm pluginabi.PluginManager) (err error) {
 			bp.MakeBackup("AutoBackup")
 		}
 	}), gocron.WithSingletonMode(gocron.LimitModeReschedule))
+	cron := bp.cron
+	bp.TrackResource("scheduler", "AutoBackup", func() {
+		cron.Shutdown()
+	})
 
 	bp.RegisterCommand("backup", bp.Cli)
 	return nil
@@ -521,24 +526,27 @@ func (bp *BackupPlugin) Start() {
 	if err != nil {
 		bp.TellrawError("@a", err)
 	}
-	go func() {
+	watcher := bp.fswatcher
+	bp.Go(func(ctx context.Context) {
 		for {
 			select {
-			case _, ok := <-bp.fswatcher.Events:
+			case <-ctx.Done():
+				return
+			case _, ok := <-watcher.Events:
 				if !ok {
 					return
 				}
 				if len(bp.GetPlayerList()) > 0 {
 					bp.MakePlayerDataBackup()
 				}
-			case err, ok := <-bp.fswatcher.Errors:
+			case err, ok := <-watcher.Errors:
 				if !ok {
 					return
 				}
 				bp.TellrawError("@a", err)
 			}
 		}
-	}()
+	})
 }
 
 func (bp *BackupPlugin) Pause() {
@@ -15,6 +15,7 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
@@ -22,9 +23,11 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core"
+	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/manager"
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin"
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/pluginabi"
 	"git.bbaa.fun/bbaa/minecraft-plugin-daemon/core/plugin/tellraw"
@@ -50,10 +53,11 @@ type StatusPlugin struct {
 	LastBroadcastMspt float64
 	LastMspt          []float64
 	ForgeTpsCommand   string
-	monitorStop       chan struct{}
 	MaxSentBandwidth  float64 // Mbps
 	MaxRecvBandwidth  float64 // Mbps
 	lastnetStat       *Status_NetStat
+	serverMetrics     atomic.Pointer[manager.ProcessMetrics]
+	workerCancel      context.CancelFunc // stops the workers of the current Start
 }
 
 type StatusPlugin_MinecraftLoad struct {
@@ -71,15 +75,8 @@ func (s *StatusPlugin) Name() string {
 	return "StatusPlugin"
 }
 
-func (s *StatusPlugin) Ping(logmsg string, iscmdrsp bool) {
-	if iscmdrsp {
-		return
-	}
-	ping := core.PlayerMessage.FindStringSubmatch(logmsg)
-	if len(ping) < 3 {
-		return
-	}
-	number, err := strconv.ParseInt(strings.TrimSpace(ping[2]), 10, 64)
+func (s *StatusPlugin) Ping(event pluginabi.ChatMessage) {
+	number, err := strconv.ParseInt(strings.TrimSpace(event.Message), 10, 64)
 	if err != nil {
 		return
 	}
@@ -96,7 +93,7 @@ func (s *StatusPlugin) Init(pm pluginabi.PluginManager) (err error) {
 		return err
 	}
 	s.RegisterCommand("status", s.status)
-	s.RegisterLogProcesser(s.Ping)
+	pluginabi.Subscribe(pm, s, s.Ping)
 	s.monitorSystem()
 	return nil
 }
@@ -311,6 +308,19 @@ func (s *StatusPlugin) status(player string, args ...string) {
 			{Text: fmt.Sprintf(" %.2f%%", cpu_usage_avg*100), Color: s.floatLevel(cpu_usage_avg)},
 		})
 	}
+	if metrics := s.serverMetrics.Load(); metrics != nil && cpu_count != 0 {
+		server_usage := metrics.CpuPercent / float64(cpu_count) / 100.0
+		usage_bar := int(math.RoundToEven(server_usage * 32.0))
+		s.Tellraw(`@a`, []tellraw.Message{
+			{Text: "服务器进程: ", Color: tellraw.Aqua},
+			{Text: "[", Color: tellraw.Yellow},
+			{Text: strings.Repeat("|", max(usage_bar, 0)), Color: tellraw.Red},
+			{Text: strings.Repeat("|", max(32-usage_bar, 0)), Color: tellraw.Green},
+			{Text: "]", Color: tellraw.Yellow},
+			{Text: fmt.Sprintf(" %.2f%%", server_usage*100), Color: s.floatLevel(server_usage)},
+			{Text: fmt.Sprintf(" %d 线程", metrics.Threads), Color: tellraw.Yellow},
+		})
+	}
 	system_load, err := load.Avg()
 	if err == nil && cpu_count != 0 {
 		load1, load5, load15 := system_load.Load1, system_load.Load5, system_load.Load15
@@ -394,10 +404,11 @@ func (s *StatusPlugin) testTPSCommand() {
 	}
 }
 
-func (s *StatusPlugin) monitorWorker() {
+func (s *StatusPlugin) monitorWorker(ctx context.Context) {
 	monitorTicker := time.NewTicker(10 * time.Second)
+	defer monitorTicker.Stop()
 	systemTicker := time.NewTicker(1 * time.Second)
-	s.monitorStop = make(chan struct{}, 1)
+	defer systemTicker.Stop()
 	for {
 		select {
 		case <-monitorTicker.C:
@@ -410,9 +421,25 @@ func (s *StatusPlugin) monitorWorker() {
 			if len(s.GetPlayerList()) > 0 {
 				s.monitorSystem()
 			}
-		case <-s.monitorStop:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *StatusPlugin) metricsWorker(ctx context.Context) {
+	stream, err := s.pm.Metrics(ctx, 2*time.Second)
+	if err != nil {
+		s.Println(color.RedString("无法订阅服务器资源监控: %v", err))
+		return
+	}
+	for {
+		metrics, err := stream.Recv()
+		if err != nil {
+			s.serverMetrics.Store(nil)
 			return
 		}
+		s.serverMetrics.Store(metrics)
 	}
 }
 
@@ -420,11 +447,15 @@ func (s *StatusPlugin) Start() {
 	if s.ForgeTpsCommand == "" {
 		s.testTPSCommand()
 	}
-	go s.monitorWorker()
+	ctx, cancel := context.WithCancel(s.Context())
+	s.workerCancel = cancel
+	s.Go(func(context.Context) { s.monitorWorker(ctx) })
+	s.Go(func(context.Context) { s.metricsWorker(ctx) })
 }
 
 func (s *StatusPlugin) Pause() {
-	if s.monitorStop != nil {
-		s.monitorStop <- struct{}{}
+	if s.workerCancel != nil {
+		s.workerCancel()
+		s.workerCancel = nil
 	}
 }